@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fuzz "github.com/google/gofuzz"
+	"github.com/konveyor/volume-snapshot-mover/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+const fuzzIterations = 1000
+
+func getFuzzer(t *testing.T) *fuzz.Fuzzer {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(rand.Int63()), serializer.NewCodecFactory(scheme))
+}
+
+func TestVolumeSnapshotBackupConversion(t *testing.T) {
+	f := getFuzzer(t)
+
+	t.Run("v1alpha1 -> v1beta1 -> v1alpha1", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			spoke := &VolumeSnapshotBackup{}
+			f.Fuzz(spoke)
+
+			hub := &v1beta1.VolumeSnapshotBackup{}
+			if err := spoke.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			got := &VolumeSnapshotBackup{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(spoke, got) {
+				t.Fatalf("round trip lost data: %s", cmp.Diff(spoke, got))
+			}
+		}
+	})
+
+	t.Run("v1beta1 -> v1alpha1 -> v1beta1", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			hub := &v1beta1.VolumeSnapshotBackup{}
+			f.Fuzz(hub)
+
+			spoke := &VolumeSnapshotBackup{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			got := &v1beta1.VolumeSnapshotBackup{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(hub, got) {
+				t.Fatalf("round trip lost data: %s", cmp.Diff(hub, got))
+			}
+		}
+	})
+}
+
+func TestVolumeSnapshotRestoreConversion(t *testing.T) {
+	f := getFuzzer(t)
+
+	t.Run("v1alpha1 -> v1beta1 -> v1alpha1", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			spoke := &VolumeSnapshotRestore{}
+			f.Fuzz(spoke)
+
+			hub := &v1beta1.VolumeSnapshotRestore{}
+			if err := spoke.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			got := &VolumeSnapshotRestore{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(spoke, got) {
+				t.Fatalf("round trip lost data: %s", cmp.Diff(spoke, got))
+			}
+		}
+	})
+
+	t.Run("v1beta1 -> v1alpha1 -> v1beta1", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			hub := &v1beta1.VolumeSnapshotRestore{}
+			f.Fuzz(hub)
+
+			spoke := &VolumeSnapshotRestore{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			got := &v1beta1.VolumeSnapshotRestore{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+
+			if !apiequality.Semantic.DeepEqual(hub, got) {
+				t.Fatalf("round trip lost data: %s", cmp.Diff(hub, got))
+			}
+		}
+	})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DataMoverConfigName is the name of the DataMoverConfig read by the controller,
+// DataMoverConfigs with any other name are ignored
+const DataMoverConfigName = "cluster"
+
+// DataMoverConfigSpec defines the desired state of DataMoverConfig
+type DataMoverConfigSpec struct {
+	// Maximum number of volumesnapshotbackups moving data at the same time.
+	// Defaults to the DATAMOVER_CONCURRENT_BACKUP env of the controller deployment
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentBackups *int32 `json:"concurrentBackups,omitempty"`
+	// Maximum number of volumesnapshotrestores moving data at the same time.
+	// Defaults to the DATAMOVER_CONCURRENT_RESTORE env of the controller deployment
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ConcurrentRestores *int32 `json:"concurrentRestores,omitempty"`
+	// Storage class of the volumes restored from a backed up storage class, by backed up storage class.
+	// The target storage classes must exist
+	// +optional
+	StorageClassMapping map[string]string `json:"storageClassMapping,omitempty"`
+	// Volume snapshot class of the volumes restored from a backed up volume snapshot class, by backed up
+	// volume snapshot class. The target volume snapshot classes must exist
+	// +optional
+	VolumeSnapshotClassMapping map[string]string `json:"volumeSnapshotClassMapping,omitempty"`
+	// Percentage added to the backed up size of the restored volumes, restored with the backed up size when unset
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	RestoreCapacityGrowthPercent *int32 `json:"restoreCapacityGrowthPercent,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=datamoverconfigs,scope=Cluster
+// +kubebuilder:printcolumn:name="Concurrent Backups",type=integer,JSONPath=".spec.concurrentBackups"
+// +kubebuilder:printcolumn:name="Concurrent Restores",type=integer,JSONPath=".spec.concurrentRestores"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// DataMoverConfig is the Schema for the datamoverconfigs API
+type DataMoverConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DataMoverConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DataMoverConfigList contains a list of DataMoverConfig
+type DataMoverConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DataMoverConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DataMoverConfig{}, &DataMoverConfigList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MigratedFromConfigMapAnnotation is set on the DataMoverStorageClassConfigs migrated from a
+// "<storageclass>-config" ConfigMap, to the name of the ConfigMap
+const MigratedFromConfigMapAnnotation = "datamover.oadp.openshift.io/migrated-from-configmap"
+
+// MigratedResourceVersionAnnotation is the resourceVersion of the ConfigMap a DataMoverStorageClassConfig
+// was migrated from, the configuration is migrated again when the ConfigMap changes
+const MigratedResourceVersionAnnotation = "datamover.oadp.openshift.io/migrated-resource-version"
+
+// DataMoverStorageClassConfigSpec defines the desired state of DataMoverStorageClassConfig
+type DataMoverStorageClassConfigSpec struct {
+	// Volume options of the volumesnapshotbackups of the PVCs of the storage class
+	// +optional
+	Source DataMoverVolumeOptions `json:"source,omitempty"`
+	// Volume options of the volumesnapshotrestores of the PVCs backed up from the storage class
+	// +optional
+	Destination DataMoverVolumeOptions `json:"destination,omitempty"`
+}
+
+// DataMoverVolumeOptions defines the volumes used to move the data of a PVC
+type DataMoverVolumeOptions struct {
+	// Storage class of the volume moved, defaults to the storage class of the PVC
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Access mode of the volume moved, defaults to the access modes of the PVC
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	// +optional
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+	// Storage class of the restic cache volume
+	// +optional
+	CacheStorageClassName string `json:"cacheStorageClassName,omitempty"`
+	// Access mode of the restic cache volume
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	// +optional
+	CacheAccessMode corev1.PersistentVolumeAccessMode `json:"cacheAccessMode,omitempty"`
+	// Capacity of the restic cache volume
+	// +optional
+	CacheCapacity *resource.Quantity `json:"cacheCapacity,omitempty"`
+	// Run the mover pod with the security context of the application pod using the PVC
+	// +kubebuilder:default=false
+	// +optional
+	MoverSecurityContext bool `json:"moverSecurityContext,omitempty"`
+}
+
+// DataMoverStorageClassConfigStatus defines the observed state of DataMoverStorageClassConfig
+type DataMoverStorageClassConfigStatus struct {
+	// Latest volumesnapshotbackups that used the configuration, as namespace/name
+	// +optional
+	VolumeSnapshotBackups []string `json:"volumeSnapshotBackups,omitempty"`
+	// Latest volumesnapshotrestores that used the configuration, as namespace/name
+	// +optional
+	VolumeSnapshotRestores []string `json:"volumeSnapshotRestores,omitempty"`
+	// LastUsedTimestamp records the time the configuration was last used
+	// +optional
+	LastUsedTimestamp *metav1.Time `json:"lastUsedTimestamp,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=datamoverstorageclassconfigs,shortName=dmscconfig
+// +kubebuilder:printcolumn:name="Last Used",type=date,JSONPath=".status.lastUsedTimestamp"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// DataMoverStorageClassConfig is the Schema for the datamoverstorageclassconfigs API. It is named after
+// the storage class it configures and read from the protected namespace of the volumesnapshotbackups
+// and volumesnapshotrestores
+type DataMoverStorageClassConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DataMoverStorageClassConfigSpec   `json:"spec,omitempty"`
+	Status DataMoverStorageClassConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DataMoverStorageClassConfigList contains a list of DataMoverStorageClassConfig
+type DataMoverStorageClassConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DataMoverStorageClassConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DataMoverStorageClassConfig{}, &DataMoverStorageClassConfigList{})
+}
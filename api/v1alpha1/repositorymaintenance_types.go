@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RepositoryMaintenanceSpec defines the desired state of RepositoryMaintenance
+type RepositoryMaintenanceSpec struct {
+	// Restic secret of the BSL holding the repositories and their credentials,
+	// the same secret referenced by the volumesnapshotbackups
+	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef"`
+	// Interval between two removals of the stale locks left by crashed mover pods,
+	// disabled when unset
+	// +optional
+	UnlockInterval *metav1.Duration `json:"unlockInterval,omitempty"`
+	// Interval between two restic prunes, disabled when unset
+	// +optional
+	PruneInterval *metav1.Duration `json:"pruneInterval,omitempty"`
+	// Interval between two restic checks, disabled when unset
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// RepositoryMaintenanceStatus defines the observed state of RepositoryMaintenance
+type RepositoryMaintenanceStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// repositorymaintenance phase status
+	Phase RepositoryMaintenancePhase `json:"phase,omitempty"`
+	// Results of the last run of every maintenance task
+	// +optional
+	Tasks []MaintenanceTaskStatus `json:"tasks,omitempty"`
+}
+
+// MaintenanceTask is a restic command run on every repository of the BSL
+type MaintenanceTask string
+
+const (
+	MaintenanceTaskUnlock MaintenanceTask = "unlock"
+
+	MaintenanceTaskPrune MaintenanceTask = "prune"
+
+	MaintenanceTaskCheck MaintenanceTask = "check"
+)
+
+// MaintenanceTaskStatus is the result of the last run of a maintenance task
+type MaintenanceTaskStatus struct {
+	// maintenance task
+	Task MaintenanceTask `json:"task"`
+	// LastRunTimestamp records the time the task last ran
+	// +optional
+	LastRunTimestamp *metav1.Time `json:"lastRunTimestamp,omitempty"`
+	// number of repositories the task ran on
+	// +optional
+	Repositories int32 `json:"repositories,omitempty"`
+	// repositories the task failed on
+	// +optional
+	FailedRepositories []string `json:"failedRepositories,omitempty"`
+	// error of the last failure
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type RepositoryMaintenancePhase string
+
+const (
+	SnapMoverMaintenancePhaseRunning RepositoryMaintenancePhase = "Running"
+
+	SnapMoverMaintenancePhaseCompleted RepositoryMaintenancePhase = "Completed"
+
+	SnapMoverMaintenancePhaseFailed RepositoryMaintenancePhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=repositorymaintenances,shortName=repomaint
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// RepositoryMaintenance is the Schema for the repositorymaintenances API
+type RepositoryMaintenance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RepositoryMaintenanceSpec   `json:"spec,omitempty"`
+	Status RepositoryMaintenanceStatus `json:"status,omitempty"`
+}
+
+// GetTaskStatus returns the status of the given task, or nil if it never ran
+func (m *RepositoryMaintenance) GetTaskStatus(task MaintenanceTask) *MaintenanceTaskStatus {
+	for i := range m.Status.Tasks {
+		if m.Status.Tasks[i].Task == task {
+			return &m.Status.Tasks[i]
+		}
+	}
+
+	return nil
+}
+
+//+kubebuilder:object:root=true
+
+// RepositoryMaintenanceList contains a list of RepositoryMaintenance
+type RepositoryMaintenanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RepositoryMaintenance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RepositoryMaintenance{}, &RepositoryMaintenanceList{})
+}
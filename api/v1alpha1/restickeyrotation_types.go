@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResticKeyRotationSpec defines the desired state of ResticKeyRotation
+type ResticKeyRotationSpec struct {
+	// Restic secret of the BSL holding the repositories and their credentials,
+	// updated with the new key once it is added to every repository
+	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef"`
+	// Secret holding the new restic password, either as RESTIC_PASSWORD or
+	// wrapped with a key provider
+	NewKeySecretRef corev1.LocalObjectReference `json:"newKeySecretRef"`
+	// Keep the previous key in the repositories rather than retiring it
+	// +optional
+	RetainOldKey bool `json:"retainOldKey,omitempty"`
+}
+
+// ResticKeyRotationStatus defines the observed state of ResticKeyRotation
+type ResticKeyRotationStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// restickeyrotation phase status
+	Phase ResticKeyRotationPhase `json:"phase,omitempty"`
+	// number of repositories of the BSL
+	// +optional
+	Repositories int32 `json:"repositories,omitempty"`
+	// repositories the current step failed on
+	// +optional
+	FailedRepositories []string `json:"failedRepositories,omitempty"`
+	// number of volumesnapshotbackup and volumesnapshotrestore secrets moved to the new key
+	// +optional
+	MigratedSecrets int32 `json:"migratedSecrets,omitempty"`
+	// CompletionTimestamp records the time the rotation completed
+	// +optional
+	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+}
+
+type ResticKeyRotationPhase string
+
+const (
+	SnapMoverKeyRotationPhaseAddingKey ResticKeyRotationPhase = "AddingKey"
+
+	SnapMoverKeyRotationPhaseRetiringKey ResticKeyRotationPhase = "RetiringKey"
+
+	SnapMoverKeyRotationPhaseCompleted ResticKeyRotationPhase = "Completed"
+
+	SnapMoverKeyRotationPhaseFailed ResticKeyRotationPhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=restickeyrotations,shortName=keyrotation
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Repositories",type=integer,JSONPath=".status.repositories"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// ResticKeyRotation is the Schema for the restickeyrotations API
+type ResticKeyRotation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ResticKeyRotationSpec   `json:"spec,omitempty"`
+	Status ResticKeyRotationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ResticKeyRotationList contains a list of ResticKeyRotation
+type ResticKeyRotationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResticKeyRotation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ResticKeyRotation{}, &ResticKeyRotationList{})
+}
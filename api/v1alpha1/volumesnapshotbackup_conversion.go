@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/konveyor/volume-snapshot-mover/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts this VolumeSnapshotBackup to the Hub version (v1beta1).
+func (src *VolumeSnapshotBackup) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.VolumeSnapshotBackup)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+
+	// spec
+	dst.Spec.VolumeSnapshotContent = in.Spec.VolumeSnapshotContent
+	dst.Spec.ProtectedNamespace = in.Spec.ProtectedNamespace
+	dst.Spec.RepositorySecretRef = in.Spec.ResticSecretRef
+	dst.Spec.Mover = v1beta1.DataMoverType(in.Spec.Mover)
+	dst.Spec.RetryPolicy = (*v1beta1.RetryPolicy)(in.Spec.RetryPolicy)
+	dst.Spec.DataDeletionPolicy = v1beta1.DataDeletionPolicy(in.Spec.DataDeletionPolicy)
+	dst.Spec.VolumeOptions = (*v1beta1.VolumeOptions)(in.Spec.VolumeOptions)
+
+	// status
+	dst.Status.Completed = in.Status.Completed
+	dst.Status.Conditions = in.Status.Conditions
+	dst.Status.SourcePVCData = v1beta1.PVCData(in.Status.SourcePVCData)
+	dst.Status.Repository = in.Status.ResticRepository
+	dst.Status.Phase = v1beta1.VolumeSnapshotBackupPhase(in.Status.Phase)
+	dst.Status.BatchingStatus = v1beta1.VolumeSnapshotBackupBatchingStatus(in.Status.BatchingStatus)
+	dst.Status.QueuePosition = in.Status.QueuePosition
+	dst.Status.VolumeSnapshotClassName = in.Status.VolumeSnapshotClassName
+	dst.Status.StartTimestamp = in.Status.StartTimestamp
+	dst.Status.CompletionTimestamp = in.Status.CompletionTimestamp
+	dst.Status.ReplicationSourceData = v1beta1.ReplicationSourceData(in.Status.ReplicationSourceData)
+	dst.Status.Progress = (*v1beta1.DataMoverProgress)(in.Status.Progress)
+	dst.Status.Attempts = in.Status.Attempts
+	dst.Status.NextRetryTimestamp = in.Status.NextRetryTimestamp
+	dst.Status.RepositoryLayout = v1beta1.RepositoryLayout(in.Status.RepositoryLayout)
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *VolumeSnapshotBackup) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.VolumeSnapshotBackup)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+
+	// spec
+	dst.Spec.VolumeSnapshotContent = in.Spec.VolumeSnapshotContent
+	dst.Spec.ProtectedNamespace = in.Spec.ProtectedNamespace
+	dst.Spec.ResticSecretRef = in.Spec.RepositorySecretRef
+	dst.Spec.Mover = DataMoverType(in.Spec.Mover)
+	dst.Spec.RetryPolicy = (*RetryPolicy)(in.Spec.RetryPolicy)
+	dst.Spec.DataDeletionPolicy = DataDeletionPolicy(in.Spec.DataDeletionPolicy)
+	dst.Spec.VolumeOptions = (*VolumeOptions)(in.Spec.VolumeOptions)
+
+	// status
+	dst.Status.Completed = in.Status.Completed
+	dst.Status.Conditions = in.Status.Conditions
+	dst.Status.SourcePVCData = PVCData(in.Status.SourcePVCData)
+	dst.Status.ResticRepository = in.Status.Repository
+	dst.Status.Phase = VolumeSnapshotBackupPhase(in.Status.Phase)
+	dst.Status.BatchingStatus = VolumeSnapshotBackupBatchingStatus(in.Status.BatchingStatus)
+	dst.Status.QueuePosition = in.Status.QueuePosition
+	dst.Status.VolumeSnapshotClassName = in.Status.VolumeSnapshotClassName
+	dst.Status.StartTimestamp = in.Status.StartTimestamp
+	dst.Status.CompletionTimestamp = in.Status.CompletionTimestamp
+	dst.Status.ReplicationSourceData = ReplicationSourceData(in.Status.ReplicationSourceData)
+	dst.Status.Progress = (*DataMoverProgress)(in.Status.Progress)
+	dst.Status.Attempts = in.Status.Attempts
+	dst.Status.NextRetryTimestamp = in.Status.NextRetryTimestamp
+	dst.Status.RepositoryLayout = RepositoryLayout(in.Status.RepositoryLayout)
+
+	return nil
+}
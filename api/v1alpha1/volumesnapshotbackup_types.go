@@ -17,10 +17,18 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strconv"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// PriorityAnnotation orders queued volumesnapshotbackups, higher values are processed first.
+// Volumesnapshotbackups without the annotation have priority 0
+const PriorityAnnotation = "datamover.oadp.openshift.io/priority"
+
 // VolumeSnapshotBackupSpec defines the desired state of VolumeSnapshotBackup
 type VolumeSnapshotBackupSpec struct {
 	VolumeSnapshotContent corev1.ObjectReference `json:"volumeSnapshotContent,omitempty"`
@@ -28,6 +36,55 @@ type VolumeSnapshotBackupSpec struct {
 	ProtectedNamespace string `json:"protectedNamespace,omitempty"`
 	// Restic Secret reference for given BSL
 	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef,omitempty"`
+	// Data mover used to move the volume data, defaults to restic
+	// +optional
+	Mover DataMoverType `json:"mover,omitempty"`
+	// Retry policy for failed data movement, a failed sync is not retried when unset
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// Whether the moved data is deleted from the BSL along with the volumesnapshotbackup, Retain when unset
+	// +optional
+	DataDeletionPolicy DataDeletionPolicy `json:"dataDeletionPolicy,omitempty"`
+	// Volume options of the data movement, taking precedence over the DataMoverStorageClassConfig of the PVC storage class
+	// +optional
+	VolumeOptions *VolumeOptions `json:"volumeOptions,omitempty"`
+}
+
+// RetryPolicy defines how failed data movement is retried
+type RetryPolicy struct {
+	// maximum number of attempts, including the first one
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts int32 `json:"maxAttempts"`
+	// delay before the first retry, doubled for every following retry. Defaults to 30s
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// maximum delay between two attempts. Defaults to 10m
+	// +optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// VolumeOptions overrides the volume options of the DataMoverStorageClassConfig of the PVC storage class
+type VolumeOptions struct {
+	// Storage class of the volume moved
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Access mode of the volume moved
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	// +optional
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+	// Storage class of the restic cache volume
+	// +optional
+	CacheStorageClassName string `json:"cacheStorageClassName,omitempty"`
+	// Access mode of the restic cache volume
+	// +kubebuilder:validation:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	// +optional
+	CacheAccessMode corev1.PersistentVolumeAccessMode `json:"cacheAccessMode,omitempty"`
+	// Capacity of the restic cache volume
+	// +optional
+	CacheCapacity *resource.Quantity `json:"cacheCapacity,omitempty"`
+	// Run the mover pod with the security context of the application pod using the PVC
+	// +optional
+	MoverSecurityContext *bool `json:"moverSecurityContext,omitempty"`
 }
 
 // VolumeSnapshotBackupStatus defines the observed state of VolumeSnapshotBackup
@@ -44,6 +101,10 @@ type VolumeSnapshotBackupStatus struct {
 	Phase VolumeSnapshotBackupPhase `json:"phase,omitempty"`
 	// volumesnapshotbackup batching status
 	BatchingStatus VolumeSnapshotBackupBatchingStatus `json:"batchingStatus,omitempty"`
+	// position of the volumesnapshotbackup in the batching queue, starting at 1.
+	// Only set while the batching status is Queued
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
 	// name of the VolumeSnapshotClass
 	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
 	// StartTimestamp records the time a volsumesnapshotbackup was started.
@@ -54,6 +115,18 @@ type VolumeSnapshotBackupStatus struct {
 	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
 	// Includes information pertaining to Volsync ReplicationSource CR
 	ReplicationSourceData ReplicationSourceData `json:"replicationSourceData,omitempty"`
+	// number of data movement attempts started
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+	// NextRetryTimestamp records the time the failed data movement is retried.
+	// +optional
+	NextRetryTimestamp *metav1.Time `json:"nextRetryTimestamp,omitempty"`
+	// Progress of the data movement
+	// +optional
+	Progress *DataMoverProgress `json:"progress,omitempty"`
+	// layout of the restic repository the volume was written to
+	// +optional
+	RepositoryLayout RepositoryLayout `json:"repositoryLayout,omitempty"`
 }
 
 type ReplicationSourceData struct {
@@ -65,6 +138,9 @@ type ReplicationSourceData struct {
 	// CompletionTimestamp records the time a ReplicationSource reached a terminal state.
 	// +optional
 	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+	// id of the restic snapshot taken by the ReplicationSource, read from its mover logs
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
 }
 
 type PVCData struct {
@@ -74,6 +150,92 @@ type PVCData struct {
 	Size string `json:"size,omitempty"`
 	// name of the StorageClass
 	StorageClassName string `json:"storageClassName,omitempty"`
+	// access modes of the PersistentVolumeClaim
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// volume mode of the PersistentVolumeClaim
+	// +optional
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+	// user labels of the PersistentVolumeClaim
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// user annotations of the PersistentVolumeClaim
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// UID of the PersistentVolumeClaim, identifies the volume in the volume repository layout
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+}
+
+// DataMoverProgress reports how far along the data mover is
+type DataMoverProgress struct {
+	// total number of bytes to be moved
+	// +optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+	// number of bytes moved so far
+	// +optional
+	BytesDone int64 `json:"bytesDone,omitempty"`
+	// total number of files to be moved
+	// +optional
+	TotalFiles int64 `json:"totalFiles,omitempty"`
+	// number of files moved so far
+	// +optional
+	FilesDone int64 `json:"filesDone,omitempty"`
+	// percentage of the data moved so far
+	// +optional
+	Percentage int32 `json:"percentage,omitempty"`
+	// current throughput in bytes per second
+	// +optional
+	BytesPerSecond int64 `json:"bytesPerSecond,omitempty"`
+	// estimated time remaining until the data movement completes
+	// +optional
+	EstimatedTimeRemaining *metav1.Duration `json:"estimatedTimeRemaining,omitempty"`
+}
+
+// RepositoryLayout is the layout of the restic repositories in the BSL
+type RepositoryLayout string
+
+const (
+	// RepositoryLayoutBackup writes every backup of a volume to its own repository,
+	// <repository>/<protected namespace>/<backup>/<pvc>
+	RepositoryLayoutBackup RepositoryLayout = "backup"
+
+	// RepositoryLayoutVolume writes the backups of a volume to a repository shared by all of them,
+	// <repository>/<protected namespace>/volumes/<pvc namespace>/<pvc uid>, so they are deduplicated
+	RepositoryLayoutVolume RepositoryLayout = "volume"
+)
+
+// DataDeletionPolicy is what happens to the moved data when a volumesnapshotbackup is deleted
+// +kubebuilder:validation:Enum=Delete;Retain
+type DataDeletionPolicy string
+
+const (
+	// DataDeletionPolicyDelete forgets and prunes the restic snapshot of the volumesnapshotbackup
+	DataDeletionPolicyDelete DataDeletionPolicy = "Delete"
+
+	// DataDeletionPolicyRetain keeps the moved data in the BSL
+	DataDeletionPolicyRetain DataDeletionPolicy = "Retain"
+)
+
+// DataMoverType is the VolSync mover used to move volume data
+// +kubebuilder:validation:Enum=restic;rclone;rsync-tls;rsync
+type DataMoverType string
+
+const (
+	ResticDataMover DataMoverType = "restic"
+
+	RcloneDataMover DataMoverType = "rclone"
+
+	// rsync movers copy the cloned volume to a volumesnapshotrestore
+	// of the same cluster, without writing to a repository
+	RsyncTLSDataMover DataMoverType = "rsync-tls"
+
+	RsyncDataMover DataMoverType = "rsync"
+)
+
+// IsClone returns true for the movers copying volumes within the cluster
+func (t DataMoverType) IsClone() bool {
+	return t == RsyncTLSDataMover || t == RsyncDataMover
 }
 
 type VolumeSnapshotBackupPhase string
@@ -105,10 +267,13 @@ const (
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:path=volumesnapshotbackups,shortName=vsb
+//+kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="PVC Name",type=string,JSONPath=".status.sourcePVCData.name"
 // +kubebuilder:printcolumn:name="VolumeSnapshotContent",type=string,JSONPath=".spec.volumeSnapshotContent.name"
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="BatchingStatus",type=string,JSONPath=".status.batchingStatus"
+// +kubebuilder:printcolumn:name="Queue Position",type=integer,JSONPath=".status.queuePosition",priority=1
+// +kubebuilder:printcolumn:name="Progress",type=integer,JSONPath=".status.progress.percentage"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // VolumeSnapshotBackup is the Schema for the volumesnapshotbackups API
@@ -129,6 +294,21 @@ type VolumeSnapshotBackupList struct {
 	Items           []VolumeSnapshotBackup `json:"items"`
 }
 
+// GetPriority returns the priority set with PriorityAnnotation
+func (r *VolumeSnapshotBackup) GetPriority() (int32, error) {
+	value, ok := r.Annotations[PriorityAnnotation]
+	if !ok {
+		return 0, nil
+	}
+
+	priority, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(priority), nil
+}
+
 func init() {
 	SchemeBuilder.Register(&VolumeSnapshotBackup{}, &VolumeSnapshotBackupList{})
 }
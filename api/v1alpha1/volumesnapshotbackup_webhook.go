@@ -0,0 +1,135 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the VolumeSnapshotBackup webhooks, including the
+// conversion webhook served at /convert, with the manager
+func (r *VolumeSnapshotBackup) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-datamover-oadp-openshift-io-v1alpha1-volumesnapshotbackup,mutating=false,failurePolicy=fail,sideEffects=None,groups=datamover.oadp.openshift.io,resources=volumesnapshotbackups,verbs=create;update,versions=v1alpha1,name=vvolumesnapshotbackup.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &VolumeSnapshotBackup{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotBackup) ValidateCreate() error {
+	allErrs := r.validatePriority()
+	allErrs = append(allErrs, r.ValidateSpec()...)
+
+	return r.toInvalidError(allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotBackup) ValidateUpdate(old runtime.Object) error {
+	oldVSB, ok := old.(*VolumeSnapshotBackup)
+	if !ok {
+		return apierrors.NewBadRequest("old object is not a volumesnapshotbackup")
+	}
+
+	allErrs := r.validatePriority()
+
+	// metadata only updates, such as finalizer removal, are always allowed
+	if equality.Semantic.DeepEqual(oldVSB.Spec, r.Spec) {
+		return r.toInvalidError(allErrs)
+	}
+
+	// the data deletion policy can change until the volumesnapshotbackup is gone, so a failing deletion can be retained
+	oldSpec := oldVSB.Spec.DeepCopy()
+	oldSpec.DataDeletionPolicy = r.Spec.DataDeletionPolicy
+	if (oldVSB.Status.StartTimestamp != nil || len(oldVSB.Status.Phase) > 0) && !equality.Semantic.DeepEqual(*oldSpec, r.Spec) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "spec cannot be modified once the volumesnapshotbackup has started"))
+	}
+	allErrs = append(allErrs, r.ValidateSpec()...)
+
+	return r.toInvalidError(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotBackup) ValidateDelete() error {
+	return nil
+}
+
+// ValidateSpec returns every problem found in the VolumeSnapshotBackup spec
+func (r *VolumeSnapshotBackup) ValidateSpec() field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if len(r.Spec.VolumeSnapshotContent.Name) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("volumeSnapshotContent", "name"), "volumesnapshotcontent name cannot be empty"))
+	}
+
+	if len(r.Spec.ProtectedNamespace) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("protectedNamespace"), "protected namespace cannot be empty"))
+	}
+
+	// rsync movers do not use a repository
+	if len(r.Spec.ResticSecretRef.Name) == 0 && !r.Spec.Mover.IsClone() {
+		allErrs = append(allErrs, field.Required(specPath.Child("resticSecretRef", "name"), "restic secret name cannot be empty"))
+	}
+
+	switch r.Spec.DataDeletionPolicy {
+	case "", DataDeletionPolicyDelete, DataDeletionPolicyRetain:
+	default:
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("dataDeletionPolicy"), r.Spec.DataDeletionPolicy,
+			[]string{string(DataDeletionPolicyDelete), string(DataDeletionPolicyRetain)}))
+	}
+
+	if r.Spec.RetryPolicy != nil {
+		retryPath := specPath.Child("retryPolicy")
+		if r.Spec.RetryPolicy.MaxAttempts < 1 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("maxAttempts"), r.Spec.RetryPolicy.MaxAttempts, "max attempts must be at least 1"))
+		}
+		if r.Spec.RetryPolicy.Backoff != nil && r.Spec.RetryPolicy.Backoff.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("backoff"), r.Spec.RetryPolicy.Backoff.Duration.String(), "backoff must be positive"))
+		}
+		if r.Spec.RetryPolicy.MaxBackoff != nil && r.Spec.RetryPolicy.MaxBackoff.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(retryPath.Child("maxBackoff"), r.Spec.RetryPolicy.MaxBackoff.Duration.String(), "max backoff must be positive"))
+		}
+	}
+
+	return allErrs
+}
+
+func (r *VolumeSnapshotBackup) validatePriority() field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if _, err := r.GetPriority(); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(PriorityAnnotation), r.Annotations[PriorityAnnotation], "priority must be a 32-bit integer"))
+	}
+
+	return allErrs
+}
+
+func (r *VolumeSnapshotBackup) toInvalidError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind(VSBKind).GroupKind(), r.Name, allErrs)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestVSB() *VolumeSnapshotBackup {
+	return &VolumeSnapshotBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsb",
+			Namespace: "bar",
+		},
+		Spec: VolumeSnapshotBackupSpec{
+			VolumeSnapshotContent: corev1.ObjectReference{
+				Name: "sample-snapshot",
+			},
+			ProtectedNamespace: "foo",
+			ResticSecretRef: corev1.LocalObjectReference{
+				Name: "sample-secret",
+			},
+		},
+	}
+}
+
+func TestVolumeSnapshotBackup_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		vsb        func() *VolumeSnapshotBackup
+		wantErrLen int
+	}{
+		{
+			name:       "Given valid vsb -> no errors",
+			vsb:        newTestVSB,
+			wantErrLen: 0,
+		},
+		{
+			name: "Given empty volumesnapshotcontent name -> one error",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.VolumeSnapshotContent.Name = ""
+				return vsb
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given empty spec -> every error reported",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec = VolumeSnapshotBackupSpec{}
+				return vsb
+			},
+			wantErrLen: 3,
+		},
+		{
+			name: "Given rsync-tls mover without restic secret -> no errors",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.Mover = RsyncTLSDataMover
+				vsb.Spec.ResticSecretRef = corev1.LocalObjectReference{}
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given valid priority annotation -> no errors",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Annotations = map[string]string{PriorityAnnotation: "-10"}
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given non integer priority annotation -> one error",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Annotations = map[string]string{PriorityAnnotation: "high"}
+				return vsb
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given valid retry policy -> no errors",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.RetryPolicy = &RetryPolicy{
+					MaxAttempts: 3,
+					Backoff:     &metav1.Duration{Duration: time.Minute},
+					MaxBackoff:  &metav1.Duration{Duration: time.Hour},
+				}
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given invalid retry policy -> every error reported",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.RetryPolicy = &RetryPolicy{
+					MaxAttempts: 0,
+					Backoff:     &metav1.Duration{Duration: -time.Minute},
+					MaxBackoff:  &metav1.Duration{},
+				}
+				return vsb
+			},
+			wantErrLen: 3,
+		},
+		{
+			name: "Given unknown data deletion policy -> one error",
+			vsb: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.DataDeletionPolicy = "Purge"
+				return vsb
+			},
+			wantErrLen: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.vsb().ValidateCreate()
+			if got := causeCount(err); got != tt.wantErrLen {
+				t.Errorf("ValidateCreate() error = %v, want %v causes", err, tt.wantErrLen)
+			}
+		})
+	}
+}
+
+func TestVolumeSnapshotBackup_ValidateUpdate(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name       string
+		old        func() *VolumeSnapshotBackup
+		new        func() *VolumeSnapshotBackup
+		wantErrLen int
+	}{
+		{
+			name: "Given metadata only update on started vsb -> no errors",
+			old: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Status.StartTimestamp = &now
+				return vsb
+			},
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Finalizers = []string{"oadp.openshift.io/oadp-datamover"}
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given spec update before start -> no errors",
+			old:  newTestVSB,
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.ProtectedNamespace = "baz"
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given spec update after start -> forbidden",
+			old: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Status.Phase = SnapMoverBackupPhaseInProgress
+				return vsb
+			},
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.ProtectedNamespace = "baz"
+				return vsb
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given data deletion policy update after start -> no errors",
+			old: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Status.Phase = SnapMoverBackupPhaseCompleted
+				vsb.Spec.DataDeletionPolicy = DataDeletionPolicyDelete
+				return vsb
+			},
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.DataDeletionPolicy = DataDeletionPolicyRetain
+				return vsb
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given invalid spec update after start -> every error reported",
+			old: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Status.StartTimestamp = &now
+				return vsb
+			},
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Spec.ProtectedNamespace = ""
+				return vsb
+			},
+			wantErrLen: 2,
+		},
+		{
+			name: "Given invalid priority annotation on started vsb -> one error",
+			old: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Status.StartTimestamp = &now
+				return vsb
+			},
+			new: func() *VolumeSnapshotBackup {
+				vsb := newTestVSB()
+				vsb.Annotations = map[string]string{PriorityAnnotation: "1.5"}
+				return vsb
+			},
+			wantErrLen: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.new().ValidateUpdate(tt.old())
+			if got := causeCount(err); got != tt.wantErrLen {
+				t.Errorf("ValidateUpdate() error = %v, want %v causes", err, tt.wantErrLen)
+			}
+		})
+	}
+}
+
+// causeCount returns the number of field errors carried by an invalid error
+func causeCount(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	statusErr, ok := err.(*apierrors.StatusError)
+	if !ok || statusErr.ErrStatus.Details == nil {
+		return -1
+	}
+
+	return len(statusErr.ErrStatus.Details.Causes)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeSnapshotBackupGroupSpec defines the desired state of VolumeSnapshotBackupGroup
+type VolumeSnapshotBackupGroupSpec struct {
+	// VolumeSnapshotContents of the volume group snapshot, moved as one unit
+	// +kubebuilder:validation:MinItems=1
+	VolumeSnapshotContents []corev1.ObjectReference `json:"volumeSnapshotContents"`
+	// name of the VolumeGroupSnapshot the VolumeSnapshotContents were taken by
+	// +optional
+	VolumeGroupSnapshotName string `json:"volumeGroupSnapshotName,omitempty"`
+	// Namespace where the Velero deployment is present
+	ProtectedNamespace string `json:"protectedNamespace,omitempty"`
+	// Restic Secret reference for given BSL
+	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef,omitempty"`
+	// Data mover used to move the volume data, defaults to restic
+	// +optional
+	Mover DataMoverType `json:"mover,omitempty"`
+	// Retries of the failed ReplicationSource syncs of the member volumesnapshotbackups
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// VolumeSnapshotBackupGroupStatus defines the observed state of VolumeSnapshotBackupGroup
+type VolumeSnapshotBackupGroupStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// volumesnapshotbackupgroup phase status
+	Phase VolumeSnapshotGroupPhase `json:"phase,omitempty"`
+	// id shared by the members of the group, set as their group-tag label and recorded in their manifests.
+	// It is not a restic snapshot tag, the VolSync restic mover does not tag snapshots
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// member volumesnapshotbackups, one per VolumeSnapshotContent
+	// +optional
+	Members []BackupGroupMember `json:"members,omitempty"`
+	// CompletionTimestamp records the time the group reached a terminal state
+	// +optional
+	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+}
+
+// BackupGroupMember is the volumesnapshotbackup moving one VolumeSnapshotContent of the group
+type BackupGroupMember struct {
+	// name of the VolumeSnapshotContent
+	VolumeSnapshotContent string `json:"volumeSnapshotContent"`
+	// name of the member volumesnapshotbackup
+	VolumeSnapshotBackup string `json:"volumeSnapshotBackup"`
+	// phase of the member volumesnapshotbackup
+	// +optional
+	Phase VolumeSnapshotBackupPhase `json:"phase,omitempty"`
+	// backed up PVC, restic repository and VolumeSnapshotClass of the member,
+	// used by the volumesnapshotrestoregroup
+	// +optional
+	BackupRef VSBRef `json:"backupRef,omitempty"`
+}
+
+type VolumeSnapshotGroupPhase string
+
+const (
+	SnapMoverGroupPhaseInProgress VolumeSnapshotGroupPhase = "InProgress"
+
+	SnapMoverGroupPhaseCompleted VolumeSnapshotGroupPhase = "Completed"
+
+	SnapMoverGroupPhaseFailed VolumeSnapshotGroupPhase = "Failed"
+)
+
+// IsTerminal returns true once the group has completed or failed
+func (p VolumeSnapshotGroupPhase) IsTerminal() bool {
+	return p == SnapMoverGroupPhaseCompleted || p == SnapMoverGroupPhaseFailed
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=volumesnapshotbackupgroups,shortName=vsbg
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// VolumeSnapshotBackupGroup is the Schema for the volumesnapshotbackupgroups API
+type VolumeSnapshotBackupGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeSnapshotBackupGroupSpec   `json:"spec,omitempty"`
+	Status VolumeSnapshotBackupGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VolumeSnapshotBackupGroupList contains a list of VolumeSnapshotBackupGroup
+type VolumeSnapshotBackupGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeSnapshotBackupGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VolumeSnapshotBackupGroup{}, &VolumeSnapshotBackupGroupList{})
+}
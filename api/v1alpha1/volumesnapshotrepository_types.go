@@ -0,0 +1,130 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeSnapshotRepositorySpec defines the desired state of VolumeSnapshotRepository
+type VolumeSnapshotRepositorySpec struct {
+	// Restic secret of the BSL holding the repository and its credentials,
+	// the same secret referenced by the volumesnapshotbackups
+	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef"`
+	// Interval between two discoveries of the repository. The repository
+	// is only discovered once when unset
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// VolumeSnapshotRepositoryStatus defines the observed state of VolumeSnapshotRepository
+type VolumeSnapshotRepositoryStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// volumesnapshotrepository phase status
+	Phase VolumeSnapshotRepositoryPhase `json:"phase,omitempty"`
+	// generation of the spec used by the last discovery
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastDiscoveryTimestamp records the time the snapshots were last discovered
+	// +optional
+	LastDiscoveryTimestamp *metav1.Time `json:"lastDiscoveryTimestamp,omitempty"`
+	// Snapshots found in the repository that can be restored
+	// +optional
+	Snapshots []RepositorySnapshot `json:"snapshots,omitempty"`
+}
+
+// RepositorySnapshot is a restorable volume snapshot found in the repository
+type RepositorySnapshot struct {
+	// name referenced by volumesnapshotrestores, <backupName>-<pvcName>, or
+	// <backupName>-<pvc namespace>-<pvc name> for the snapshots of shared repositories
+	Name string `json:"name"`
+	// protected namespace of the volumesnapshotbackup that wrote the snapshot
+	ProtectedNamespace string `json:"protectedNamespace"`
+	// name of the velero backup
+	BackupName string `json:"backupName"`
+	// name of the PVC cloned from the volumesnapshotcontent by the volumesnapshotbackup,
+	// or of the backed up PVC for the snapshots of shared repositories
+	PVCName string `json:"pvcName"`
+	// restic repository path of the snapshot
+	ResticRepository string `json:"resticrepository"`
+	// backed up PVC name, size and StorageClass, read from the snapshot manifest
+	// +optional
+	SourcePVCData *PVCData `json:"sourcePVCData,omitempty"`
+	// name of the VolumeSnapshotClass, read from the snapshot manifest
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// tag of the volumesnapshotbackupgroup the snapshot was moved with, read from the snapshot manifest
+	// +optional
+	Group string `json:"group,omitempty"`
+	// time the snapshot was taken, set for the snapshots of repositories shared by the backups of a volume
+	// +optional
+	SnapshotTimestamp *metav1.Time `json:"snapshotTimestamp,omitempty"`
+	// id of the snapshot, set for the snapshots of repositories shared by the backups of a volume
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+}
+
+type VolumeSnapshotRepositoryPhase string
+
+const (
+	SnapMoverRepositoryPhaseDiscovering VolumeSnapshotRepositoryPhase = "Discovering"
+
+	SnapMoverRepositoryPhaseReady VolumeSnapshotRepositoryPhase = "Ready"
+
+	SnapMoverRepositoryPhaseFailed VolumeSnapshotRepositoryPhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=volumesnapshotrepositories,shortName=vsrepo
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Last Discovery",type=date,JSONPath=".status.lastDiscoveryTimestamp"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// VolumeSnapshotRepository is the Schema for the volumesnapshotrepositories API
+type VolumeSnapshotRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeSnapshotRepositorySpec   `json:"spec,omitempty"`
+	Status VolumeSnapshotRepositoryStatus `json:"status,omitempty"`
+}
+
+// GetSnapshot returns the snapshot with the given name, or nil if the repository does not contain it
+func (r *VolumeSnapshotRepository) GetSnapshot(name string) *RepositorySnapshot {
+	for i := range r.Status.Snapshots {
+		if r.Status.Snapshots[i].Name == name {
+			return &r.Status.Snapshots[i]
+		}
+	}
+
+	return nil
+}
+
+//+kubebuilder:object:root=true
+
+// VolumeSnapshotRepositoryList contains a list of VolumeSnapshotRepository
+type VolumeSnapshotRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeSnapshotRepository `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VolumeSnapshotRepository{}, &VolumeSnapshotRepositoryList{})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/konveyor/volume-snapshot-mover/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts this VolumeSnapshotRestore to the Hub version (v1beta1).
+func (src *VolumeSnapshotRestore) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.VolumeSnapshotRestore)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+
+	// spec
+	dst.Spec.RepositorySecretRef = in.Spec.ResticSecretRef
+	dst.Spec.BackupRef = (*v1beta1.VolumeSnapshotBackupReference)(in.Spec.BackupRef)
+	dst.Spec.RepositorySnapshotRef = (*v1beta1.RepositorySnapshotReference)(in.Spec.RepositorySnapshotRef)
+	dst.Spec.BackupData.PVCData = v1beta1.PVCData(in.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData)
+	dst.Spec.BackupData.Repository = in.Spec.VolumeSnapshotMoverBackupref.ResticRepository
+	dst.Spec.BackupData.VolumeSnapshotClassName = in.Spec.VolumeSnapshotMoverBackupref.VolumeSnapshotClassName
+	dst.Spec.BackupData.SnapshotTimestamp = in.Spec.VolumeSnapshotMoverBackupref.SnapshotTimestamp
+	dst.Spec.BackupData.SnapshotID = in.Spec.VolumeSnapshotMoverBackupref.SnapshotID
+	dst.Spec.ProtectedNamespace = in.Spec.ProtectedNamespace
+	dst.Spec.Mover = v1beta1.DataMoverType(in.Spec.Mover)
+	dst.Spec.VolumeOptions = (*v1beta1.VolumeOptions)(in.Spec.VolumeOptions)
+
+	// status
+	dst.Status.Conditions = in.Status.Conditions
+	dst.Status.Phase = v1beta1.VolumeSnapshotRestorePhase(in.Status.Phase)
+	dst.Status.BatchingStatus = v1beta1.VolumeSnapshotRestoreBatchingStatus(in.Status.BatchingStatus)
+	dst.Status.SnapshotHandle = in.Status.SnapshotHandle
+	dst.Status.VolumeSnapshotContentName = in.Status.VolumeSnapshotContentName
+	dst.Status.StartTimestamp = in.Status.StartTimestamp
+	dst.Status.CompletionTimestamp = in.Status.CompletionTimestamp
+	dst.Status.ReplicationDestinationData = v1beta1.ReplicationDestinationData(in.Status.ReplicationDestinationData)
+	dst.Status.Progress = (*v1beta1.DataMoverProgress)(in.Status.Progress)
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version.
+func (dst *VolumeSnapshotRestore) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.VolumeSnapshotRestore)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+
+	// spec
+	dst.Spec.ResticSecretRef = in.Spec.RepositorySecretRef
+	dst.Spec.BackupRef = (*VolumeSnapshotBackupReference)(in.Spec.BackupRef)
+	dst.Spec.RepositorySnapshotRef = (*RepositorySnapshotReference)(in.Spec.RepositorySnapshotRef)
+	dst.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData = PVCData(in.Spec.BackupData.PVCData)
+	dst.Spec.VolumeSnapshotMoverBackupref.ResticRepository = in.Spec.BackupData.Repository
+	dst.Spec.VolumeSnapshotMoverBackupref.VolumeSnapshotClassName = in.Spec.BackupData.VolumeSnapshotClassName
+	dst.Spec.VolumeSnapshotMoverBackupref.SnapshotTimestamp = in.Spec.BackupData.SnapshotTimestamp
+	dst.Spec.VolumeSnapshotMoverBackupref.SnapshotID = in.Spec.BackupData.SnapshotID
+	dst.Spec.ProtectedNamespace = in.Spec.ProtectedNamespace
+	dst.Spec.Mover = DataMoverType(in.Spec.Mover)
+	dst.Spec.VolumeOptions = (*VolumeOptions)(in.Spec.VolumeOptions)
+
+	// status
+	dst.Status.Conditions = in.Status.Conditions
+	dst.Status.Phase = VolumeSnapshotRestorePhase(in.Status.Phase)
+	dst.Status.BatchingStatus = VolumeSnapshotRestoreBatchingStatus(in.Status.BatchingStatus)
+	dst.Status.SnapshotHandle = in.Status.SnapshotHandle
+	dst.Status.VolumeSnapshotContentName = in.Status.VolumeSnapshotContentName
+	dst.Status.StartTimestamp = in.Status.StartTimestamp
+	dst.Status.CompletionTimestamp = in.Status.CompletionTimestamp
+	dst.Status.ReplicationDestinationData = ReplicationDestinationData(in.Status.ReplicationDestinationData)
+	dst.Status.Progress = (*DataMoverProgress)(in.Status.Progress)
+
+	return nil
+}
@@ -24,10 +24,26 @@ import (
 // VolumeSnapshotRestoreSpec defines the desired state of VolumeSnapshotRestore
 type VolumeSnapshotRestoreSpec struct {
 	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef,omitempty"`
+	// Reference to the volumesnapshotbackup being restored, required by
+	// the rsync movers to consume its cloned volume
+	// +optional
+	BackupRef *VolumeSnapshotBackupReference `json:"backupRef,omitempty"`
+	// Reference to a snapshot discovered by a volumesnapshotrepository,
+	// used instead of volumeSnapshotMoverBackupRef.resticrepository
+	// +optional
+	RepositorySnapshotRef *RepositorySnapshotReference `json:"repositorySnapshotRef,omitempty"`
 	// Includes associated volumesnapshotbackup details
 	VolumeSnapshotMoverBackupref VSBRef `json:"volumeSnapshotMoverBackupRef,omitempty"`
 	// Namespace where the Velero deployment is present
 	ProtectedNamespace string `json:"protectedNamespace,omitempty"`
+	// Data mover used to move the volume data, must match the mover used
+	// by the associated volumesnapshotbackup. Defaults to restic
+	// +optional
+	Mover DataMoverType `json:"mover,omitempty"`
+	// Volume options of the data movement, taking precedence over the DataMoverStorageClassConfig of the
+	// backed up PVC storage class
+	// +optional
+	VolumeOptions *VolumeOptions `json:"volumeOptions,omitempty"`
 }
 
 // VolumeSnapshotRestoreStatus defines the observed state of VolumeSnapshotRestore
@@ -47,6 +63,26 @@ type VolumeSnapshotRestoreStatus struct {
 	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
 	// Includes information pertaining to Volsync ReplicationDestination CR
 	ReplicationDestinationData ReplicationDestinationData `json:"replicationDestinationData,omitempty"`
+	// Progress of the data movement
+	// +optional
+	Progress *DataMoverProgress `json:"progress,omitempty"`
+}
+
+// VolumeSnapshotBackupReference identifies the volumesnapshotbackup a restore is created from
+type VolumeSnapshotBackupReference struct {
+	// name of the VolumeSnapshotBackup
+	Name string `json:"name"`
+	// namespace of the VolumeSnapshotBackup, defaults to the volumesnapshotrestore namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RepositorySnapshotReference identifies a snapshot discovered by a volumesnapshotrepository
+type RepositorySnapshotReference struct {
+	// name of the VolumeSnapshotRepository in the protected namespace
+	Repository string `json:"repository"`
+	// name of the snapshot in the VolumeSnapshotRepository status
+	Name string `json:"name"`
 }
 
 type VSBRef struct {
@@ -56,6 +92,12 @@ type VSBRef struct {
 	ResticRepository string `json:"resticrepository,omitempty"`
 	// name of the VolumeSnapshotClass
 	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// time the snapshot was taken, the latest snapshot of a shared repository taken until then is restored
+	// +optional
+	SnapshotTimestamp *metav1.Time `json:"snapshotTimestamp,omitempty"`
+	// id of the snapshot in a shared repository, the restore fails if another snapshot is restored
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
 }
 
 type VolumeSnapshotRestorePhase string
@@ -87,10 +129,12 @@ const (
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:path=volumesnapshotrestores,shortName=vsr
+//+kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="PVC Name",type=string,JSONPath=".spec.volumeSnapshotMoverBackupRef.sourcePVCData.name"
 // +kubebuilder:printcolumn:name="VolumeSnapshotContent",type=string,JSONPath=".status.volumeSnapshotContentName"
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="BatchingStatus",type=string,JSONPath=".status.batchingStatus"
+// +kubebuilder:printcolumn:name="Progress",type=integer,JSONPath=".status.progress.percentage"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // VolumeSnapshotRestore is the Schema for the volumesnapshotrestores API
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the VolumeSnapshotRestore webhooks, including the
+// conversion webhook served at /convert, with the manager
+func (r *VolumeSnapshotRestore) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-datamover-oadp-openshift-io-v1alpha1-volumesnapshotrestore,mutating=false,failurePolicy=fail,sideEffects=None,groups=datamover.oadp.openshift.io,resources=volumesnapshotrestores,verbs=create;update,versions=v1alpha1,name=vvolumesnapshotrestore.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &VolumeSnapshotRestore{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotRestore) ValidateCreate() error {
+	return r.toInvalidError(r.ValidateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotRestore) ValidateUpdate(old runtime.Object) error {
+	oldVSR, ok := old.(*VolumeSnapshotRestore)
+	if !ok {
+		return apierrors.NewBadRequest("old object is not a volumesnapshotrestore")
+	}
+
+	// metadata only updates, such as finalizer removal, are always allowed
+	if equality.Semantic.DeepEqual(oldVSR.Spec, r.Spec) {
+		return nil
+	}
+
+	allErrs := field.ErrorList{}
+	if oldVSR.Status.StartTimestamp != nil || len(oldVSR.Status.Phase) > 0 {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "spec cannot be modified once the volumesnapshotrestore has started"))
+	}
+	allErrs = append(allErrs, r.ValidateSpec()...)
+
+	return r.toInvalidError(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *VolumeSnapshotRestore) ValidateDelete() error {
+	return nil
+}
+
+// ValidateSpec returns every problem found in the VolumeSnapshotRestore spec
+func (r *VolumeSnapshotRestore) ValidateSpec() field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	backupRefPath := specPath.Child("volumeSnapshotMoverBackupRef")
+
+	// rsync movers consume the cloned volume of the volumesnapshotbackup instead of a repository
+	if r.Spec.Mover.IsClone() {
+		if r.Spec.BackupRef == nil || len(r.Spec.BackupRef.Name) == 0 {
+			allErrs = append(allErrs, field.Required(specPath.Child("backupRef", "name"), fmt.Sprintf("volumesnapshotbackup name cannot be empty for the %s mover", r.Spec.Mover)))
+		}
+		if r.Spec.RepositorySnapshotRef != nil {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("repositorySnapshotRef"), fmt.Sprintf("the %s mover does not restore from a repository", r.Spec.Mover)))
+		}
+	} else if r.Spec.RepositorySnapshotRef != nil {
+		// the repository and its secret are resolved from the volumesnapshotrepository
+		snapshotRefPath := specPath.Child("repositorySnapshotRef")
+		if len(r.Spec.RepositorySnapshotRef.Repository) == 0 {
+			allErrs = append(allErrs, field.Required(snapshotRefPath.Child("repository"), "volumesnapshotrepository name cannot be empty"))
+		}
+		if len(r.Spec.RepositorySnapshotRef.Name) == 0 {
+			allErrs = append(allErrs, field.Required(snapshotRefPath.Child("name"), "repository snapshot name cannot be empty"))
+		}
+	} else {
+		if len(r.Spec.ResticSecretRef.Name) == 0 {
+			allErrs = append(allErrs, field.Required(specPath.Child("resticSecretRef", "name"), "restic secret name cannot be empty"))
+		}
+
+		if len(r.Spec.VolumeSnapshotMoverBackupref.ResticRepository) == 0 {
+			allErrs = append(allErrs, field.Required(backupRefPath.Child("resticrepository"), "restic repository cannot be empty"))
+		}
+	}
+
+	if len(r.Spec.ProtectedNamespace) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("protectedNamespace"), "protected namespace cannot be empty"))
+	}
+
+	// the backed up pvc data of a repository snapshot is read from its manifest when unset
+	pvcDataRequired := r.Spec.RepositorySnapshotRef == nil
+	if pvcDataRequired && len(r.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name) == 0 {
+		allErrs = append(allErrs, field.Required(backupRefPath.Child("sourcePVCData", "name"), "backed up pvc name cannot be empty"))
+	}
+
+	size := r.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size
+	if len(size) == 0 {
+		if pvcDataRequired {
+			allErrs = append(allErrs, field.Required(backupRefPath.Child("sourcePVCData", "size"), "backed up pvc size cannot be empty"))
+		}
+	} else if _, err := resource.ParseQuantity(size); err != nil {
+		allErrs = append(allErrs, field.Invalid(backupRefPath.Child("sourcePVCData", "size"), size, err.Error()))
+	}
+
+	// the data movers only move filesystem volumes
+	if volumeMode := r.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.VolumeMode; volumeMode != nil && *volumeMode == corev1.PersistentVolumeBlock {
+		allErrs = append(allErrs, field.NotSupported(backupRefPath.Child("sourcePVCData", "volumeMode"), *volumeMode, []string{string(corev1.PersistentVolumeFilesystem)}))
+	}
+
+	return allErrs
+}
+
+func (r *VolumeSnapshotRestore) toInvalidError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind(VSRKind).GroupKind(), r.Name, allErrs)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestVSR() *VolumeSnapshotRestore {
+	return &VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsr",
+			Namespace: "bar",
+		},
+		Spec: VolumeSnapshotRestoreSpec{
+			ResticSecretRef: corev1.LocalObjectReference{
+				Name: "sample-secret",
+			},
+			ProtectedNamespace: "foo",
+			VolumeSnapshotMoverBackupref: VSBRef{
+				ResticRepository: "s3://sample-path/snapshots",
+				BackedUpPVCData: PVCData{
+					Name: "sample-pvc",
+					Size: "10Gi",
+				},
+			},
+		},
+	}
+}
+
+func TestVolumeSnapshotRestore_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		vsr        func() *VolumeSnapshotRestore
+		wantErrLen int
+	}{
+		{
+			name:       "Given valid vsr -> no errors",
+			vsr:        newTestVSR,
+			wantErrLen: 0,
+		},
+		{
+			name: "Given unparseable pvc size -> one error",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size = "ten gigs"
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given block volume mode -> one error",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				blockMode := corev1.PersistentVolumeBlock
+				vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.VolumeMode = &blockMode
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given empty spec -> every error reported",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec = VolumeSnapshotRestoreSpec{}
+				return vsr
+			},
+			wantErrLen: 5,
+		},
+		{
+			name: "Given rsync-tls mover with backup ref -> no errors",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.Mover = RsyncTLSDataMover
+				vsr.Spec.ResticSecretRef = corev1.LocalObjectReference{}
+				vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository = ""
+				vsr.Spec.BackupRef = &VolumeSnapshotBackupReference{Name: "sample-vsb"}
+				return vsr
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given rsync mover without backup ref -> one error",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.Mover = RsyncDataMover
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given repository snapshot ref without repository -> one error",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.ResticSecretRef = corev1.LocalObjectReference{}
+				vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository = ""
+				vsr.Spec.RepositorySnapshotRef = &RepositorySnapshotReference{Name: "backup-pvc"}
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+		{
+			name: "Given repository snapshot ref without pvc data -> no errors",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository = ""
+				vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData = PVCData{}
+				vsr.Spec.RepositorySnapshotRef = &RepositorySnapshotReference{Repository: "bsl", Name: "backup-pvc"}
+				return vsr
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given repository snapshot ref with rsync mover -> one error",
+			vsr: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.Mover = RsyncDataMover
+				vsr.Spec.BackupRef = &VolumeSnapshotBackupReference{Name: "sample-vsb"}
+				vsr.Spec.RepositorySnapshotRef = &RepositorySnapshotReference{Repository: "bsl", Name: "backup-pvc"}
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.vsr().ValidateCreate()
+			if got := causeCount(err); got != tt.wantErrLen {
+				t.Errorf("ValidateCreate() error = %v, want %v causes", err, tt.wantErrLen)
+			}
+		})
+	}
+}
+
+func TestVolumeSnapshotRestore_ValidateUpdate(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name       string
+		old        func() *VolumeSnapshotRestore
+		new        func() *VolumeSnapshotRestore
+		wantErrLen int
+	}{
+		{
+			name: "Given metadata only update on started vsr -> no errors",
+			old: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Status.StartTimestamp = &now
+				return vsr
+			},
+			new: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Labels = map[string]string{"velero.io/restore-name": "sample-restore"}
+				return vsr
+			},
+			wantErrLen: 0,
+		},
+		{
+			name: "Given spec update after start -> forbidden",
+			old: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Status.StartTimestamp = &now
+				return vsr
+			},
+			new: func() *VolumeSnapshotRestore {
+				vsr := newTestVSR()
+				vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size = "20Gi"
+				return vsr
+			},
+			wantErrLen: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.new().ValidateUpdate(tt.old())
+			if got := causeCount(err); got != tt.wantErrLen {
+				t.Errorf("ValidateUpdate() error = %v, want %v causes", err, tt.wantErrLen)
+			}
+		})
+	}
+}
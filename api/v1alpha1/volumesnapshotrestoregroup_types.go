@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeSnapshotRestoreGroupSpec defines the desired state of VolumeSnapshotRestoreGroup
+type VolumeSnapshotRestoreGroupSpec struct {
+	// Name and namespace of the completed VolumeSnapshotBackupGroup being restored,
+	// its members are restored when Volumes is empty
+	// +optional
+	BackupGroupRef *VolumeSnapshotBackupReference `json:"backupGroupRef,omitempty"`
+	// Backed up volumes of the group, restored together
+	// +optional
+	Volumes []VSBRef `json:"volumes,omitempty"`
+	// Namespace where the Velero deployment is present
+	ProtectedNamespace string `json:"protectedNamespace,omitempty"`
+	// Restic Secret reference for given BSL
+	ResticSecretRef corev1.LocalObjectReference `json:"resticSecretRef,omitempty"`
+	// Data mover used to restore the volume data, must match the mover used by the backup group
+	// +optional
+	Mover DataMoverType `json:"mover,omitempty"`
+}
+
+// VolumeSnapshotRestoreGroupStatus defines the observed state of VolumeSnapshotRestoreGroup
+type VolumeSnapshotRestoreGroupStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// volumesnapshotrestoregroup phase status
+	Phase VolumeSnapshotGroupPhase `json:"phase,omitempty"`
+	// member volumesnapshotrestores, one per volume
+	// +optional
+	Members []RestoreGroupMember `json:"members,omitempty"`
+	// CompletionTimestamp records the time the group reached a terminal state
+	// +optional
+	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+}
+
+// RestoreGroupMember is the volumesnapshotrestore restoring one volume of the group
+type RestoreGroupMember struct {
+	// name of the backed up PVC
+	PVCName string `json:"pvcName"`
+	// name of the member volumesnapshotrestore
+	VolumeSnapshotRestore string `json:"volumeSnapshotRestore"`
+	// phase of the member volumesnapshotrestore
+	// +optional
+	Phase VolumeSnapshotRestorePhase `json:"phase,omitempty"`
+	// snapshot handle of the restored volume
+	// +optional
+	SnapshotHandle string `json:"snapshotHandle,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=volumesnapshotrestoregroups,shortName=vsrg
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// VolumeSnapshotRestoreGroup is the Schema for the volumesnapshotrestoregroups API
+type VolumeSnapshotRestoreGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeSnapshotRestoreGroupSpec   `json:"spec,omitempty"`
+	Status VolumeSnapshotRestoreGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VolumeSnapshotRestoreGroupList contains a list of VolumeSnapshotRestoreGroup
+type VolumeSnapshotRestoreGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeSnapshotRestoreGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VolumeSnapshotRestoreGroup{}, &VolumeSnapshotRestoreGroupList{})
+}
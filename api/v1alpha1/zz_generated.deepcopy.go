@@ -22,31 +22,861 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupGroupMember) DeepCopyInto(out *BackupGroupMember) {
+	*out = *in
+	in.BackupRef.DeepCopyInto(&out.BackupRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupGroupMember.
+func (in *BackupGroupMember) DeepCopy() *BackupGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverConfig) DeepCopyInto(out *DataMoverConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverConfig.
+func (in *DataMoverConfig) DeepCopy() *DataMoverConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataMoverConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverConfigList) DeepCopyInto(out *DataMoverConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataMoverConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverConfigList.
+func (in *DataMoverConfigList) DeepCopy() *DataMoverConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataMoverConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverConfigSpec) DeepCopyInto(out *DataMoverConfigSpec) {
+	*out = *in
+	if in.ConcurrentBackups != nil {
+		in, out := &in.ConcurrentBackups, &out.ConcurrentBackups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentRestores != nil {
+		in, out := &in.ConcurrentRestores, &out.ConcurrentRestores
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StorageClassMapping != nil {
+		in, out := &in.StorageClassMapping, &out.StorageClassMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VolumeSnapshotClassMapping != nil {
+		in, out := &in.VolumeSnapshotClassMapping, &out.VolumeSnapshotClassMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RestoreCapacityGrowthPercent != nil {
+		in, out := &in.RestoreCapacityGrowthPercent, &out.RestoreCapacityGrowthPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverConfigSpec.
+func (in *DataMoverConfigSpec) DeepCopy() *DataMoverConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverProgress) DeepCopyInto(out *DataMoverProgress) {
+	*out = *in
+	if in.EstimatedTimeRemaining != nil {
+		in, out := &in.EstimatedTimeRemaining, &out.EstimatedTimeRemaining
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverProgress.
+func (in *DataMoverProgress) DeepCopy() *DataMoverProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverStorageClassConfig) DeepCopyInto(out *DataMoverStorageClassConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverStorageClassConfig.
+func (in *DataMoverStorageClassConfig) DeepCopy() *DataMoverStorageClassConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverStorageClassConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataMoverStorageClassConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverStorageClassConfigList) DeepCopyInto(out *DataMoverStorageClassConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataMoverStorageClassConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverStorageClassConfigList.
+func (in *DataMoverStorageClassConfigList) DeepCopy() *DataMoverStorageClassConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverStorageClassConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataMoverStorageClassConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverStorageClassConfigSpec) DeepCopyInto(out *DataMoverStorageClassConfigSpec) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	in.Destination.DeepCopyInto(&out.Destination)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverStorageClassConfigSpec.
+func (in *DataMoverStorageClassConfigSpec) DeepCopy() *DataMoverStorageClassConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverStorageClassConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverStorageClassConfigStatus) DeepCopyInto(out *DataMoverStorageClassConfigStatus) {
+	*out = *in
+	if in.VolumeSnapshotBackups != nil {
+		in, out := &in.VolumeSnapshotBackups, &out.VolumeSnapshotBackups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeSnapshotRestores != nil {
+		in, out := &in.VolumeSnapshotRestores, &out.VolumeSnapshotRestores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastUsedTimestamp != nil {
+		in, out := &in.LastUsedTimestamp, &out.LastUsedTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverStorageClassConfigStatus.
+func (in *DataMoverStorageClassConfigStatus) DeepCopy() *DataMoverStorageClassConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverStorageClassConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMoverVolumeOptions) DeepCopyInto(out *DataMoverVolumeOptions) {
+	*out = *in
+	if in.CacheCapacity != nil {
+		in, out := &in.CacheCapacity, &out.CacheCapacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMoverVolumeOptions.
+func (in *DataMoverVolumeOptions) DeepCopy() *DataMoverVolumeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMoverVolumeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskStatus) DeepCopyInto(out *MaintenanceTaskStatus) {
+	*out = *in
+	if in.LastRunTimestamp != nil {
+		in, out := &in.LastRunTimestamp, &out.LastRunTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.FailedRepositories != nil {
+		in, out := &in.FailedRepositories, &out.FailedRepositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskStatus.
+func (in *MaintenanceTaskStatus) DeepCopy() *MaintenanceTaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PVCData) DeepCopyInto(out *PVCData) {
 	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(corev1.PersistentVolumeMode)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCData.
+func (in *PVCData) DeepCopy() *PVCData {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationDestinationData) DeepCopyInto(out *ReplicationDestinationData) {
+	*out = *in
+	if in.StartTimestamp != nil {
+		in, out := &in.StartTimestamp, &out.StartTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTimestamp != nil {
+		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationData.
+func (in *ReplicationDestinationData) DeepCopy() *ReplicationDestinationData {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationDestinationData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceData) DeepCopyInto(out *ReplicationSourceData) {
+	*out = *in
+	if in.StartTimestamp != nil {
+		in, out := &in.StartTimestamp, &out.StartTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTimestamp != nil {
+		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceData.
+func (in *ReplicationSourceData) DeepCopy() *ReplicationSourceData {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryMaintenance) DeepCopyInto(out *RepositoryMaintenance) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryMaintenance.
+func (in *RepositoryMaintenance) DeepCopy() *RepositoryMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RepositoryMaintenance) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryMaintenanceList) DeepCopyInto(out *RepositoryMaintenanceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RepositoryMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryMaintenanceList.
+func (in *RepositoryMaintenanceList) DeepCopy() *RepositoryMaintenanceList {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryMaintenanceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RepositoryMaintenanceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryMaintenanceSpec) DeepCopyInto(out *RepositoryMaintenanceSpec) {
+	*out = *in
+	out.ResticSecretRef = in.ResticSecretRef
+	if in.UnlockInterval != nil {
+		in, out := &in.UnlockInterval, &out.UnlockInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PruneInterval != nil {
+		in, out := &in.PruneInterval, &out.PruneInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CheckInterval != nil {
+		in, out := &in.CheckInterval, &out.CheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryMaintenanceSpec.
+func (in *RepositoryMaintenanceSpec) DeepCopy() *RepositoryMaintenanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryMaintenanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryMaintenanceStatus) DeepCopyInto(out *RepositoryMaintenanceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]MaintenanceTaskStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryMaintenanceStatus.
+func (in *RepositoryMaintenanceStatus) DeepCopy() *RepositoryMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositorySnapshot) DeepCopyInto(out *RepositorySnapshot) {
+	*out = *in
+	if in.SourcePVCData != nil {
+		in, out := &in.SourcePVCData, &out.SourcePVCData
+		*out = new(PVCData)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SnapshotTimestamp != nil {
+		in, out := &in.SnapshotTimestamp, &out.SnapshotTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositorySnapshot.
+func (in *RepositorySnapshot) DeepCopy() *RepositorySnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositorySnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositorySnapshotReference) DeepCopyInto(out *RepositorySnapshotReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositorySnapshotReference.
+func (in *RepositorySnapshotReference) DeepCopy() *RepositorySnapshotReference {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositorySnapshotReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticKeyRotation) DeepCopyInto(out *ResticKeyRotation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticKeyRotation.
+func (in *ResticKeyRotation) DeepCopy() *ResticKeyRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticKeyRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResticKeyRotation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticKeyRotationList) DeepCopyInto(out *ResticKeyRotationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ResticKeyRotation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticKeyRotationList.
+func (in *ResticKeyRotationList) DeepCopy() *ResticKeyRotationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticKeyRotationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ResticKeyRotationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticKeyRotationSpec) DeepCopyInto(out *ResticKeyRotationSpec) {
+	*out = *in
+	out.ResticSecretRef = in.ResticSecretRef
+	out.NewKeySecretRef = in.NewKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticKeyRotationSpec.
+func (in *ResticKeyRotationSpec) DeepCopy() *ResticKeyRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticKeyRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticKeyRotationStatus) DeepCopyInto(out *ResticKeyRotationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailedRepositories != nil {
+		in, out := &in.FailedRepositories, &out.FailedRepositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTimestamp != nil {
+		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticKeyRotationStatus.
+func (in *ResticKeyRotationStatus) DeepCopy() *ResticKeyRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResticKeyRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreGroupMember) DeepCopyInto(out *RestoreGroupMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreGroupMember.
+func (in *RestoreGroupMember) DeepCopy() *RestoreGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSBRef) DeepCopyInto(out *VSBRef) {
+	*out = *in
+	in.BackedUpPVCData.DeepCopyInto(&out.BackedUpPVCData)
+	if in.SnapshotTimestamp != nil {
+		in, out := &in.SnapshotTimestamp, &out.SnapshotTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSBRef.
+func (in *VSBRef) DeepCopy() *VSBRef {
+	if in == nil {
+		return nil
+	}
+	out := new(VSBRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeOptions) DeepCopyInto(out *VolumeOptions) {
+	*out = *in
+	if in.CacheCapacity != nil {
+		in, out := &in.CacheCapacity, &out.CacheCapacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MoverSecurityContext != nil {
+		in, out := &in.MoverSecurityContext, &out.MoverSecurityContext
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeOptions.
+func (in *VolumeOptions) DeepCopy() *VolumeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackup) DeepCopyInto(out *VolumeSnapshotBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackup.
+func (in *VolumeSnapshotBackup) DeepCopy() *VolumeSnapshotBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackupGroup) DeepCopyInto(out *VolumeSnapshotBackupGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupGroup.
+func (in *VolumeSnapshotBackupGroup) DeepCopy() *VolumeSnapshotBackupGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotBackupGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotBackupGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackupGroupList) DeepCopyInto(out *VolumeSnapshotBackupGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeSnapshotBackupGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupGroupList.
+func (in *VolumeSnapshotBackupGroupList) DeepCopy() *VolumeSnapshotBackupGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotBackupGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotBackupGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackupGroupSpec) DeepCopyInto(out *VolumeSnapshotBackupGroupSpec) {
+	*out = *in
+	if in.VolumeSnapshotContents != nil {
+		in, out := &in.VolumeSnapshotContents, &out.VolumeSnapshotContents
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	out.ResticSecretRef = in.ResticSecretRef
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCData.
-func (in *PVCData) DeepCopy() *PVCData {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupGroupSpec.
+func (in *VolumeSnapshotBackupGroupSpec) DeepCopy() *VolumeSnapshotBackupGroupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PVCData)
+	out := new(VolumeSnapshotBackupGroupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReplicationDestinationData) DeepCopyInto(out *ReplicationDestinationData) {
+func (in *VolumeSnapshotBackupGroupStatus) DeepCopyInto(out *VolumeSnapshotBackupGroupStatus) {
 	*out = *in
-	if in.StartTimestamp != nil {
-		in, out := &in.StartTimestamp, &out.StartTimestamp
-		*out = (*in).DeepCopy()
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]BackupGroupMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.CompletionTimestamp != nil {
 		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
@@ -54,76 +884,152 @@ func (in *ReplicationDestinationData) DeepCopyInto(out *ReplicationDestinationDa
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationDestinationData.
-func (in *ReplicationDestinationData) DeepCopy() *ReplicationDestinationData {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupGroupStatus.
+func (in *VolumeSnapshotBackupGroupStatus) DeepCopy() *VolumeSnapshotBackupGroupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ReplicationDestinationData)
+	out := new(VolumeSnapshotBackupGroupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReplicationSourceData) DeepCopyInto(out *ReplicationSourceData) {
+func (in *VolumeSnapshotBackupList) DeepCopyInto(out *VolumeSnapshotBackupList) {
 	*out = *in
-	if in.StartTimestamp != nil {
-		in, out := &in.StartTimestamp, &out.StartTimestamp
-		*out = (*in).DeepCopy()
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeSnapshotBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.CompletionTimestamp != nil {
-		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
-		*out = (*in).DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupList.
+func (in *VolumeSnapshotBackupList) DeepCopy() *VolumeSnapshotBackupList {
+	if in == nil {
+		return nil
 	}
+	out := new(VolumeSnapshotBackupList)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceData.
-func (in *ReplicationSourceData) DeepCopy() *ReplicationSourceData {
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotBackupReference) DeepCopyInto(out *VolumeSnapshotBackupReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupReference.
+func (in *VolumeSnapshotBackupReference) DeepCopy() *VolumeSnapshotBackupReference {
 	if in == nil {
 		return nil
 	}
-	out := new(ReplicationSourceData)
+	out := new(VolumeSnapshotBackupReference)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VSBRef) DeepCopyInto(out *VSBRef) {
+func (in *VolumeSnapshotBackupSpec) DeepCopyInto(out *VolumeSnapshotBackupSpec) {
 	*out = *in
-	out.BackedUpPVCData = in.BackedUpPVCData
+	out.VolumeSnapshotContent = in.VolumeSnapshotContent
+	out.ResticSecretRef = in.ResticSecretRef
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeOptions != nil {
+		in, out := &in.VolumeOptions, &out.VolumeOptions
+		*out = new(VolumeOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSBRef.
-func (in *VSBRef) DeepCopy() *VSBRef {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupSpec.
+func (in *VolumeSnapshotBackupSpec) DeepCopy() *VolumeSnapshotBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VSBRef)
+	out := new(VolumeSnapshotBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VolumeSnapshotBackup) DeepCopyInto(out *VolumeSnapshotBackup) {
+func (in *VolumeSnapshotBackupStatus) DeepCopyInto(out *VolumeSnapshotBackupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SourcePVCData.DeepCopyInto(&out.SourcePVCData)
+	if in.StartTimestamp != nil {
+		in, out := &in.StartTimestamp, &out.StartTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTimestamp != nil {
+		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+	in.ReplicationSourceData.DeepCopyInto(&out.ReplicationSourceData)
+	if in.NextRetryTimestamp != nil {
+		in, out := &in.NextRetryTimestamp, &out.NextRetryTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(DataMoverProgress)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupStatus.
+func (in *VolumeSnapshotBackupStatus) DeepCopy() *VolumeSnapshotBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRepository) DeepCopyInto(out *VolumeSnapshotRepository) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackup.
-func (in *VolumeSnapshotBackup) DeepCopy() *VolumeSnapshotBackup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRepository.
+func (in *VolumeSnapshotRepository) DeepCopy() *VolumeSnapshotRepository {
 	if in == nil {
 		return nil
 	}
-	out := new(VolumeSnapshotBackup)
+	out := new(VolumeSnapshotRepository)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VolumeSnapshotBackup) DeepCopyObject() runtime.Object {
+func (in *VolumeSnapshotRepository) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -131,31 +1037,31 @@ func (in *VolumeSnapshotBackup) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VolumeSnapshotBackupList) DeepCopyInto(out *VolumeSnapshotBackupList) {
+func (in *VolumeSnapshotRepositoryList) DeepCopyInto(out *VolumeSnapshotRepositoryList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]VolumeSnapshotBackup, len(*in))
+		*out = make([]VolumeSnapshotRepository, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupList.
-func (in *VolumeSnapshotBackupList) DeepCopy() *VolumeSnapshotBackupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRepositoryList.
+func (in *VolumeSnapshotRepositoryList) DeepCopy() *VolumeSnapshotRepositoryList {
 	if in == nil {
 		return nil
 	}
-	out := new(VolumeSnapshotBackupList)
+	out := new(VolumeSnapshotRepositoryList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *VolumeSnapshotBackupList) DeepCopyObject() runtime.Object {
+func (in *VolumeSnapshotRepositoryList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -163,24 +1069,28 @@ func (in *VolumeSnapshotBackupList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VolumeSnapshotBackupSpec) DeepCopyInto(out *VolumeSnapshotBackupSpec) {
+func (in *VolumeSnapshotRepositorySpec) DeepCopyInto(out *VolumeSnapshotRepositorySpec) {
 	*out = *in
-	out.VolumeSnapshotContent = in.VolumeSnapshotContent
 	out.ResticSecretRef = in.ResticSecretRef
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupSpec.
-func (in *VolumeSnapshotBackupSpec) DeepCopy() *VolumeSnapshotBackupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRepositorySpec.
+func (in *VolumeSnapshotRepositorySpec) DeepCopy() *VolumeSnapshotRepositorySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VolumeSnapshotBackupSpec)
+	out := new(VolumeSnapshotRepositorySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VolumeSnapshotBackupStatus) DeepCopyInto(out *VolumeSnapshotBackupStatus) {
+func (in *VolumeSnapshotRepositoryStatus) DeepCopyInto(out *VolumeSnapshotRepositoryStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -189,24 +1099,25 @@ func (in *VolumeSnapshotBackupStatus) DeepCopyInto(out *VolumeSnapshotBackupStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.SourcePVCData = in.SourcePVCData
-	if in.StartTimestamp != nil {
-		in, out := &in.StartTimestamp, &out.StartTimestamp
+	if in.LastDiscoveryTimestamp != nil {
+		in, out := &in.LastDiscoveryTimestamp, &out.LastDiscoveryTimestamp
 		*out = (*in).DeepCopy()
 	}
-	if in.CompletionTimestamp != nil {
-		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
-		*out = (*in).DeepCopy()
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]RepositorySnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	in.ReplicationSourceData.DeepCopyInto(&out.ReplicationSourceData)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotBackupStatus.
-func (in *VolumeSnapshotBackupStatus) DeepCopy() *VolumeSnapshotBackupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRepositoryStatus.
+func (in *VolumeSnapshotRepositoryStatus) DeepCopy() *VolumeSnapshotRepositoryStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(VolumeSnapshotBackupStatus)
+	out := new(VolumeSnapshotRepositoryStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -216,7 +1127,7 @@ func (in *VolumeSnapshotRestore) DeepCopyInto(out *VolumeSnapshotRestore) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -238,6 +1149,124 @@ func (in *VolumeSnapshotRestore) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreGroup) DeepCopyInto(out *VolumeSnapshotRestoreGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreGroup.
+func (in *VolumeSnapshotRestoreGroup) DeepCopy() *VolumeSnapshotRestoreGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotRestoreGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreGroupList) DeepCopyInto(out *VolumeSnapshotRestoreGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeSnapshotRestoreGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreGroupList.
+func (in *VolumeSnapshotRestoreGroupList) DeepCopy() *VolumeSnapshotRestoreGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotRestoreGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreGroupSpec) DeepCopyInto(out *VolumeSnapshotRestoreGroupSpec) {
+	*out = *in
+	if in.BackupGroupRef != nil {
+		in, out := &in.BackupGroupRef, &out.BackupGroupRef
+		*out = new(VolumeSnapshotBackupReference)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]VSBRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.ResticSecretRef = in.ResticSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreGroupSpec.
+func (in *VolumeSnapshotRestoreGroupSpec) DeepCopy() *VolumeSnapshotRestoreGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreGroupStatus) DeepCopyInto(out *VolumeSnapshotRestoreGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]RestoreGroupMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionTimestamp != nil {
+		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreGroupStatus.
+func (in *VolumeSnapshotRestoreGroupStatus) DeepCopy() *VolumeSnapshotRestoreGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotRestoreList) DeepCopyInto(out *VolumeSnapshotRestoreList) {
 	*out = *in
@@ -274,7 +1303,22 @@ func (in *VolumeSnapshotRestoreList) DeepCopyObject() runtime.Object {
 func (in *VolumeSnapshotRestoreSpec) DeepCopyInto(out *VolumeSnapshotRestoreSpec) {
 	*out = *in
 	out.ResticSecretRef = in.ResticSecretRef
-	out.VolumeSnapshotMoverBackupref = in.VolumeSnapshotMoverBackupref
+	if in.BackupRef != nil {
+		in, out := &in.BackupRef, &out.BackupRef
+		*out = new(VolumeSnapshotBackupReference)
+		**out = **in
+	}
+	if in.RepositorySnapshotRef != nil {
+		in, out := &in.RepositorySnapshotRef, &out.RepositorySnapshotRef
+		*out = new(RepositorySnapshotReference)
+		**out = **in
+	}
+	in.VolumeSnapshotMoverBackupref.DeepCopyInto(&out.VolumeSnapshotMoverBackupref)
+	if in.VolumeOptions != nil {
+		in, out := &in.VolumeOptions, &out.VolumeOptions
+		*out = new(VolumeOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreSpec.
@@ -306,6 +1350,11 @@ func (in *VolumeSnapshotRestoreStatus) DeepCopyInto(out *VolumeSnapshotRestoreSt
 		*out = (*in).DeepCopy()
 	}
 	in.ReplicationDestinationData.DeepCopyInto(&out.ReplicationDestinationData)
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(DataMoverProgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreStatus.
@@ -8,6 +8,7 @@ import (
 	"github.com/go-logr/logr"
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
 	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -16,12 +17,14 @@ import (
 
 var cleanupVSRTypes = []client.Object{
 	&corev1.Secret{},
+	&volsyncv1alpha1.ReplicationSource{},
 	&volsyncv1alpha1.ReplicationDestination{},
 }
 
 func (r *VolumeSnapshotBackupReconciler) CleanBackupResources(log logr.Logger) (bool, error) {
 	cleanupVSBTypes := []client.Object{
 		&volsyncv1alpha1.ReplicationSource{},
+		&batchv1.Job{},
 		&corev1.PersistentVolumeClaim{},
 		&corev1.Pod{},
 		&snapv1.VolumeSnapshot{},
@@ -45,8 +48,14 @@ func (r *VolumeSnapshotBackupReconciler) CleanBackupResources(log logr.Logger) (
 		return false, nil
 	}
 
+	// the cloned volume of rsync movers is kept for the volumesnapshotrestores until the vsb is deleted
+	deletingClone := vsb.Spec.Mover.IsClone() && !vsb.DeletionTimestamp.IsZero()
+	if vsb.Spec.Mover.IsClone() && !deletingClone {
+		cleanupVSBTypes = []client.Object{&corev1.Pod{}}
+	}
+
 	// no need to perfrom cleanup for the vsb if the datamovement has already completed, completed phase comes after cleanup
-	if len(vsb.Status.Phase) > 0 && vsb.Status.Phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted {
+	if len(vsb.Status.Phase) > 0 && vsb.Status.Phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted && !deletingClone {
 		r.Log.Info(fmt.Sprintf("skipping CleanBackupResources step for vsb %s/%s as datamovement is complete", vsb.Namespace, vsb.Name))
 		return true, nil
 	}
@@ -79,6 +88,7 @@ func (r *VolumeSnapshotBackupReconciler) CleanBackupResources(log logr.Logger) (
 		if err != nil {
 			return false, err
 		}
+		observeVSBPhase(&vsb)
 	}
 
 	return true, nil
@@ -256,6 +266,7 @@ func (r *VolumeSnapshotRestoreReconciler) CleanRestoreResources(log logr.Logger)
 		if err != nil {
 			return false, err
 		}
+		observeVSRPhase(&vsr)
 	}
 
 	return true, nil
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// setStatusFromClonedPVC completes a vsb using an rsync mover once its cloned PVC is bound,
+// the data is moved later on by the volumesnapshotrestores consuming the cloned PVC
+func (r *VolumeSnapshotBackupReconciler) setStatusFromClonedPVC(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) (bool, error) {
+	if vsb == nil {
+		return false, errors.New("nil vsb in setStatusFromClonedPVC")
+	}
+
+	if vsb.Status.Phase == volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted {
+		return true, nil
+	}
+
+	pvcName := fmt.Sprintf("%s-pvc", vsb.Spec.VolumeSnapshotContent.Name)
+	clonedPVC := corev1.PersistentVolumeClaim{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsb.Spec.ProtectedNamespace, Name: pvcName}, &clonedPVC); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if clonedPVC.Status.Phase != corev1.ClaimBound {
+		r.Log.Info(fmt.Sprintf("waiting for cloned PVC %s/%s to be bound", vsb.Spec.ProtectedNamespace, pvcName))
+		return false, nil
+	}
+
+	r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s batching status as completed", vsb.Name))
+	if err := r.updateVSBBatchingStatus(volsnapmoverv1alpha1.SnapMoverBackupBatchingCompleted, r.Client); err != nil {
+		return false, err
+	}
+
+	r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s VolSync phase as complete, cloned PVC %s/%s is ready", r.req.NamespacedName, vsb.Spec.ProtectedNamespace, pvcName))
+	if err := r.updateVSBStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, r.Client); err != nil {
+		return false, err
+	}
+
+	r.Scheduler.Release(types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name})
+
+	return true, nil
+}
+
+// CreateCloneReplicationSource creates the replicationsource syncing the cloned PVC of the
+// referenced volumesnapshotbackup to the replicationdestination of a vsr using an rsync mover
+func (r *VolumeSnapshotRestoreReconciler) CreateCloneReplicationSource(log logr.Logger) (bool, error) {
+
+	// get volumesnapshotrestore from cluster
+	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+	if err := r.Get(r.Context, r.req.NamespacedName, &vsr); err != nil {
+		// ignore is not found error
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotrestore %s", r.req.NamespacedName))
+		return false, err
+	}
+
+	// only rsync movers read the cloned PVC, other movers restore from the repository
+	if !vsr.Spec.Mover.IsClone() {
+		return true, nil
+	}
+
+	mover, err := getCloneMover(vsr.Spec.Mover)
+	if err != nil {
+		return false, err
+	}
+
+	vsb, err := r.getCloneSource(&vsr)
+	if err != nil {
+		return false, err
+	}
+
+	switch vsb.Status.Phase {
+	case volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed:
+		if err := r.updateVSRStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, r.Client); err != nil {
+			return false, err
+		}
+		r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
+		return false, errors.New(fmt.Sprintf("volumesnapshotbackup %s/%s failed to clone the volume", vsb.Namespace, vsb.Name))
+	case volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted:
+	default:
+		r.Log.Info(fmt.Sprintf("waiting for volumesnapshotbackup %s/%s to clone the volume", vsb.Namespace, vsb.Name))
+		return false, nil
+	}
+
+	// get the cloned pvc of the vsb
+	pvcName := fmt.Sprintf("%s-pvc", vsb.Spec.VolumeSnapshotContent.Name)
+	clonedPVC := corev1.PersistentVolumeClaim{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsr.Spec.ProtectedNamespace, Name: pvcName}, &clonedPVC); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch cloned PVC %s/%s", vsr.Spec.ProtectedNamespace, pvcName))
+		return false, err
+	}
+
+	repDestName := fmt.Sprintf("%s-rep-dest", vsr.Name)
+	repDest := volsyncv1alpha1.ReplicationDestination{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsr.Spec.ProtectedNamespace, Name: repDestName}, &repDest); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	config, err := GetDataMoverStorageClassConfig(vsr.Spec.ProtectedNamespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName, r.Log, r.Client)
+	if err != nil {
+		return false, err
+	}
+
+	veleroSA, err := GetVeleroServiceAccount(vsr.Spec.ProtectedNamespace, r.Client)
+	if err != nil {
+		return false, err
+	}
+
+	replicationSourceSpec, err := mover.buildCloneReplicationSourceSpec(r, &vsr, clonedPVC.Name, &repDest, config, veleroSA)
+	if err != nil {
+		return false, err
+	}
+	if replicationSourceSpec == nil {
+		r.Log.Info(fmt.Sprintf("waiting for replicationdestination %s/%s to accept connections", vsr.Spec.ProtectedNamespace, repDestName))
+		return false, nil
+	}
+
+	// define replicationSource to be created, labelled with the vsr so it is cleaned up with the replicationdestination
+	repSource := &volsyncv1alpha1.ReplicationSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-rep-src", vsr.Name),
+			Namespace: vsr.Spec.ProtectedNamespace,
+			Labels: map[string]string{
+				VSRLabel: vsr.Name,
+			},
+		},
+	}
+
+	// Create ReplicationSource in protected namespace
+	op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, repSource, func() error {
+		if repSource.CreationTimestamp.IsZero() {
+			repSource.Spec = *replicationSourceSpec
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(repSource,
+			corev1.EventTypeNormal,
+			"ReplicationSourceReconciled",
+			fmt.Sprintf("%s replicationsource %s", op, repSource.Name),
+		)
+	}
+	return true, nil
+}
+
+// getCloneSource returns the volumesnapshotbackup whose cloned PVC is consumed by the vsr
+func (r *VolumeSnapshotRestoreReconciler) getCloneSource(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore) (*volsnapmoverv1alpha1.VolumeSnapshotBackup, error) {
+	if vsr == nil {
+		return nil, errors.New("nil vsr in getCloneSource")
+	}
+
+	if vsr.Spec.BackupRef == nil || len(vsr.Spec.BackupRef.Name) == 0 {
+		return nil, errors.New(fmt.Sprintf("volumesnapshotbackup reference cannot be empty for vsr %s/%s using the %s mover", vsr.Namespace, vsr.Name, vsr.Spec.Mover))
+	}
+
+	vsbNamespace := vsr.Spec.BackupRef.Namespace
+	if len(vsbNamespace) == 0 {
+		vsbNamespace = vsr.Namespace
+	}
+
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsbNamespace, Name: vsr.Spec.BackupRef.Name}, &vsb); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotbackup %s/%s", vsbNamespace, vsr.Spec.BackupRef.Name))
+		return nil, err
+	}
+
+	if vsb.Spec.Mover != vsr.Spec.Mover {
+		return nil, errors.New(fmt.Sprintf("volumesnapshotbackup %s/%s uses the %s mover instead of %s", vsb.Namespace, vsb.Name, vsb.Spec.Mover, vsr.Spec.Mover))
+	}
+
+	// the replicationsource has to be in the namespace of the cloned PVC
+	if vsb.Spec.ProtectedNamespace != vsr.Spec.ProtectedNamespace {
+		return nil, errors.New(fmt.Sprintf("volumesnapshotbackup %s/%s protected namespace %s does not match %s", vsb.Namespace, vsb.Name, vsb.Spec.ProtectedNamespace, vsr.Spec.ProtectedNamespace))
+	}
+
+	return &vsb, nil
+}
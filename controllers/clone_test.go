@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"testing"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotRestoreReconciler_CreateCloneReplicationSource(t *testing.T) {
+	address := "10.0.0.1"
+	keySecret := "rsync-tls-key"
+
+	newVSB := func(mover volsnapmoverv1alpha1.DataMoverType, phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "sample-vsb",
+				Namespace: "bar",
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				VolumeSnapshotContent: corev1.ObjectReference{
+					Name: "snapcontent",
+				},
+				ProtectedNamespace: namespace,
+				Mover:              mover,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Phase: phase,
+			},
+		}
+	}
+	newVSR := func(mover volsnapmoverv1alpha1.DataMoverType) *volsnapmoverv1alpha1.VolumeSnapshotRestore {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "sample-vsr",
+				Namespace: "bar",
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+				BackupRef: &volsnapmoverv1alpha1.VolumeSnapshotBackupReference{
+					Name: "sample-vsb",
+				},
+				ProtectedNamespace: namespace,
+				Mover:              mover,
+			},
+		}
+	}
+	clonedPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "snapcontent-pvc",
+			Namespace: namespace,
+		},
+	}
+	veleroSA := &corev1.ServiceAccount{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "velero",
+			Namespace: namespace,
+		},
+	}
+	readyRepDest := &volsyncv1alpha1.ReplicationDestination{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vsr-rep-dest",
+			Namespace: namespace,
+		},
+		Status: &volsyncv1alpha1.ReplicationDestinationStatus{
+			RsyncTLS: &volsyncv1alpha1.ReplicationDestinationRsyncTLSStatus{
+				Address:   &address,
+				KeySecret: &keySecret,
+			},
+		},
+	}
+	pendingRepDest := &volsyncv1alpha1.ReplicationDestination{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vsr-rep-dest",
+			Namespace: namespace,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		objs          []client.Object
+		want          bool
+		wantErr       bool
+		wantRepSource bool
+		wantPhase     volsnapmoverv1alpha1.VolumeSnapshotRestorePhase
+	}{
+		{
+			name: "Given restic vsr -> nothing to do",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.ResticDataMover),
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "Given vsr without referenced vsb -> error",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "Given vsb using a different mover -> error",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+				newVSB(volsnapmoverv1alpha1.RsyncDataMover, volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted),
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "Given vsb still cloning -> wait",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+				newVSB(volsnapmoverv1alpha1.RsyncTLSDataMover, volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress),
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "Given failed vsb -> vsr failed",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+				newVSB(volsnapmoverv1alpha1.RsyncTLSDataMover, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed),
+			},
+			want:      false,
+			wantErr:   true,
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed,
+		},
+		{
+			name: "Given repDest without address -> wait",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+				newVSB(volsnapmoverv1alpha1.RsyncTLSDataMover, volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted),
+				clonedPVC,
+				veleroSA,
+				pendingRepDest,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "Given ready repDest -> replicationsource created",
+			objs: []client.Object{
+				newVSR(volsnapmoverv1alpha1.RsyncTLSDataMover),
+				newVSB(volsnapmoverv1alpha1.RsyncTLSDataMover, volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted),
+				clonedPVC,
+				veleroSA,
+				readyRepDest,
+			},
+			want:          true,
+			wantErr:       false,
+			wantRepSource: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjectsRepSrc(tt.objs...)
+			if err != nil {
+				t.Errorf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotRestoreReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(tt.name),
+				EventRecorder: record.NewFakeRecorder(10),
+				req: reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsr"},
+				},
+			}
+			got, err := r.CreateCloneReplicationSource(r.Log)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateCloneReplicationSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CreateCloneReplicationSource() got = %v, want %v", got, tt.want)
+			}
+
+			repSource := volsyncv1alpha1.ReplicationSource{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: "sample-vsr-rep-src"}, &repSource)
+			if tt.wantRepSource {
+				if err != nil {
+					t.Fatalf("expected replicationsource, got error %v", err)
+				}
+				if repSource.Labels[VSRLabel] != "sample-vsr" {
+					t.Errorf("replicationsource labels = %v, want %s=sample-vsr", repSource.Labels, VSRLabel)
+				}
+				if repSource.Spec.SourcePVC != clonedPVC.Name || repSource.Spec.RsyncTLS == nil || *repSource.Spec.RsyncTLS.Address != address {
+					t.Errorf("replicationsource spec = %v, want rsync-tls from %s to %s", repSource.Spec, clonedPVC.Name, address)
+				}
+			} else if !k8serrors.IsNotFound(err) {
+				t.Errorf("expected no replicationsource, got error %v", err)
+			}
+
+			vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+			if err := fakeClient.Get(r.Context, r.req.NamespacedName, &vsr); err != nil {
+				t.Fatalf("unable to fetch vsr: %v", err)
+			}
+			if vsr.Status.Phase != tt.wantPhase {
+				t.Errorf("vsr phase = %v, want %v", vsr.Status.Phase, tt.wantPhase)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ const (
 	backupLabel                   = "velero.io/backup-name"
 	restoreLabel                  = "velero.io/restore-name"
 	DummyPodImage                 = "quay.io/konveyor/rsync-transfer:latest"
+	VolSyncImage                  = "quay.io/backube/volsync:0.7.0"
 	volumeSnapshotClassDefaultKey = "snapshot.storage.kubernetes.io/is-default-class"
 	storageClassDefaultKey        = "storageclass.kubernetes.io/is-default-class"
 	OADPBSLProviderName           = "openshift.io/oadp-bsl-provider"
@@ -45,19 +46,55 @@ const (
 	AWSAccessKey     = "AWS_ACCESS_KEY_ID"
 	AWSSecretKey     = "AWS_SECRET_ACCESS_KEY"
 	AWSDefaultRegion = "AWS_DEFAULT_REGION"
+	AWSSessionToken  = "AWS_SESSION_TOKEN"
+	// role assumed with the projected service account token, AWS IRSA
+	AWSRoleARN = "AWS_ROLE_ARN"
+	// CA of the endpoint of S3-compatible providers, used when RESTIC_CUSTOM_CA is not set
+	AWSEndpointsCA = "AWS_ENDPOINTS_CA"
+
+	// S3-compatible provider vars, named after the velero BSL config
+	S3Url                 = "s3Url"
+	S3ForcePathStyle      = "s3ForcePathStyle"
+	InsecureSkipTLSVerify = "insecureSkipTLSVerify"
 
 	// Azure vars
 	AzureAccountName = "AZURE_ACCOUNT_NAME"
 	AzureAccountKey  = "AZURE_ACCOUNT_KEY"
+	AzureAccountSAS  = "AZURE_ACCOUNT_SAS"
+	// application federated with the projected service account token, Azure Workload Identity
+	AzureClientID = "AZURE_CLIENT_ID"
+	AzureTenantID = "AZURE_TENANT_ID"
 
 	// GCP vars
 	GoogleApplicationCredentials = "GOOGLE_APPLICATION_CREDENTIALS"
 
+	// Workload identity vars
+	CredentialsServiceAccount = "credentialsServiceAccount"
+	CredentialsDuration       = "credentialsDuration"
+
+	// REST server vars
+	ResticRestUsername = "RESTIC_REST_USERNAME"
+	ResticRestPassword = "RESTIC_REST_PASSWORD"
+
+	// SFTP vars
+	SFTPPrivateKey = "SFTP_PRIVATE_KEY"
+	SFTPKnownHosts = "SFTP_KNOWN_HOSTS"
+
+	// Local vars, the PVC holding the repositories
+	ResticRepositoryPVC = "RESTIC_REPOSITORY_PVC"
+
 	// Restic repo vars
 	ResticCustomCA      = "RESTIC_CUSTOM_CA"
 	ResticPassword      = "RESTIC_PASSWORD"
 	ResticRepository    = "RESTIC_REPOSITORY"
 	ResticPruneInterval = "restic-prune-interval"
+	// layout of the restic repositories, backup or volume
+	ResticRepositoryLayout = "restic-repository-layout"
+
+	// Rclone vars
+	RcloneConfig        = "rclone.conf"
+	RcloneConfigSection = "RCLONE_CONFIG_SECTION"
+	RcloneDestPath      = "RCLONE_DEST_PATH"
 
 	// Datamover annotation keys
 	DatamoverResticRepository = "datamover.io/restic-repository"
@@ -68,6 +105,9 @@ const (
 	AWSProvider   = "aws"
 	AzureProvider = "azure"
 	GCPProvider   = "gcp"
+	RESTProvider  = "rest"
+	SFTPProvider  = "sftp"
+	LocalProvider = "local"
 )
 
 // VSM configmap values
@@ -102,23 +142,6 @@ const (
 	SnapshotScheduleCron = "SnapshotScheduleCron"
 )
 
-// Restic secret vars to create new secrets
-var (
-	AWSAccessValue        []byte
-	AWSSecretValue        []byte
-	AWSDefaultRegionValue []byte
-
-	AzureAccountNameValue []byte
-	AzureAccountKeyValue  []byte
-
-	GoogleApplicationCredentialsValue []byte
-
-	ResticCustomCAValue      []byte
-	ResticPasswordValue      []byte
-	ResticRepoValue          string
-	ResticPruneIntervalValue []byte
-)
-
 type ReconcileFunc func(logr.Logger) (bool, error)
 
 // reconcileBatch steps through a list of reconcile functions until one returns
@@ -161,30 +184,14 @@ func BuildResticSecret(givensecret *corev1.Secret, secret *corev1.Secret, restic
 
 	switch provider {
 	case AWSProvider:
-		// assign new restic secret values
-		for key, val := range givensecret.Data {
-			switch {
-			case key == AWSAccessKey:
-				AWSAccessValue = val
-			case key == AWSSecretKey:
-				AWSSecretValue = val
-			case key == AWSDefaultRegion:
-				AWSDefaultRegionValue = val
-			case key == ResticPassword:
-				ResticPasswordValue = val
-			case key == ResticCustomCA:
-				ResticCustomCAValue = val
-			}
-		}
-
 		// build new Restic secret
 		resticSecretData := &corev1.Secret{
 			Data: map[string][]byte{
-				AWSAccessKey:                AWSAccessValue,
-				AWSSecretKey:                AWSSecretValue,
-				AWSDefaultRegion:            AWSDefaultRegionValue,
-				ResticCustomCA:              ResticCustomCAValue,
-				ResticPassword:              ResticPasswordValue,
+				AWSAccessKey:                givensecret.Data[AWSAccessKey],
+				AWSSecretKey:                givensecret.Data[AWSSecretKey],
+				AWSDefaultRegion:            givensecret.Data[AWSDefaultRegion],
+				ResticCustomCA:              givensecret.Data[ResticCustomCA],
+				ResticPassword:              givensecret.Data[ResticPassword],
 				ResticRepository:            []byte(resticrepo),
 				ResticPruneInterval:         []byte(pruneInterval),
 				SnapshotScheduleCron:        []byte(scheduleCronExpr),
@@ -196,31 +203,35 @@ func BuildResticSecret(givensecret *corev1.Secret, secret *corev1.Secret, restic
 				SnapshotRetainPolicyWithin:  []byte(rpolicy.within),
 			},
 		}
-		secret.Data = resticSecretData.Data
-		return nil
 
-	case AzureProvider:
-		// assign new restic secret values
-		for key, val := range givensecret.Data {
-			switch {
-			case key == AzureAccountName:
-				AzureAccountNameValue = val
-			case key == AzureAccountKey:
-				AzureAccountKeyValue = val
-			case key == ResticPassword:
-				ResticPasswordValue = val
-			case key == ResticCustomCA:
-				ResticCustomCAValue = val
+		// S3-compatible providers, the endpoint is part of the resticrepo
+		if len(givensecret.Data[ResticCustomCA]) == 0 && len(givensecret.Data[AWSEndpointsCA]) > 0 {
+			resticSecretData.Data[ResticCustomCA] = givensecret.Data[AWSEndpointsCA]
+		}
+		// the volsync restic mover cannot skip the verification of the endpoint certificate, so the
+		// certificates the endpoint presents are trusted instead
+		if len(resticSecretData.Data[ResticCustomCA]) == 0 && isInsecureSkipTLSVerify(givensecret) {
+			ca, err := getEndpointCertificates(resticrepo)
+			if err != nil {
+				return err
 			}
+			resticSecretData.Data[ResticCustomCA] = ca
+		}
+		// the session token of the short-lived credentials of a role
+		if len(givensecret.Data[AWSSessionToken]) > 0 {
+			resticSecretData.Data[AWSSessionToken] = givensecret.Data[AWSSessionToken]
 		}
+		secret.Data = resticSecretData.Data
+		return nil
 
+	case AzureProvider:
 		// build new Restic secret
 		resticSecretData := &corev1.Secret{
 			Data: map[string][]byte{
-				AzureAccountName:            AzureAccountNameValue,
-				AzureAccountKey:             AzureAccountKeyValue,
-				ResticCustomCA:              ResticCustomCAValue,
-				ResticPassword:              ResticPasswordValue,
+				AzureAccountName:            givensecret.Data[AzureAccountName],
+				AzureAccountKey:             givensecret.Data[AzureAccountKey],
+				ResticCustomCA:              givensecret.Data[ResticCustomCA],
+				ResticPassword:              givensecret.Data[ResticPassword],
 				ResticRepository:            []byte(resticrepo),
 				ResticPruneInterval:         []byte(pruneInterval),
 				SnapshotScheduleCron:        []byte(scheduleCronExpr),
@@ -232,28 +243,21 @@ func BuildResticSecret(givensecret *corev1.Secret, secret *corev1.Secret, restic
 				SnapshotRetainPolicyWithin:  []byte(rpolicy.within),
 			},
 		}
+
+		// the SAS of the short-lived credentials of a workload identity
+		if len(givensecret.Data[AzureAccountSAS]) > 0 {
+			resticSecretData.Data[AzureAccountSAS] = givensecret.Data[AzureAccountSAS]
+		}
 		secret.Data = resticSecretData.Data
 		return nil
 
 	case GCPProvider:
-		// assign new restic secret values
-		for key, val := range givensecret.Data {
-			switch {
-			case key == GoogleApplicationCredentials:
-				GoogleApplicationCredentialsValue = val
-			case key == ResticPassword:
-				ResticPasswordValue = val
-			case key == ResticCustomCA:
-				ResticCustomCAValue = val
-			}
-		}
-
 		// build new Restic secret
 		resticSecretData := &corev1.Secret{
 			Data: map[string][]byte{
-				GoogleApplicationCredentials: GoogleApplicationCredentialsValue,
-				ResticCustomCA:               ResticCustomCAValue,
-				ResticPassword:               ResticPasswordValue,
+				GoogleApplicationCredentials: givensecret.Data[GoogleApplicationCredentials],
+				ResticCustomCA:               givensecret.Data[ResticCustomCA],
+				ResticPassword:               givensecret.Data[ResticPassword],
 				ResticRepository:             []byte(resticrepo),
 				ResticPruneInterval:          []byte(pruneInterval),
 				SnapshotScheduleCron:         []byte(scheduleCronExpr),
@@ -267,9 +271,35 @@ func BuildResticSecret(givensecret *corev1.Secret, secret *corev1.Secret, restic
 		}
 		secret.Data = resticSecretData.Data
 		return nil
+
+	case RESTProvider, SFTPProvider, LocalProvider:
+		// the movers reach the repository through a rest server, which takes its credentials from the url
+		repo, err := getRESTRepository(resticrepo, givensecret)
+		if err != nil {
+			return err
+		}
+
+		// build new Restic secret
+		secret.Data = map[string][]byte{
+			ResticCustomCA:              givensecret.Data[ResticCustomCA],
+			ResticPassword:              givensecret.Data[ResticPassword],
+			ResticRepository:            []byte(repo),
+			ResticPruneInterval:         []byte(pruneInterval),
+			SnapshotScheduleCron:        []byte(scheduleCronExpr),
+			SnapshotRetainPolicyDaily:   []byte(rpolicy.daily),
+			SnapshotRetainPolicyWeekly:  []byte(rpolicy.weekly),
+			SnapshotRetainPolicyHourly:  []byte(rpolicy.hourly),
+			SnapshotRetainPolicyMonthly: []byte(rpolicy.monthly),
+			SnapshotRetainPolicyYearly:  []byte(rpolicy.yearly),
+			SnapshotRetainPolicyWithin:  []byte(rpolicy.within),
+		}
+		return nil
+
+	case "":
+		return errors.New(fmt.Sprintf("restic secret %s/%s is missing the %s label", givensecret.Namespace, givensecret.Name, OADPBSLProviderName))
 	}
 
-	return nil
+	return errors.New(fmt.Sprintf("unsupported restic secret provider %q", provider))
 }
 
 func ValidateResticSecret(resticsecret *corev1.Secret) error {
@@ -356,6 +386,50 @@ func ValidateResticSecret(resticsecret *corev1.Secret) error {
 				}
 			}
 		}
+
+	case RESTProvider, SFTPProvider, LocalProvider:
+		return validateResticServerSecret(resticsecret, provider)
+
+	case "":
+		return errors.New(fmt.Sprintf("restic secret %s/%s is missing the %s label", resticsecret.Namespace, resticsecret.Name, OADPBSLProviderName))
+
+	default:
+		return errors.New(fmt.Sprintf("unsupported restic secret provider %q", provider))
+	}
+
+	return nil
+}
+
+// resticServerRequiredKeys lists the keys the restic secret of the rest, sftp and local providers must set
+var resticServerRequiredKeys = map[string][]string{
+	RESTProvider:  {ResticPassword, ResticRepository},
+	SFTPProvider:  {ResticPassword, ResticRepository, SFTPPrivateKey},
+	LocalProvider: {ResticPassword, ResticRepositoryPVC},
+}
+
+// validateResticServerSecret returns an error if the restic secret of a rest, sftp or local repository misses a key
+func validateResticServerSecret(resticsecret *corev1.Secret, provider string) error {
+	for _, key := range resticServerRequiredKeys[provider] {
+		if !checkByteArrayIsEmpty(resticsecret.Data[key]) {
+			return errors.New(fmt.Sprintf("%s value cannot be empty for provider %s", key, provider))
+		}
+	}
+
+	// the rest server credentials are used together
+	if checkByteArrayIsEmpty(resticsecret.Data[ResticRestUsername]) != checkByteArrayIsEmpty(resticsecret.Data[ResticRestPassword]) {
+		return errors.New(fmt.Sprintf("%s and %s must be set together", ResticRestUsername, ResticRestPassword))
+	}
+
+	resticRepo := string(resticsecret.Data[ResticRepository])
+	switch provider {
+	case RESTProvider:
+		if _, err := getRESTRepository(resticRepo, resticsecret); err != nil {
+			return err
+		}
+	case SFTPProvider:
+		if _, _, _, _, err := parseSFTPRepository(resticRepo); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -389,6 +463,11 @@ func (r *VolumeSnapshotBackupReconciler) setVSBStatus(log logr.Logger) (bool, er
 		return false, errors.New("vsb failed to complete")
 	}
 
+	// rsync movers only clone the volume, the data is moved by the volumesnapshotrestores
+	if vsb.Spec.Mover.IsClone() {
+		return r.setStatusFromClonedPVC(&vsb)
+	}
+
 	repSourceName := fmt.Sprintf("%s-rep-src", vsb.Name)
 	repSource := volsyncv1alpha1.ReplicationSource{}
 	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsb.Spec.ProtectedNamespace, Name: repSourceName}, &repSource); err != nil {
@@ -496,6 +575,7 @@ func updateVSBFromBackup(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, client
 		if err != nil {
 			return err
 		}
+		observeVSBPhase(vsb)
 		return errors.New("backup failed. Marking volumeSnapshotBackup as partiallyFailed")
 	}
 	return nil
@@ -541,6 +621,7 @@ func updateVSRFromRestore(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, clien
 		if err != nil {
 			return err
 		}
+		observeVSRPhase(vsr)
 		return errors.New("restore failed. Marking volumeSnapshotRestore as partiallyFailed")
 	}
 	return nil
@@ -554,6 +635,9 @@ func (r *VolumeSnapshotRestoreReconciler) updateVSRStatusPhase(repDest *volsyncv
 	}
 
 	vsr.Status.Phase = phase
+	if phase == volsnapmoverv1alpha1.SnapMoverRestoreVolSyncPhaseCompleted {
+		vsr.Status.Progress = completedProgress(vsr.Status.Progress)
+	}
 
 	// recording completion timestamp for VSR as completed is a terminal state
 	now := metav1.Now()
@@ -567,6 +651,7 @@ func (r *VolumeSnapshotRestoreReconciler) updateVSRStatusPhase(repDest *volsyncv
 	if err != nil {
 		return err
 	}
+	observeVSRPhase(&vsr)
 
 	return nil
 }
@@ -596,6 +681,9 @@ func (r *VolumeSnapshotBackupReconciler) updateVSBStatusPhase(repSource *volsync
 	}
 
 	vsb.Status.Phase = phase
+	if phase == volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted {
+		vsb.Status.Progress = completedProgress(vsb.Status.Progress)
+	}
 
 	// recording completion timestamp for VSB as completed is a terminal state
 	now := metav1.Now()
@@ -604,12 +692,14 @@ func (r *VolumeSnapshotBackupReconciler) updateVSBStatusPhase(repSource *volsync
 	//recording replication source completion timestamp on VSB's status
 	if repSource != nil && repSource.Status.LastSyncTime != nil {
 		vsb.Status.ReplicationSourceData.CompletionTimestamp = repSource.Status.LastSyncTime
+		vsb.Status.ReplicationSourceData.SnapshotID = getResticSnapshotID(repSource.Status.LatestMoverStatus, resticSnapshotSavedRegex)
 	}
 
 	err := client.Status().Update(context.Background(), &vsb)
 	if err != nil {
 		return err
 	}
+	observeVSBPhase(&vsb)
 
 	return nil
 }
@@ -622,6 +712,25 @@ func (r *VolumeSnapshotBackupReconciler) updateVSBBatchingStatus(batchStatus vol
 	}
 
 	vsb.Status.BatchingStatus = batchStatus
+	vsb.Status.QueuePosition = 0
+
+	err := client.Status().Update(context.Background(), &vsb)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *VolumeSnapshotBackupReconciler) updateVSBQueuePosition(position int32, client client.Client) error {
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := r.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotbackup %s", r.req.NamespacedName))
+		return err
+	}
+
+	vsb.Status.BatchingStatus = volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued
+	vsb.Status.QueuePosition = position
 
 	err := client.Status().Update(context.Background(), &vsb)
 	if err != nil {
@@ -725,69 +834,134 @@ func GetRestoreBatchValue(namespace string, client client.Client) (string, error
 	return restoreBatchValue, nil
 }
 
-func (r *VolumeSnapshotBackupReconciler) setVSBQueue(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, log logr.Logger) (bool, error) {
+// initBackupScheduler sets the backup concurrency limit from the deployment env, unless
+// set by the DataMoverConfig, and rebuilds the processing slots from the
+// volumesnapshotbackups in the cluster
+func (r *VolumeSnapshotBackupReconciler) initBackupScheduler(namespace string) error {
+	if r.Scheduler.Limit() == 0 {
+		batchValue, err := GetBackupBatchValue(namespace, r.Client)
+		if err != nil {
+			return err
+		}
 
-	// update non-processed VSB as queued
-	if processingVSBs >= VSBBatchNumber && len(vsb.Status.BatchingStatus) == 0 {
-		log.Info(fmt.Sprintf("marking vsb %v batching status as queued", vsb.Name))
+		batchNumber, err := strconv.Atoi(batchValue)
+		if err != nil {
+			return err
+		}
+		r.Scheduler.SetLimit(batchNumber)
+	}
+
+	return r.Scheduler.Sync(func() ([]types.NamespacedName, error) {
+		return processingVSBKeys(r.Client)
+	})
+}
 
-		err := r.updateVSBBatchingStatus(volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued, r.Client)
+// initRestoreScheduler sets the restore concurrency limit from the deployment env, unless
+// set by the DataMoverConfig, and rebuilds the processing slots from the
+// volumesnapshotrestores in the cluster
+func (r *VolumeSnapshotRestoreReconciler) initRestoreScheduler(namespace string) error {
+	if r.Scheduler.Limit() == 0 {
+		batchValue, err := GetRestoreBatchValue(namespace, r.Client)
 		if err != nil {
-			return false, err
+			return err
 		}
 
-		// requeue VSB is max batch number is still being processed
-	} else if processingVSBs >= VSBBatchNumber && vsb.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued {
-		return false, nil
+		batchNumber, err := strconv.Atoi(batchValue)
+		if err != nil {
+			return err
+		}
+		r.Scheduler.SetLimit(batchNumber)
+	}
+
+	return r.Scheduler.Sync(func() ([]types.NamespacedName, error) {
+		return processingVSRKeys(r.Client)
+	})
+}
+
+func (r *VolumeSnapshotBackupReconciler) setVSBQueue(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, log logr.Logger) (bool, error) {
+	key := types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name}
 
-		// add a queued VSB to processing batch
-	} else if processingVSBs < VSBBatchNumber && (vsb.Status.BatchingStatus == "" ||
-		vsb.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued) {
+	switch vsb.Status.BatchingStatus {
+	case volsnapmoverv1alpha1.SnapMoverBackupBatchingCompleted:
+		return true, nil
 
+	// VSB already holds a slot, make sure it is accounted for
+	case volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing:
+		r.Scheduler.Track(key)
+		return true, nil
+	}
+
+	waiting, err := queuedVSBs(r.Client)
+	if err != nil {
+		return false, err
+	}
+
+	// add a new or queued VSB to processing batch if it is next in the queue
+	scheduled, position := r.Scheduler.Schedule(key, waiting)
+	if scheduled {
 		log.Info(fmt.Sprintf("marking vsb %v batching status as processing", vsb.Name))
 
 		err := r.updateVSBBatchingStatus(volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing, r.Client)
 		if err != nil {
+			r.Scheduler.Release(key)
 			return false, err
 		}
 
-		processingVSBs++
+		return true, nil
 	}
 
-	return true, nil
-}
-
-func (r *VolumeSnapshotRestoreReconciler) setVSRQueue(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, log logr.Logger) (bool, error) {
-
-	// update non-processed VSR as queued
-	if processingVSRs >= VSRBatchNumber && len(vsr.Status.BatchingStatus) == 0 {
-		log.Info(fmt.Sprintf("marking vsr %v batching status as queued", vsr.Name))
+	// update non-processed VSB as queued along with its queue position
+	if vsb.Status.BatchingStatus != volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued || vsb.Status.QueuePosition != position {
+		log.Info(fmt.Sprintf("marking vsb %v batching status as queued at position %v", vsb.Name, position))
 
-		err := r.updateVSRBatchingStatus(volsnapmoverv1alpha1.SnapMoverRestoreBatchingQueued, r.Client)
+		err := r.updateVSBQueuePosition(position, r.Client)
 		if err != nil {
 			return false, err
 		}
+	}
 
-		// requeue VSR is max batch number is still being processed
-	} else if processingVSRs >= VSRBatchNumber && vsr.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverRestoreBatchingQueued {
-		return false, nil
+	// requeue VSB as max batch number is still being processed
+	return false, nil
+}
+
+func (r *VolumeSnapshotRestoreReconciler) setVSRQueue(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, log logr.Logger) (bool, error) {
+	key := types.NamespacedName{Namespace: vsr.Namespace, Name: vsr.Name}
+
+	switch vsr.Status.BatchingStatus {
+	case volsnapmoverv1alpha1.SnapMoverRestoreBatchingCompleted:
+		return true, nil
 
-		// add a queued VSR to processing batch
-	} else if processingVSRs < VSRBatchNumber && (vsr.Status.BatchingStatus == "" ||
-		vsr.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverRestoreBatchingQueued) {
+	// VSR already holds a slot, make sure it is accounted for
+	case volsnapmoverv1alpha1.SnapMoverRestoreBatchingProcessing:
+		r.Scheduler.Track(key)
+		return true, nil
+	}
 
+	// add a new or queued VSR to processing batch if a slot is free
+	if r.Scheduler.TryAcquire(key) {
 		log.Info(fmt.Sprintf("marking vsr %v batching status as processing", vsr.Name))
 
-		vsr.Status.BatchingStatus = volsnapmoverv1alpha1.SnapMoverRestoreBatchingProcessing
 		err := r.updateVSRBatchingStatus(volsnapmoverv1alpha1.SnapMoverRestoreBatchingProcessing, r.Client)
 		if err != nil {
+			r.Scheduler.Release(key)
 			return false, err
 		}
 
-		processingVSRs++
+		return true, nil
 	}
 
-	return true, nil
+	// update non-processed VSR as queued
+	if len(vsr.Status.BatchingStatus) == 0 {
+		log.Info(fmt.Sprintf("marking vsr %v batching status as queued", vsr.Name))
+
+		err := r.updateVSRBatchingStatus(volsnapmoverv1alpha1.SnapMoverRestoreBatchingQueued, r.Client)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// requeue VSR as max batch number is still being processed
+	return false, nil
 }
 
 func GetPodSecurityContext(namespace string, sourcePVCName string, c client.Client) (*corev1.PodSecurityContext, error) {
@@ -97,6 +97,7 @@ func Test_BuildResticSecret(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "givensecret",
 					Namespace: "test-ns",
+					Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
 				},
 			},
 			secret: &corev1.Secret{
@@ -108,6 +109,41 @@ func Test_BuildResticSecret(t *testing.T) {
 			want:    true,
 			wantErr: false,
 		},
+		{
+			name: "Given givenSecret without provider label, should error out",
+			givensecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "givensecret",
+					Namespace: "test-ns",
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "test-ns",
+				},
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "Given givenSecret with unknown provider label, should error out",
+			givensecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "givensecret",
+					Namespace: "test-ns",
+					Labels:    map[string]string{OADPBSLProviderName: "ibm"},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "test-ns",
+				},
+			},
+			want:    false,
+			wantErr: true,
+		},
 		{
 			name:        "Given nil givenSecret, should error out",
 			givensecret: nil,
@@ -136,12 +172,75 @@ func Test_BuildResticSecret(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := BuildResticSecret(tt.givensecret, tt.secret, "repo", "14", &RetainPolicy{}, "")
-			if err != nil && tt.wantErr {
-				t.Logf("BuildResticSecret() error = %v, wantErr %v", err, tt.wantErr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BuildResticSecret() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if err == nil && tt.want && !tt.wantErr {
-				t.Logf("BuildResticSecret err = %v, wantErr %v", err, tt.wantErr)
+			if tt.want && string(tt.secret.Data[ResticRepository]) != "repo" {
+				t.Errorf("BuildResticSecret() %s = %s, want repo", ResticRepository, tt.secret.Data[ResticRepository])
+			}
+		})
+	}
+}
+
+func Test_ValidateResticSecret(t *testing.T) {
+	newSecret := func(provider string, data map[string][]byte) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "givensecret",
+				Namespace: "test-ns",
+			},
+			Data: data,
+		}
+		if len(provider) > 0 {
+			secret.Labels = map[string]string{OADPBSLProviderName: provider}
+		}
+		return secret
+	}
+
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		wantErr bool
+	}{
+		{
+			name:   "Given aws secret, should pass",
+			secret: newSecret(AWSProvider, secretData),
+		},
+		{
+			name:    "Given nil secret, should error out",
+			wantErr: true,
+		},
+		{
+			name:    "Given secret without data, should error out",
+			secret:  newSecret(AWSProvider, nil),
+			wantErr: true,
+		},
+		{
+			name:    "Given secret without provider label, should error out",
+			secret:  newSecret("", secretData),
+			wantErr: true,
+		},
+		{
+			name:    "Given secret with unknown provider label, should error out",
+			secret:  newSecret("ibm", secretData),
+			wantErr: true,
+		},
+		{
+			name: "Given aws secret with empty restic password, should error out",
+			secret: newSecret(AWSProvider, map[string][]byte{
+				AWSAccessKey:     []byte("access-key"),
+				AWSSecretKey:     []byte("secret-key"),
+				ResticPassword:   []byte(""),
+				ResticRepository: []byte("s3:s3.amazonaws.com/bucket"),
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateResticSecret(tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateResticSecret() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,523 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// expiration of the short-lived credentials written to a mover secret, RFC3339
+	CredentialsExpirationAnnotation = "datamover.oadp.openshift.io/credentials-expiration"
+
+	defaultCredentialsServiceAccount = "velero"
+	defaultCredentialsDuration       = time.Hour
+	// credentials expiring within the refresh window are resolved again
+	credentialsRefreshWindow = 10 * time.Minute
+
+	awsWebIdentityAudience   = "sts.amazonaws.com"
+	azureWebIdentityAudience = "api://AzureADTokenExchange"
+	azureStorageScope        = "https://storage.azure.com/.default"
+	azureStorageVersion      = "2020-12-06"
+
+	// the volsync restic mover mounts GOOGLE_APPLICATION_CREDENTIALS at this path
+	moverGoogleCredentialsFile = "/credentials/gcs.json"
+	// the projected token is embedded in the external account configuration under this field
+	googleSubjectTokenField = "subject_token"
+	// the external account configuration may only send the token to these hosts
+	googleSTSHost           = "sts.googleapis.com"
+	googleImpersonationHost = "iamcredentials.googleapis.com"
+
+	// set by the admin on the controller, the restic secrets cannot choose the service account and GCP audience
+	credentialsServiceAccountsEnv  = "DATA_MOVER_CREDENTIALS_SERVICE_ACCOUNTS"
+	gcpWorkloadIdentityAudienceEnv = "DATA_MOVER_GCP_WORKLOAD_IDENTITY_AUDIENCE"
+)
+
+//+kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+
+// CredentialResolver returns the short-lived credentials of a restic secret configured for a workload identity
+type CredentialResolver interface {
+	Resolve(ctx context.Context, secret *corev1.Secret, resticRepo string) (*ResolvedCredentials, error)
+}
+
+// ResolvedCredentials are the secret keys of short-lived credentials, along with their expiration
+type ResolvedCredentials struct {
+	Data       map[string][]byte
+	Expiration time.Time
+}
+
+// workloadIdentityResolver exchanges a token of the credentials service account for the credentials of
+// AWS IRSA, Azure Workload Identity and GCP Workload Identity Federation
+type workloadIdentityResolver struct {
+	clientset  kubernetes.Interface
+	httpClient *http.Client
+	now        func() time.Time
+
+	// the service accounts a restic secret may request a token of, and the audience of the GCP workload
+	// identity pool, set by the admin on the controller
+	serviceAccounts []string
+	gcpAudience     string
+
+	// endpoints, overridden in tests
+	awsSTSEndpoint     string
+	azureAuthorityHost string
+	azureBlobEndpoint  string
+}
+
+// NewCredentialResolver returns a CredentialResolver requesting the service account tokens with the given clientset
+func NewCredentialResolver(clientset kubernetes.Interface) CredentialResolver {
+	return &workloadIdentityResolver{
+		clientset:          clientset,
+		httpClient:         &http.Client{Timeout: 30 * time.Second},
+		now:                time.Now,
+		serviceAccounts:    getCredentialsServiceAccounts(),
+		gcpAudience:        os.Getenv(gcpWorkloadIdentityAudienceEnv),
+		azureAuthorityHost: "https://login.microsoftonline.com",
+		azureBlobEndpoint:  "https://%s.blob.core.windows.net",
+	}
+}
+
+// getCredentialsServiceAccounts returns the service accounts the restic secrets may request a token of,
+// the comma separated DATA_MOVER_CREDENTIALS_SERVICE_ACCOUNTS or velero
+func getCredentialsServiceAccounts() []string {
+	serviceAccounts := []string{}
+	for _, serviceAccount := range strings.Split(os.Getenv(credentialsServiceAccountsEnv), ",") {
+		if serviceAccount = strings.TrimSpace(serviceAccount); len(serviceAccount) > 0 {
+			serviceAccounts = append(serviceAccounts, serviceAccount)
+		}
+	}
+	if len(serviceAccounts) == 0 {
+		return []string{defaultCredentialsServiceAccount}
+	}
+	return serviceAccounts
+}
+
+// usesWorkloadIdentity returns whether the credentials of a restic secret are resolved from a workload identity
+func usesWorkloadIdentity(secret *corev1.Secret) bool {
+	switch secret.Labels[OADPBSLProviderName] {
+	case AWSProvider:
+		return len(secret.Data[AWSRoleARN]) > 0 && len(secret.Data[AWSAccessKey]) == 0
+	case AzureProvider:
+		return len(secret.Data[AzureClientID]) > 0 && len(secret.Data[AzureTenantID]) > 0 && len(secret.Data[AzureAccountKey]) == 0
+	case GCPProvider:
+		config := struct {
+			Type string `json:"type"`
+		}{}
+		return json.Unmarshal(secret.Data[GoogleApplicationCredentials], &config) == nil && config.Type == "external_account"
+	}
+
+	return false
+}
+
+// resolvedCredentialKeys lists the keys of the short-lived credentials of each provider
+var resolvedCredentialKeys = map[string][]string{
+	AWSProvider:   {AWSAccessKey, AWSSecretKey, AWSSessionToken},
+	AzureProvider: {AzureAccountSAS},
+	GCPProvider:   {GoogleApplicationCredentials},
+}
+
+// resolveResticSecret returns the restic secret the mover secret is built from. Workload identity credentials
+// are resolved, unless the mover secret already holds credentials outside of the refresh window, and their
+// expiration is recorded on the mover secret
+func resolveResticSecret(ctx context.Context, resolver CredentialResolver, givensecret *corev1.Secret, moverSecret *corev1.Secret,
+	resticRepo string, now time.Time) (*corev1.Secret, error) {
+
+	if !usesWorkloadIdentity(givensecret) {
+		return givensecret, nil
+	}
+
+	resolved := givensecret.DeepCopy()
+	keys := resolvedCredentialKeys[givensecret.Labels[OADPBSLProviderName]]
+	if expiration, err := time.Parse(time.RFC3339, moverSecret.Annotations[CredentialsExpirationAnnotation]); err == nil &&
+		expiration.Sub(now) > credentialsRefreshWindow {
+		for _, key := range keys {
+			resolved.Data[key] = moverSecret.Data[key]
+		}
+		return resolved, nil
+	}
+
+	if resolver == nil {
+		return nil, errors.New(fmt.Sprintf("restic secret %s uses a workload identity, but no credential resolver is configured", givensecret.Name))
+	}
+	creds, err := resolver.Resolve(ctx, givensecret, resticRepo)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		resolved.Data[key] = creds.Data[key]
+	}
+
+	if moverSecret.Annotations == nil {
+		moverSecret.Annotations = map[string]string{}
+	}
+	moverSecret.Annotations[CredentialsExpirationAnnotation] = creds.Expiration.UTC().Format(time.RFC3339)
+	return resolved, nil
+}
+
+// Resolve returns the short-lived credentials of a restic secret configured for a workload identity
+func (r *workloadIdentityResolver) Resolve(ctx context.Context, secret *corev1.Secret, resticRepo string) (*ResolvedCredentials, error) {
+	duration := defaultCredentialsDuration
+	if val := string(secret.Data[CredentialsDuration]); len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 15*time.Minute {
+			return nil, errors.New(fmt.Sprintf("invalid %s %q, at least 15m is expected", CredentialsDuration, val))
+		}
+		duration = d
+	}
+
+	switch provider := secret.Labels[OADPBSLProviderName]; provider {
+	case AWSProvider:
+		return r.resolveAWS(ctx, secret, duration)
+	case AzureProvider:
+		return r.resolveAzure(ctx, secret, resticRepo, duration)
+	case GCPProvider:
+		return r.resolveGCP(ctx, secret, duration)
+	default:
+		return nil, errors.New(fmt.Sprintf("workload identity is not supported for provider %q", provider))
+	}
+}
+
+// serviceAccountToken requests a token of the credentials service account of a restic secret
+func (r *workloadIdentityResolver) serviceAccountToken(ctx context.Context, secret *corev1.Secret, audience string,
+	duration time.Duration) (string, error) {
+
+	serviceAccount := string(secret.Data[CredentialsServiceAccount])
+	if len(serviceAccount) == 0 {
+		serviceAccount = defaultCredentialsServiceAccount
+	}
+	if !isAllowedServiceAccount(r.serviceAccounts, serviceAccount) {
+		return "", errors.New(fmt.Sprintf("service account %s/%s is not allowed by %s %q", secret.Namespace, serviceAccount,
+			credentialsServiceAccountsEnv, strings.Join(r.serviceAccounts, ",")))
+	}
+
+	expirationSeconds := int64(duration.Seconds())
+	tokenRequest, err := r.clientset.CoreV1().ServiceAccounts(secret.Namespace).CreateToken(ctx, serviceAccount,
+		&authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				Audiences:         []string{audience},
+				ExpirationSeconds: &expirationSeconds,
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("cannot request a token of service account %s/%s: %v", secret.Namespace, serviceAccount, err))
+	}
+	if len(tokenRequest.Status.Token) == 0 {
+		return "", errors.New(fmt.Sprintf("empty token for service account %s/%s", secret.Namespace, serviceAccount))
+	}
+
+	return tokenRequest.Status.Token, nil
+}
+
+// isAllowedServiceAccount returns whether a restic secret may request a token of the service account
+func isAllowedServiceAccount(serviceAccounts []string, serviceAccount string) bool {
+	for _, allowed := range serviceAccounts {
+		if allowed == serviceAccount {
+			return true
+		}
+	}
+	return false
+}
+
+// do sends a request, returning the body of a successful response
+func (r *workloadIdentityResolver) do(req *http.Request) ([]byte, error) {
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.New(fmt.Sprintf("%s %s returned %s: %s", req.Method, req.URL.Host, resp.Status, strings.TrimSpace(string(body))))
+	}
+
+	return body, nil
+}
+
+// resolveAWS assumes the AWS_ROLE_ARN role with a web identity token of the service account
+func (r *workloadIdentityResolver) resolveAWS(ctx context.Context, secret *corev1.Secret, duration time.Duration) (*ResolvedCredentials, error) {
+	token, err := r.serviceAccountToken(ctx, secret, awsWebIdentityAudience, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := r.awsSTSEndpoint
+	if len(endpoint) == 0 {
+		endpoint = "https://sts.amazonaws.com"
+		if region := string(secret.Data[AWSDefaultRegion]); len(region) > 0 {
+			endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", region)
+		}
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {string(secret.Data[AWSRoleARN])},
+		"RoleSessionName":  {fmt.Sprintf("oadp-datamover-%s", secret.Namespace)},
+		"WebIdentityToken": {token},
+		"DurationSeconds":  {fmt.Sprintf("%d", int64(duration.Seconds()))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := r.do(req)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot assume role %s: %v", secret.Data[AWSRoleARN], err))
+	}
+
+	resp := struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}{}
+	if err := xml.Unmarshal(body, &resp); err != nil || len(resp.Credentials.SessionToken) == 0 {
+		return nil, errors.New(fmt.Sprintf("cannot parse the credentials of role %s", secret.Data[AWSRoleARN]))
+	}
+
+	return &ResolvedCredentials{
+		Data: map[string][]byte{
+			AWSAccessKey:    []byte(resp.Credentials.AccessKeyID),
+			AWSSecretKey:    []byte(resp.Credentials.SecretAccessKey),
+			AWSSessionToken: []byte(resp.Credentials.SessionToken),
+		},
+		Expiration: resp.Credentials.Expiration,
+	}, nil
+}
+
+// resolveAzure signs a user delegation SAS of the container of the repository, with a storage token of the
+// AZURE_CLIENT_ID application federated with the service account
+func (r *workloadIdentityResolver) resolveAzure(ctx context.Context, secret *corev1.Secret, resticRepo string,
+	duration time.Duration) (*ResolvedCredentials, error) {
+
+	// azure:container:/prefix
+	container, _, found := strings.Cut(strings.TrimPrefix(resticRepo, "azure:"), ":")
+	if !strings.HasPrefix(resticRepo, "azure:") || !found || len(container) == 0 {
+		return nil, errors.New(fmt.Sprintf("restic repository %q is not an azure repository", resticRepo))
+	}
+	account := string(secret.Data[AzureAccountName])
+	if len(account) == 0 {
+		return nil, errors.New(fmt.Sprintf("%s value cannot be empty for a workload identity", AzureAccountName))
+	}
+
+	token, err := r.serviceAccountToken(ctx, secret, azureWebIdentityAudience, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"client_id":             {string(secret.Data[AzureClientID])},
+		"scope":                 {azureStorageScope},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {token},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimRight(r.azureAuthorityHost, "/"), secret.Data[AzureTenantID]),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := r.do(req)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot get a storage token of client %s: %v", secret.Data[AzureClientID], err))
+	}
+	accessToken := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(body, &accessToken); err != nil || len(accessToken.AccessToken) == 0 {
+		return nil, errors.New(fmt.Sprintf("cannot parse the storage token of client %s", secret.Data[AzureClientID]))
+	}
+
+	// the user delegation key is valid as long as the SAS signed with it
+	start := r.now().UTC().Add(-5 * time.Minute).Truncate(time.Second)
+	expiry := r.now().UTC().Add(duration).Truncate(time.Second)
+	keyInfo, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"KeyInfo"`
+		Start   string   `xml:"Start"`
+		Expiry  string   `xml:"Expiry"`
+	}{Start: start.Format(time.RFC3339), Expiry: expiry.Format(time.RFC3339)})
+	if err != nil {
+		return nil, err
+	}
+
+	blobEndpoint := fmt.Sprintf(r.azureBlobEndpoint, account)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/?restype=service&comp=userdelegationkey", blobEndpoint),
+		bytes.NewReader(append([]byte(xml.Header), keyInfo...)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken.AccessToken))
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("Content-Type", "application/xml")
+
+	body, err = r.do(req)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot get a user delegation key of storage account %s: %v", account, err))
+	}
+	key := userDelegationKey{}
+	if err := xml.Unmarshal(body, &key); err != nil || len(key.Value) == 0 {
+		return nil, errors.New(fmt.Sprintf("cannot parse the user delegation key of storage account %s", account))
+	}
+
+	sas, err := signUserDelegationSAS(key, account, container, start, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResolvedCredentials{
+		Data:       map[string][]byte{AzureAccountSAS: []byte(sas)},
+		Expiration: expiry,
+	}, nil
+}
+
+// userDelegationKey is the key a user delegation SAS is signed with
+type userDelegationKey struct {
+	SignedOid     string `xml:"SignedOid"`
+	SignedTid     string `xml:"SignedTid"`
+	SignedStart   string `xml:"SignedStart"`
+	SignedExpiry  string `xml:"SignedExpiry"`
+	SignedService string `xml:"SignedService"`
+	SignedVersion string `xml:"SignedVersion"`
+	Value         string `xml:"Value"`
+}
+
+// signUserDelegationSAS returns a user delegation SAS granting restic access to the blobs of a container
+func signUserDelegationSAS(key userDelegationKey, account, container string, start, expiry time.Time) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(key.Value)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid user delegation key of storage account %s", account))
+	}
+
+	permissions := "racwdl"
+	st, se := start.UTC().Format(time.RFC3339), expiry.UTC().Format(time.RFC3339)
+	stringToSign := strings.Join([]string{
+		permissions,
+		st,
+		se,
+		fmt.Sprintf("/blob/%s/%s", account, container),
+		key.SignedOid,
+		key.SignedTid,
+		key.SignedStart,
+		key.SignedExpiry,
+		key.SignedService,
+		key.SignedVersion,
+		"", // signedAuthorizedUserObjectId
+		"", // signedUnauthorizedUserObjectId
+		"", // signedCorrelationId
+		"", // signedIP
+		"https",
+		azureStorageVersion,
+		"c",
+		"", // signedSnapshotTime
+		"", // signedEncryptionScope
+		"", // rscc
+		"", // rscd
+		"", // rsce
+		"", // rscl
+		"", // rsct
+	}, "\n")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+
+	query := url.Values{
+		"sp":    {permissions},
+		"st":    {st},
+		"se":    {se},
+		"skoid": {key.SignedOid},
+		"sktid": {key.SignedTid},
+		"skt":   {key.SignedStart},
+		"ske":   {key.SignedExpiry},
+		"sks":   {key.SignedService},
+		"skv":   {key.SignedVersion},
+		"spr":   {"https"},
+		"sv":    {azureStorageVersion},
+		"sr":    {"c"},
+		"sig":   {base64.StdEncoding.EncodeToString(mac.Sum(nil))},
+	}
+	return query.Encode(), nil
+}
+
+// resolveGCP embeds a token of the service account in the external account configuration of the workload identity
+// pool, so the mover exchanges it for the credentials of the pool without mounting a projected token
+func (r *workloadIdentityResolver) resolveGCP(ctx context.Context, secret *corev1.Secret, duration time.Duration) (*ResolvedCredentials, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(secret.Data[GoogleApplicationCredentials], &config); err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot parse the external account configuration of %s: %v", GoogleApplicationCredentials, err))
+	}
+	if len(r.gcpAudience) == 0 {
+		return nil, errors.New(fmt.Sprintf("GCP workload identity requires the audience of the pool in %s", gcpWorkloadIdentityAudienceEnv))
+	}
+	if audience, _ := config["audience"].(string); audience != r.gcpAudience {
+		return nil, errors.New(fmt.Sprintf("the external account configuration of %s has audience %q, %s is %q",
+			GoogleApplicationCredentials, audience, gcpWorkloadIdentityAudienceEnv, r.gcpAudience))
+	}
+	// the token is only sent to the Google STS and IAM credentials endpoints
+	tokenURL, _ := config["token_url"].(string)
+	if !isGoogleURL(tokenURL, googleSTSHost) {
+		return nil, errors.New(fmt.Sprintf("the external account configuration of %s has token_url %q, https://%s is expected",
+			GoogleApplicationCredentials, tokenURL, googleSTSHost))
+	}
+	if impersonationURL, ok := config["service_account_impersonation_url"].(string); ok && !isGoogleURL(impersonationURL, googleImpersonationHost) {
+		return nil, errors.New(fmt.Sprintf("the external account configuration of %s has service_account_impersonation_url %q, https://%s is expected",
+			GoogleApplicationCredentials, impersonationURL, googleImpersonationHost))
+	}
+
+	expiration := r.now().Add(duration)
+	token, err := r.serviceAccountToken(ctx, secret, r.gcpAudience, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	config[googleSubjectTokenField] = token
+	config["credential_source"] = map[string]interface{}{
+		"file": moverGoogleCredentialsFile,
+		"format": map[string]interface{}{
+			"type":                     "json",
+			"subject_token_field_name": googleSubjectTokenField,
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResolvedCredentials{
+		Data:       map[string][]byte{GoogleApplicationCredentials: data},
+		Expiration: expiration,
+	}, nil
+}
+
+// isGoogleURL returns whether the url is an https url of the given Google host
+func isGoogleURL(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme == "https" && u.Host == host && len(u.User.String()) == 0
+}
@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testGCPAudience is the audience of the GCP workload identity pool of the test resolver
+const testGCPAudience = "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/oadp"
+
+// newTestCredentialResolver returns a resolver issuing "<serviceaccount>:<audience>" service account tokens
+// of the velero and datamover service accounts and sending its requests to the given server
+func newTestCredentialResolver(server *httptest.Server, now time.Time) *workloadIdentityResolver {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateActionImpl)
+		tokenRequest := create.GetObject().(*authenticationv1.TokenRequest)
+		tokenRequest.Status.Token = fmt.Sprintf("%s:%s", create.Name, tokenRequest.Spec.Audiences[0])
+		return true, tokenRequest, nil
+	})
+
+	return &workloadIdentityResolver{
+		clientset:          clientset,
+		httpClient:         server.Client(),
+		now:                func() time.Time { return now },
+		serviceAccounts:    []string{"velero", "datamover"},
+		gcpAudience:        testGCPAudience,
+		awsSTSEndpoint:     server.URL,
+		azureAuthorityHost: server.URL,
+		azureBlobEndpoint:  server.URL + "/%s",
+	}
+}
+
+func TestUsesWorkloadIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		data     map[string]string
+		want     bool
+	}{
+		{
+			name:     "Given AWS role -> workload identity",
+			provider: AWSProvider,
+			data:     map[string]string{AWSRoleARN: "arn:aws:iam::123456789012:role/velero"},
+			want:     true,
+		},
+		{
+			name:     "Given AWS role and access keys -> static credentials",
+			provider: AWSProvider,
+			data:     map[string]string{AWSRoleARN: "arn:aws:iam::123456789012:role/velero", AWSAccessKey: "key"},
+		},
+		{
+			name:     "Given Azure client and tenant -> workload identity",
+			provider: AzureProvider,
+			data:     map[string]string{AzureClientID: "client", AzureTenantID: "tenant"},
+			want:     true,
+		},
+		{
+			name:     "Given GCP external account -> workload identity",
+			provider: GCPProvider,
+			data:     map[string]string{GoogleApplicationCredentials: `{"type":"external_account"}`},
+			want:     true,
+		},
+		{
+			name:     "Given GCP service account key -> static credentials",
+			provider: GCPProvider,
+			data:     map[string]string{GoogleApplicationCredentials: `{"type":"service_account"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usesWorkloadIdentity(newProviderSecret(tt.provider, tt.data)); got != tt.want {
+				t.Errorf("usesWorkloadIdentity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadIdentityResolver_Resolve(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	delegationKey := base64.StdEncoding.EncodeToString([]byte("delegation-key"))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = req.ParseForm()
+		switch {
+		case req.Form.Get("Action") == "AssumeRoleWithWebIdentity":
+			if req.Form.Get("WebIdentityToken") != "velero:sts.amazonaws.com" || req.Form.Get("DurationSeconds") != "3600" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>2023-03-01T13:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+		case strings.HasSuffix(req.URL.Path, "/oauth2/v2.0/token"):
+			if req.URL.Path != "/tenant/oauth2/v2.0/token" || req.Form.Get("client_assertion") != "datamover:api://AzureADTokenExchange" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"storage-token","expires_in":3599}`)
+		case req.URL.Query().Get("comp") == "userdelegationkey":
+			if req.URL.Path != "/account/" || req.Header.Get("Authorization") != "Bearer storage-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `<UserDelegationKey><SignedOid>oid</SignedOid><SignedTid>tid</SignedTid>
+<SignedStart>2023-03-01T11:55:00Z</SignedStart><SignedExpiry>2023-03-01T13:00:00Z</SignedExpiry>
+<SignedService>b</SignedService><SignedVersion>2020-12-06</SignedVersion><Value>%s</Value></UserDelegationKey>`, delegationKey)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		secret     *corev1.Secret
+		resticRepo string
+		validate   func(*ResolvedCredentials) error
+		wantErr    bool
+	}{
+		{
+			name:   "Given AWS role -> session credentials",
+			secret: newProviderSecret(AWSProvider, map[string]string{AWSRoleARN: "arn:aws:iam::123456789012:role/velero"}),
+			validate: func(creds *ResolvedCredentials) error {
+				if string(creds.Data[AWSAccessKey]) != "ASIA" || string(creds.Data[AWSSessionToken]) != "session" {
+					return fmt.Errorf("unexpected credentials %v", creds.Data)
+				}
+				if !creds.Expiration.Equal(now.Add(time.Hour)) {
+					return fmt.Errorf("unexpected expiration %v", creds.Expiration)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Given Azure workload identity -> container SAS",
+			secret: newProviderSecret(AzureProvider, map[string]string{
+				AzureAccountName:          "account",
+				AzureClientID:             "client",
+				AzureTenantID:             "tenant",
+				CredentialsServiceAccount: "datamover",
+			}),
+			resticRepo: "azure:velero:/restic/foo/backup/pvc",
+			validate: func(creds *ResolvedCredentials) error {
+				sas, err := url.ParseQuery(string(creds.Data[AzureAccountSAS]))
+				if err != nil {
+					return err
+				}
+				if sas.Get("sr") != "c" || sas.Get("skoid") != "oid" || sas.Get("se") != "2023-03-01T13:00:00Z" || len(sas.Get("sig")) == 0 {
+					return fmt.Errorf("unexpected SAS %v", sas)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Given Azure workload identity with s3 repository -> error",
+			secret: newProviderSecret(AzureProvider, map[string]string{
+				AzureAccountName: "account",
+				AzureClientID:    "client",
+				AzureTenantID:    "tenant",
+			}),
+			resticRepo: "s3:s3.amazonaws.com/velero",
+			wantErr:    true,
+		},
+		{
+			name: "Given GCP external account -> configuration with embedded token",
+			secret: newProviderSecret(GCPProvider, map[string]string{GoogleApplicationCredentials: newGCPExternalAccount(testGCPAudience,
+				"https://sts.googleapis.com/v1/token", "")}),
+			validate: func(creds *ResolvedCredentials) error {
+				config := struct {
+					SubjectToken     string `json:"subject_token"`
+					CredentialSource struct {
+						File string `json:"file"`
+					} `json:"credential_source"`
+				}{}
+				if err := json.Unmarshal(creds.Data[GoogleApplicationCredentials], &config); err != nil {
+					return err
+				}
+				if !strings.HasPrefix(config.SubjectToken, "velero://iam.googleapis.com/") || config.CredentialSource.File != moverGoogleCredentialsFile {
+					return fmt.Errorf("unexpected configuration %s", creds.Data[GoogleApplicationCredentials])
+				}
+				return nil
+			},
+		},
+		{
+			name: "Given service account not allowed -> error",
+			secret: newProviderSecret(AWSProvider, map[string]string{
+				AWSRoleARN:                "arn:aws:iam::123456789012:role/velero",
+				CredentialsServiceAccount: "builder",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "Given GCP external account of another audience -> error",
+			secret: newProviderSecret(GCPProvider, map[string]string{GoogleApplicationCredentials: newGCPExternalAccount(
+				"//iam.googleapis.com/projects/2/locations/global/workloadIdentityPools/other/providers/oadp", "https://sts.googleapis.com/v1/token", "")}),
+			wantErr: true,
+		},
+		{
+			name: "Given GCP external account with another token url -> error",
+			secret: newProviderSecret(GCPProvider, map[string]string{GoogleApplicationCredentials: newGCPExternalAccount(testGCPAudience,
+				"https://attacker.example.com/v1/token", "")}),
+			wantErr: true,
+		},
+		{
+			name: "Given GCP external account with another impersonation url -> error",
+			secret: newProviderSecret(GCPProvider, map[string]string{GoogleApplicationCredentials: newGCPExternalAccount(testGCPAudience,
+				"https://sts.googleapis.com/v1/token", "https://attacker.example.com/v1/projects/-/serviceAccounts/velero:generateAccessToken")}),
+			wantErr: true,
+		},
+		{
+			name: "Given GCP external account with Google impersonation url -> configuration with embedded token",
+			secret: newProviderSecret(GCPProvider, map[string]string{GoogleApplicationCredentials: newGCPExternalAccount(testGCPAudience,
+				"https://sts.googleapis.com/v1/token", "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/velero@p.iam.gserviceaccount.com:generateAccessToken")}),
+		},
+		{
+			name: "Given too short credentials duration -> error",
+			secret: newProviderSecret(AWSProvider, map[string]string{
+				AWSRoleARN:          "arn:aws:iam::123456789012:role/velero",
+				CredentialsDuration: "5m",
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newTestCredentialResolver(server, now)
+			creds, err := resolver.Resolve(newContextForTest(tt.name), tt.secret, tt.resticRepo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.validate != nil {
+				if err := tt.validate(creds); err != nil {
+					t.Errorf("Resolve() %v", err)
+				}
+			}
+		})
+	}
+}
+
+// newGCPExternalAccount returns the external account configuration of a workload identity pool
+func newGCPExternalAccount(audience, tokenURL, impersonationURL string) string {
+	config := map[string]interface{}{
+		"type":              "external_account",
+		"audience":          audience,
+		"token_url":         tokenURL,
+		"credential_source": map[string]string{"file": "/var/run/secrets/openshift/serviceaccount/token"},
+	}
+	if len(impersonationURL) > 0 {
+		config["service_account_impersonation_url"] = impersonationURL
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+func TestGetCredentialsServiceAccounts(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want []string
+	}{
+		{
+			name: "Given no service accounts -> velero",
+			want: []string{"velero"},
+		},
+		{
+			name: "Given service accounts -> service accounts",
+			env:  "velero, datamover,",
+			want: []string{"velero", "datamover"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(credentialsServiceAccountsEnv, tt.env)
+			got := getCredentialsServiceAccounts()
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("getCredentialsServiceAccounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// staticCredentialResolver returns the same credentials on every call, counting them
+type staticCredentialResolver struct {
+	creds *ResolvedCredentials
+	calls int
+}
+
+func (r *staticCredentialResolver) Resolve(_ context.Context, _ *corev1.Secret, _ string) (*ResolvedCredentials, error) {
+	r.calls++
+	return r.creds, nil
+}
+
+func TestResolveResticSecret(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	roleSecret := newProviderSecret(AWSProvider, map[string]string{AWSRoleARN: "arn:aws:iam::123456789012:role/velero"})
+	fresh := map[string]string{CredentialsExpirationAnnotation: "2023-03-01T12:30:00Z"}
+	expiring := map[string]string{CredentialsExpirationAnnotation: "2023-03-01T12:05:00Z"}
+
+	tests := []struct {
+		name        string
+		givensecret *corev1.Secret
+		annotations map[string]string
+		wantToken   string
+		wantCalls   int
+	}{
+		{
+			name:        "Given static credentials -> secret unchanged",
+			givensecret: newMinIOSecret(nil),
+		},
+		{
+			name:        "Given new mover secret -> credentials resolved",
+			givensecret: roleSecret,
+			wantToken:   "new",
+			wantCalls:   1,
+		},
+		{
+			name:        "Given fresh mover secret -> credentials kept",
+			givensecret: roleSecret,
+			annotations: fresh,
+			wantToken:   "old",
+		},
+		{
+			name:        "Given expiring mover secret -> credentials refreshed",
+			givensecret: roleSecret,
+			annotations: expiring,
+			wantToken:   "new",
+			wantCalls:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := &staticCredentialResolver{creds: &ResolvedCredentials{
+				Data:       map[string][]byte{AWSSessionToken: []byte("new")},
+				Expiration: now.Add(time.Hour),
+			}}
+			moverSecret := &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Annotations: tt.annotations},
+				Data:       map[string][]byte{AWSSessionToken: []byte("old")},
+			}
+
+			resolved, err := resolveResticSecret(newContextForTest(tt.name), resolver, tt.givensecret, moverSecret, "s3:s3.amazonaws.com/velero", now)
+			if err != nil {
+				t.Fatalf("resolveResticSecret() error = %v", err)
+			}
+			if got := string(resolved.Data[AWSSessionToken]); got != tt.wantToken {
+				t.Errorf("resolveResticSecret() %s = %v, want %v", AWSSessionToken, got, tt.wantToken)
+			}
+			if resolver.calls != tt.wantCalls {
+				t.Errorf("resolveResticSecret() resolved %d times, want %d", resolver.calls, tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && moverSecret.Annotations[CredentialsExpirationAnnotation] != "2023-03-01T13:00:00Z" {
+				t.Errorf("resolveResticSecret() expiration = %v", moverSecret.Annotations[CredentialsExpirationAnnotation])
+			}
+			if _, ok := resolved.Data[AWSRoleARN]; tt.givensecret == roleSecret && !ok {
+				t.Errorf("resolveResticSecret() changed the restic secret keys")
+			}
+		})
+	}
+}
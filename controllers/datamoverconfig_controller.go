@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DataMoverConfigReconciler applies the concurrency limits of the DataMoverConfig
+// to the backup and restore schedulers
+type DataMoverConfigReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Log              logr.Logger
+	BackupScheduler  *BatchScheduler
+	RestoreScheduler *BatchScheduler
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=datamoverconfigs,verbs=get;list;watch
+
+// Reconcile sets the scheduler limits whenever the DataMoverConfig changes. Queued
+// volumesnapshotbackups and volumesnapshotrestores pick up the new limits the next
+// time they are requeued.
+func (r *DataMoverConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("datamoverconfig", req.NamespacedName)
+
+	if req.Name != volsnapmoverv1alpha1.DataMoverConfigName {
+		r.Log.Info(fmt.Sprintf("ignoring datamoverconfig %s, only %s is used", req.Name, volsnapmoverv1alpha1.DataMoverConfigName))
+		return ctrl.Result{}, nil
+	}
+
+	config := volsnapmoverv1alpha1.DataMoverConfig{}
+	if err := r.Get(ctx, req.NamespacedName, &config); err != nil {
+		// fall back to the deployment env when the config is removed
+		if k8serrors.IsNotFound(err) {
+			r.BackupScheduler.SetConfiguredLimit(0)
+			r.RestoreScheduler.SetConfiguredLimit(0)
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch DataMoverConfig CR")
+		return ctrl.Result{}, err
+	}
+
+	backupLimit := getConfiguredLimit(config.Spec.ConcurrentBackups)
+	restoreLimit := getConfiguredLimit(config.Spec.ConcurrentRestores)
+	r.BackupScheduler.SetConfiguredLimit(backupLimit)
+	r.RestoreScheduler.SetConfiguredLimit(restoreLimit)
+	r.Log.Info(fmt.Sprintf("set concurrent backups to %v and concurrent restores to %v", backupLimit, restoreLimit))
+
+	return ctrl.Result{}, nil
+}
+
+// getConfiguredLimit returns 0, meaning no override, for unset or invalid limits
+func getConfiguredLimit(limit *int32) int {
+	if limit == nil || *limit < 1 {
+		return 0
+	}
+
+	return int(*limit)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DataMoverConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.DataMoverConfig{}).
+		Complete(r)
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDataMoverConfigReconciler_Reconcile(t *testing.T) {
+	tests := []struct {
+		name             string
+		configName       string
+		config           *volsnapmoverv1alpha1.DataMoverConfig
+		wantBackupLimit  int
+		wantRestoreLimit int
+	}{
+		{
+			name:       "Given datamoverconfig with both limits -> limits overridden",
+			configName: volsnapmoverv1alpha1.DataMoverConfigName,
+			config: &volsnapmoverv1alpha1.DataMoverConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name: volsnapmoverv1alpha1.DataMoverConfigName,
+				},
+				Spec: volsnapmoverv1alpha1.DataMoverConfigSpec{
+					ConcurrentBackups:  pointer.Int32(5),
+					ConcurrentRestores: pointer.Int32(7),
+				},
+			},
+			wantBackupLimit:  5,
+			wantRestoreLimit: 7,
+		},
+		{
+			name:       "Given datamoverconfig with backup limit only -> restore limit from env",
+			configName: volsnapmoverv1alpha1.DataMoverConfigName,
+			config: &volsnapmoverv1alpha1.DataMoverConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name: volsnapmoverv1alpha1.DataMoverConfigName,
+				},
+				Spec: volsnapmoverv1alpha1.DataMoverConfigSpec{
+					ConcurrentBackups: pointer.Int32(5),
+				},
+			},
+			wantBackupLimit:  5,
+			wantRestoreLimit: 2,
+		},
+		{
+			name:             "Given deleted datamoverconfig -> limits from env",
+			configName:       volsnapmoverv1alpha1.DataMoverConfigName,
+			wantBackupLimit:  2,
+			wantRestoreLimit: 2,
+		},
+		{
+			name:       "Given datamoverconfig with another name -> ignored",
+			configName: "other",
+			config: &volsnapmoverv1alpha1.DataMoverConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "other",
+				},
+				Spec: volsnapmoverv1alpha1.DataMoverConfigSpec{
+					ConcurrentBackups: pointer.Int32(5),
+				},
+			},
+			wantBackupLimit:  3,
+			wantRestoreLimit: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []client.Object{}
+			if tt.config != nil {
+				objs = append(objs, tt.config)
+			}
+			fakeClient, err := getFakeClientFromObjects(objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+
+			// schedulers start with the env limit of 2 and a previous override of 3
+			backupScheduler := NewBatchScheduler()
+			backupScheduler.SetLimit(2)
+			backupScheduler.SetConfiguredLimit(3)
+			restoreScheduler := NewBatchScheduler()
+			restoreScheduler.SetLimit(2)
+			restoreScheduler.SetConfiguredLimit(3)
+
+			r := &DataMoverConfigReconciler{
+				Client:           fakeClient,
+				Scheme:           fakeClient.Scheme(),
+				Log:              logr.Discard(),
+				BackupScheduler:  backupScheduler,
+				RestoreScheduler: restoreScheduler,
+			}
+			_, err = r.Reconcile(newContextForTest(tt.name), reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: tt.configName},
+			})
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if got := backupScheduler.Limit(); got != tt.wantBackupLimit {
+				t.Errorf("backup limit = %v, want %v", got, tt.wantBackupLimit)
+			}
+			if got := restoreScheduler.Limit(); got != tt.wantRestoreLimit {
+				t.Errorf("restore limit = %v, want %v", got, tt.wantRestoreLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// suffix of the "<storageclass>-config" ConfigMaps
+const dataMoverConfigMapSuffix = "-config"
+
+// DataMoverConfigMapReconciler migrates the "<storageclass>-config" ConfigMaps to
+// DataMoverStorageClassConfigs
+type DataMoverConfigMapReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Reconcile migrates a ConfigMap when it is created or changes, unless a DataMoverStorageClassConfig
+// was created for the storage class. The migrated configuration is garbage collected with the ConfigMap.
+func (r *DataMoverConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("configmap", req.NamespacedName)
+
+	sc := strings.TrimSuffix(req.Name, dataMoverConfigMapSuffix)
+	cm, err := GetDataMoverConfigMap(req.Namespace, sc, r.Log, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cm == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// only the ConfigMaps of a storage class are data mover configuration
+	storageClass := storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: sc}, &storageClass); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	config := volsnapmoverv1alpha1.DataMoverStorageClassConfig{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: sc}, &config)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		if len(config.Annotations[volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation]) == 0 {
+			r.Log.Info(fmt.Sprintf("datamoverstorageclassconfig %s/%s takes precedence, not migrating configmap", req.Namespace, sc))
+			return ctrl.Result{}, nil
+		}
+		if config.Annotations[volsnapmoverv1alpha1.MigratedResourceVersionAnnotation] == cm.ResourceVersion {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if _, err := migrateDataMoverConfigMap(cm, sc, r.Log, r.Client); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to migrate configmap %s", req.NamespacedName))
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DataMoverConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("datamoverconfigmap").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return strings.HasSuffix(object.GetName(), dataMoverConfigMapSuffix)
+		}))).
+		Owns(&volsnapmoverv1alpha1.DataMoverStorageClassConfig{}).
+		Complete(r)
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDataMoverConfigMapReconciler_Reconcile(t *testing.T) {
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:  v1.ObjectMeta{Name: "gp2"},
+		Provisioner: "ebs.csi.aws.com",
+	}
+	newConfigMap := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, UID: "cm-uid"},
+			Data:       data,
+		}
+	}
+	newMigratedConfig := func(resourceVersion string) *volsnapmoverv1alpha1.DataMoverStorageClassConfig {
+		return &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "gp2",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation:   "gp2-config",
+					volsnapmoverv1alpha1.MigratedResourceVersionAnnotation: resourceVersion,
+				},
+			},
+			Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		configMapName string
+		objs          []client.Object
+		wantConfig    bool
+		wantSpec      volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec
+		wantOwner     bool
+		wantErr       bool
+	}{
+		{
+			name:          "Given configmap -> migrated",
+			configMapName: "gp2-config",
+			objs: []client.Object{
+				storageClass,
+				newConfigMap("gp2-config", map[string]string{
+					SourceStorageClassName: "gp3",
+					DestinationAccessMoce:  string(corev1.ReadWriteMany),
+				}),
+			},
+			wantConfig: true,
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source:      volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "gp3"},
+				Destination: volsnapmoverv1alpha1.DataMoverVolumeOptions{AccessMode: corev1.ReadWriteMany},
+			},
+			wantOwner: true,
+		},
+		{
+			name:          "Given configmap without storage class -> ignored",
+			configMapName: "app-config",
+			objs: []client.Object{
+				newConfigMap("app-config", map[string]string{SourceStorageClassName: "gp3"}),
+			},
+		},
+		{
+			name:          "Given deleted configmap -> ignored",
+			configMapName: "gp2-config",
+			objs:          []client.Object{storageClass},
+		},
+		{
+			name:          "Given config migrated from an older configmap -> migrated again",
+			configMapName: "gp2-config",
+			objs: []client.Object{
+				storageClass,
+				newConfigMap("gp2-config", map[string]string{SourceStorageClassName: "gp3"}),
+				newMigratedConfig("1"),
+			},
+			wantConfig: true,
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "gp3"},
+			},
+			wantOwner: true,
+		},
+		{
+			name:          "Given config migrated from the current configmap -> unchanged",
+			configMapName: "gp2-config",
+			objs: []client.Object{
+				storageClass,
+				newConfigMap("gp2-config", map[string]string{SourceStorageClassName: "gp3"}),
+				newMigratedConfig("999"),
+			},
+			wantConfig: true,
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+			},
+		},
+		{
+			name:          "Given config created for the storage class -> configmap ignored",
+			configMapName: "gp2-config",
+			objs: []client.Object{
+				storageClass,
+				newConfigMap("gp2-config", map[string]string{SourceStorageClassName: "gp3"}),
+				&volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+					ObjectMeta: v1.ObjectMeta{Name: "gp2", Namespace: namespace},
+					Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+						Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+					},
+				},
+			},
+			wantConfig: true,
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+			},
+		},
+		{
+			name:          "Given invalid configmap -> error",
+			configMapName: "gp2-config",
+			objs: []client.Object{
+				storageClass,
+				newConfigMap("gp2-config", map[string]string{SourceCacheCapacity: "2 Gi"}),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+
+			r := &DataMoverConfigMapReconciler{
+				Client: fakeClient,
+				Scheme: fakeClient.Scheme(),
+				Log:    logr.Discard(),
+			}
+			_, err = r.Reconcile(newContextForTest(tt.name), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: tt.configMapName},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			config := volsnapmoverv1alpha1.DataMoverStorageClassConfig{}
+			err = fakeClient.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "gp2"}, &config)
+			if !tt.wantConfig {
+				if !k8serrors.IsNotFound(err) {
+					t.Errorf("datamoverstorageclassconfig was created, error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get datamoverstorageclassconfig: %v", err)
+			}
+			if !reflect.DeepEqual(config.Spec, tt.wantSpec) {
+				t.Errorf("spec = %v, want %v", config.Spec, tt.wantSpec)
+			}
+			if tt.wantOwner {
+				owner := v1.GetControllerOf(&config)
+				if owner == nil || owner.UID != "cm-uid" {
+					t.Errorf("controller = %v, want the configmap", owner)
+				}
+				if config.Annotations[volsnapmoverv1alpha1.MigratedResourceVersionAnnotation] != "999" {
+					t.Errorf("migrated resource version = %v, want 999", config.Annotations[volsnapmoverv1alpha1.MigratedResourceVersionAnnotation])
+				}
+			}
+		})
+	}
+}
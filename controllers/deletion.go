@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/konveyor/volume-snapshot-mover/pkg/manifest"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ConditionDataDeleted = "DataDeleted"
+const DataDeletedReasonError = "Error"
+const DataDeletedReasonComplete = "Complete"
+
+// DataDeletionLabel marks the job and secret deleting the data of a vsb, which are not removed with the vsb resources
+const DataDeletionLabel = "datamover.oadp.openshift.io/datadeletion"
+
+// a failed data deletion job is kept this long before running again
+const dataDeletionRetryInterval = time.Minute
+
+// dataDeletionScript forgets and prunes the snapshot $0 of $RESTIC_REPOSITORY, every snapshot listed by restic
+// when $0 is empty, then removes the manifest $1 when set. A repository already removed has nothing to forget
+const dataDeletionScript = `ids="$0"
+if [ -z "$ids" ] && ! ids=$(restic $RESTIC_FLAGS list snapshots 2>&1); then
+  case "$ids" in
+    *"Is there a repository at the following location"*) ids="" ;;
+    *) echo "$ids" | tail -n 1; exit 1 ;;
+  esac
+fi
+if [ -n "$ids" ]; then
+  if out=$(restic $RESTIC_FLAGS forget --prune $ids 2>&1); then
+    echo "forgot" $ids
+  else
+    case "$out" in
+      *"Is there a repository at the following location"*) echo "no repository" ;;
+      *) echo "$out" | tail -n 1; exit 1 ;;
+    esac
+  fi
+fi
+if [ -n "$1" ]; then
+  rclone deletefile "$1" 2>/dev/null || echo "manifest $1 not removed"
+fi`
+
+// dataDeletionName returns the name of the job deleting the data of a vsb, and of its secret
+func dataDeletionName(vsbName string) string {
+	return fmt.Sprintf("%s-data-deletion", vsbName)
+}
+
+// isDataDeleted returns whether the moved data of a deleted vsb has to be deleted from the BSL
+func isDataDeleted(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) bool {
+	return vsb.Spec.DataDeletionPolicy == volsnapmoverv1alpha1.DataDeletionPolicyDelete &&
+		(len(vsb.Spec.Mover) == 0 || vsb.Spec.Mover == volsnapmoverv1alpha1.ResticDataMover) &&
+		len(vsb.Status.ResticRepository) > 0
+}
+
+// DeleteBackupData forgets and prunes the restic snapshot of a deleted vsb with the Delete data deletion policy,
+// returning true once the finalizer can be removed. A failure is reported by the DataDeleted condition and retried,
+// setting the Retain policy gives up
+func (r *VolumeSnapshotBackupReconciler) DeleteBackupData(log logr.Logger) (bool, error) {
+	// get volumesnapshotbackup from cluster
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := r.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+		// ignore is not found error
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotbackup %s", r.req.NamespacedName))
+		return false, err
+	}
+
+	if !isDataDeleted(&vsb) {
+		return true, r.removeDataDeletionResources(&vsb)
+	}
+
+	job := batchv1.Job{}
+	err := r.Get(r.Context, types.NamespacedName{Namespace: vsb.Spec.ProtectedNamespace, Name: dataDeletionName(vsb.Name)}, &job)
+	if k8serrors.IsNotFound(err) {
+		job, err := r.createDataDeletionJob(&vsb)
+		if err != nil {
+			return false, err
+		}
+		if job != nil {
+			r.Log.Info(fmt.Sprintf("deleting the snapshot of volumesnapshotbackup %s from repository %s", r.req.NamespacedName, vsb.Status.ResticRepository))
+		}
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if job.Status.Succeeded > 0 {
+		if err := r.removeDataDeletionResources(&vsb); err != nil {
+			return false, err
+		}
+		r.EventRecorder.Event(&vsb, corev1.EventTypeNormal, "BackupDataDeleted",
+			fmt.Sprintf("deleted the snapshot of repository %s", vsb.Status.ResticRepository))
+		return true, r.setDataDeletedCondition(&vsb, metav1.ConditionTrue, DataDeletedReasonComplete,
+			fmt.Sprintf("deleted the snapshot of repository %s", vsb.Status.ResticRepository))
+	}
+
+	if isJobFailed(&job) {
+		if err := r.setDataDeletedCondition(&vsb, metav1.ConditionFalse, DataDeletedReasonError,
+			fmt.Sprintf("data deletion job %s failed, set the Retain data deletion policy to keep the data", job.Name)); err != nil {
+			return false, err
+		}
+
+		// run the job again once the retry interval has passed
+		if time.Since(job.CreationTimestamp.Time) >= dataDeletionRetryInterval {
+			r.EventRecorder.Event(&vsb, corev1.EventTypeWarning, "BackupDataDeletionFailed",
+				fmt.Sprintf("data deletion job %s failed, retrying", job.Name))
+			propagation := metav1.DeletePropagationBackground
+			if err := r.Delete(r.Context, &job, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !k8serrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	r.Log.Info(fmt.Sprintf("waiting for data deletion job %s/%s to complete", job.Namespace, job.Name))
+	return false, nil
+}
+
+// createDataDeletionJob creates the secret and the job deleting the snapshot of a vsb, returning a nil job when
+// the restic secret of the BSL cannot be used, as reported by the DataDeleted condition
+func (r *VolumeSnapshotBackupReconciler) createDataDeletionJob(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) (*batchv1.Job, error) {
+	resticSecret := corev1.Secret{}
+	if err := r.Get(r.Context, getResticSecretKey(vsb.Spec.ProtectedNamespace, vsb.Spec.ResticSecretRef), &resticSecret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, r.setDataDeletedCondition(vsb, metav1.ConditionFalse, DataDeletedReasonError, err.Error())
+		}
+		return nil, err
+	}
+	unwrapped, err := r.KeyProviders.UnwrapResticSecret(&resticSecret)
+	if err != nil {
+		return nil, r.setDataDeletedCondition(vsb, metav1.ConditionFalse, DataDeletedReasonError, err.Error())
+	}
+	if err := validateMaintenanceSecret(unwrapped); err != nil {
+		return nil, r.setDataDeletedCondition(vsb, metav1.ConditionFalse, DataDeletedReasonError, err.Error())
+	}
+
+	secret, err := PopulateResticSecret(dataDeletionName(vsb.Name), vsb.Spec.ProtectedNamespace, DataDeletionLabel)
+	if err != nil {
+		return nil, err
+	}
+	if err := BuildResticSecret(unwrapped, secret, vsb.Status.ResticRepository, "", &RetainPolicy{}, ""); err != nil {
+		return nil, r.setDataDeletedCondition(vsb, metav1.ConditionFalse, DataDeletedReasonError, err.Error())
+	}
+	if err := r.Create(r.Context, secret); err != nil {
+		if !k8serrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		existing := corev1.Secret{}
+		if err := r.Get(r.Context, client.ObjectKeyFromObject(secret), &existing); err != nil {
+			return nil, err
+		}
+		existing.Data = secret.Data
+		if err := r.Update(r.Context, &existing); err != nil {
+			return nil, err
+		}
+	}
+
+	job, err := buildDataDeletionJob(vsb, secret)
+	if err != nil {
+		return nil, r.setDataDeletedCondition(vsb, metav1.ConditionFalse, DataDeletedReasonError, err.Error())
+	}
+	if err := r.Create(r.Context, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return job, nil
+}
+
+// buildDataDeletionJob returns the job forgetting and pruning the snapshot of a vsb, the whole repository of the
+// backup layout or the snapshot recorded from its replicationsource in a shared repository, and removing its manifest
+func buildDataDeletionJob(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, secret *corev1.Secret) (*batchv1.Job, error) {
+	if vsb == nil {
+		return nil, errors.New("nil vsb in buildDataDeletionJob")
+	}
+	if secret == nil {
+		return nil, errors.New("nil secret in buildDataDeletionJob")
+	}
+
+	resticRepo := vsb.Status.ResticRepository
+	var snapshotID string
+	manifestPath := manifest.Path(resticRepo)
+	if vsb.Status.RepositoryLayout == volsnapmoverv1alpha1.RepositoryLayoutVolume {
+		snapshotID = getSnapshotID(vsb)
+		if len(snapshotID) == 0 {
+			return nil, errors.New(fmt.Sprintf("snapshot id of vsb %s/%s in shared repository %s is unknown", vsb.Namespace, vsb.Name, resticRepo))
+		}
+		manifestPath = manifest.BackupPath(resticRepo, vsb.Labels[backupLabel])
+	}
+
+	// the manifest is only written to the repositories rclone can reach
+	remote, env, err := getRepositoryRemote(manifestPath, secret.Name)
+	if err != nil {
+		remote, env = "", nil
+	}
+
+	labels := map[string]string{
+		DataDeletionLabel: vsb.Name,
+	}
+	return buildResticJob(dataDeletionName(vsb.Name), vsb.Spec.ProtectedNamespace, "data-deletion", labels,
+		map[string]string{
+			DatamoverResticRepository: resticRepo,
+		},
+		resticRepo, secret, env, []string{"/bin/sh", "-c", dataDeletionScript, snapshotID, remote}), nil
+}
+
+// removeDataDeletionResources removes the job and secret deleting the data of a vsb
+func (r *VolumeSnapshotBackupReconciler) removeDataDeletionResources(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) error {
+	propagation := metav1.DeletePropagationBackground
+	for _, obj := range []client.Object{&batchv1.Job{}, &corev1.Secret{}} {
+		name := dataDeletionName(vsb.Name)
+		if _, ok := obj.(*corev1.Secret); ok {
+			name = fmt.Sprintf("%s-secret", name)
+		}
+		obj.SetName(name)
+		obj.SetNamespace(vsb.Spec.ProtectedNamespace)
+		if err := r.Delete(r.Context, obj, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *VolumeSnapshotBackupReconciler) setDataDeletedCondition(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, status metav1.ConditionStatus, reason, message string) error {
+	apimeta.SetStatusCondition(&vsb.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionDataDeleted,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(r.Context, vsb)
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotBackupReconciler_DeleteBackupData(t *testing.T) {
+	newVSB := func(policy volsnapmoverv1alpha1.DataDeletionPolicy) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsb",
+				Namespace: "bar",
+				Labels: map[string]string{
+					backupLabel: "backup-1",
+				},
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				ProtectedNamespace: namespace,
+				ResticSecretRef:    corev1.LocalObjectReference{Name: "restic-secret"},
+				DataDeletionPolicy: policy,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Phase:            volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted,
+				ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-a-pvc",
+			},
+		}
+	}
+	resticSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "restic-secret",
+			Namespace: namespace,
+			Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
+		},
+		Data: map[string][]byte{
+			AWSAccessKey:     []byte("access"),
+			AWSSecretKey:     []byte("secret"),
+			ResticPassword:   []byte("password"),
+			ResticRepository: []byte("s3:s3.amazonaws.com/bucket/"),
+		},
+	}
+	newJob := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsb-data-deletion",
+				Namespace: namespace,
+			},
+			Status: status,
+		}
+	}
+	deletionSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsb-data-deletion-secret",
+			Namespace: namespace,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		objs          []client.Object
+		want          bool
+		wantJob       bool
+		wantSecret    bool
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name: "Given vsb without data deletion policy -> data retained",
+			objs: []client.Object{newVSB("")},
+			want: true,
+		},
+		{
+			name: "Given rclone vsb with Delete policy -> data retained",
+			objs: []client.Object{func() client.Object {
+				vsb := newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete)
+				vsb.Spec.Mover = volsnapmoverv1alpha1.RcloneDataMover
+				return vsb
+			}()},
+			want: true,
+		},
+		{
+			name:       "Given vsb with Delete policy -> data deletion job created",
+			objs:       []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete), resticSecret.DeepCopy()},
+			want:       false,
+			wantJob:    true,
+			wantSecret: true,
+		},
+		{
+			name:          "Given vsb with Delete policy without restic secret -> condition reports the failure",
+			objs:          []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete)},
+			want:          false,
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name: "Given running data deletion job -> wait",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete), resticSecret.DeepCopy(),
+				newJob(batchv1.JobStatus{Active: 1}), deletionSecret.DeepCopy()},
+			want:       false,
+			wantJob:    true,
+			wantSecret: true,
+		},
+		{
+			name: "Given succeeded data deletion job -> data deleted and job removed",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete), resticSecret.DeepCopy(),
+				newJob(batchv1.JobStatus{Succeeded: 1}), deletionSecret.DeepCopy()},
+			want:          true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "Given failed data deletion job -> condition reports the failure and job retried",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyDelete), resticSecret.DeepCopy(),
+				newJob(batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}}),
+				deletionSecret.DeepCopy()},
+			want:          false,
+			wantSecret:    true,
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			name: "Given failed data deletion job with Retain policy -> data retained and job removed",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.DataDeletionPolicyRetain), resticSecret.DeepCopy(),
+				newJob(batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}}),
+				deletionSecret.DeepCopy()},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotBackupReconciler{
+				Client:  fakeClient,
+				Scheme:  fakeClient.Scheme(),
+				Log:     logr.Discard(),
+				Context: newContextForTest(tt.name),
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace,
+					Name:      "sample-vsb",
+				},
+				EventRecorder: record.NewFakeRecorder(10),
+				req: reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsb"},
+				},
+			}
+			got, err := r.DeleteBackupData(r.Log)
+			if err != nil {
+				t.Fatalf("DeleteBackupData() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DeleteBackupData() got = %v, want %v", got, tt.want)
+			}
+
+			job := batchv1.Job{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: "sample-vsb-data-deletion"}, &job)
+			if tt.wantJob != !k8serrors.IsNotFound(err) {
+				t.Errorf("data deletion job found = %v, want %v", !k8serrors.IsNotFound(err), tt.wantJob)
+			}
+			secret := corev1.Secret{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: "sample-vsb-data-deletion-secret"}, &secret)
+			if tt.wantSecret != !k8serrors.IsNotFound(err) {
+				t.Errorf("data deletion secret found = %v, want %v", !k8serrors.IsNotFound(err), tt.wantSecret)
+			}
+
+			vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+			if err := fakeClient.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+				t.Fatalf("unable to fetch vsb: %v", err)
+			}
+			condition := apimeta.FindStatusCondition(vsb.Status.Conditions, ConditionDataDeleted)
+			if len(tt.wantCondition) == 0 {
+				if condition != nil {
+					t.Errorf("unexpected %s condition %v", ConditionDataDeleted, condition)
+				}
+			} else if condition == nil || condition.Status != tt.wantCondition {
+				t.Errorf("%s condition = %v, want status %v", ConditionDataDeleted, condition, tt.wantCondition)
+			}
+		})
+	}
+}
+
+func TestBuildDataDeletionJob(t *testing.T) {
+	start := metav1.NewTime(time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC))
+	completion := metav1.NewTime(start.Add(5 * time.Minute))
+	newVSB := func(layout volsnapmoverv1alpha1.RepositoryLayout, repo string) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsb",
+				Namespace: "bar",
+				Labels:    map[string]string{backupLabel: "backup-1"},
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				ProtectedNamespace: namespace,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				ResticRepository: repo,
+				RepositoryLayout: layout,
+				ReplicationSourceData: volsnapmoverv1alpha1.ReplicationSourceData{
+					StartTimestamp:      &start,
+					CompletionTimestamp: &completion,
+					SnapshotID:          "4f6bd8a2",
+				},
+			},
+		}
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-vsb-data-deletion-secret", Namespace: namespace},
+	}
+
+	tests := []struct {
+		name    string
+		vsb     *volsnapmoverv1alpha1.VolumeSnapshotBackup
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Given backup layout -> every snapshot forgotten",
+			vsb:  newVSB(volsnapmoverv1alpha1.RepositoryLayoutBackup, "s3:s3.amazonaws.com/bucket/foo/backup-1/data"),
+			want: []string{"", ":s3:bucket/foo/backup-1/data-manifest.json"},
+		},
+		{
+			name: "Given volume layout -> recorded snapshot of the replicationsource forgotten",
+			vsb:  newVSB(volsnapmoverv1alpha1.RepositoryLayoutVolume, "s3:s3.amazonaws.com/bucket/foo/volumes/bar/uid-1"),
+			want: []string{"4f6bd8a2", ":s3:bucket/foo/volumes/bar/uid-1-backup-1-manifest.json"},
+		},
+		{
+			name: "Given rest repository -> no manifest removed",
+			vsb:  newVSB(volsnapmoverv1alpha1.RepositoryLayoutBackup, "rest:http://restic-secret-repository-server.foo.svc:8000/foo/backup-1/data"),
+			want: []string{"", ""},
+		},
+		{
+			name: "Given volume layout without snapshot id -> error",
+			vsb: func() *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+				vsb := newVSB(volsnapmoverv1alpha1.RepositoryLayoutVolume, "s3:s3.amazonaws.com/bucket/foo/volumes/bar/uid-1")
+				vsb.Status.ReplicationSourceData.SnapshotID = ""
+				return vsb
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := buildDataDeletionJob(tt.vsb, secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildDataDeletionJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if job.Name != "sample-vsb-data-deletion" || job.Namespace != namespace || job.Labels[DataDeletionLabel] != "sample-vsb" {
+				t.Errorf("buildDataDeletionJob() job %s/%s labels %v", job.Namespace, job.Name, job.Labels)
+			}
+			if _, ok := job.Labels[VSBLabel]; ok {
+				t.Errorf("buildDataDeletionJob() job would be removed with the vsb resources")
+			}
+			container := job.Spec.Template.Spec.Containers[0]
+			args := container.Command[3:]
+			if len(args) != len(tt.want) {
+				t.Fatalf("buildDataDeletionJob() args = %v, want %v", args, tt.want)
+			}
+			for i := range args {
+				if args[i] != tt.want[i] {
+					t.Errorf("buildDataDeletionJob() args = %v, want %v", args, tt.want)
+				}
+			}
+			if container.EnvFrom[0].SecretRef.Name != secret.Name {
+				t.Errorf("buildDataDeletionJob() envFrom = %v", container.EnvFrom)
+			}
+		})
+	}
+}
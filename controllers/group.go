@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"fmt"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+)
+
+const (
+	// VSBGroupLabel is set on the member volumesnapshotbackups of a volumesnapshotbackupgroup
+	VSBGroupLabel = "datamover.oadp.openshift.io/vsbg"
+	// VSRGroupLabel is set on the member volumesnapshotrestores of a volumesnapshotrestoregroup
+	VSRGroupLabel = "datamover.oadp.openshift.io/vsrg"
+	// GroupTagLabel holds the id shared by the members of a volumesnapshotbackupgroup
+	GroupTagLabel = "datamover.oadp.openshift.io/group-tag"
+)
+
+// groupMemberName returns the name of the member created for the volume at the given index of a group
+func groupMemberName(groupName string, index int) string {
+	return fmt.Sprintf("%s-%d", groupName, index)
+}
+
+// getGroupPhase returns the phase of a group from the number of completed and failed members,
+// a single failed member fails the whole group
+func getGroupPhase(completed, failed, total int) volsnapmoverv1alpha1.VolumeSnapshotGroupPhase {
+	switch {
+	case failed > 0:
+		return volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed
+	case completed == total:
+		return volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted
+	}
+
+	return volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress
+}
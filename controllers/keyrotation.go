@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	KeyRotationLabel = "datamover.oadp.openshift.io/keyrotation"
+	// the new restic password, next to the current one in the key rotation secret
+	ResticNewPassword = "RESTIC_NEW_PASSWORD"
+
+	// steps of a key rotation, each run by a job on every repository of the BSL
+	keyRotationStepAdd    = "add"
+	keyRotationStepRetire = "retire"
+)
+
+// keyRotationName returns the name of the job running a step of a restickeyrotation, or of its secret without step
+func keyRotationName(name, step string) string {
+	if len(step) == 0 {
+		return fmt.Sprintf("%s-key-rotation", name)
+	}
+	return fmt.Sprintf("%s-key-%s", name, step)
+}
+
+// keyRotationCommands are the commands of each step, printing one "<step> ok|failed <repository> [error]" line per
+// repository. Both steps can run again on repositories they already handled
+var keyRotationCommands = map[string]string{
+	// the new key is added with the current password, unless the new password already opens the repository
+	keyRotationStepAdd: `  new=$(cat "$2")
+  if RESTIC_PASSWORD="$new" restic $RESTIC_FLAGS -r "$repository" cat config >/dev/null 2>&1; then
+    echo "add ok $repository"
+  elif out=$(restic $RESTIC_FLAGS -r "$repository" key add --new-password-file "$2" 2>&1); then
+    echo "add ok $repository"
+  else
+    echo "add failed $repository $(echo "$out" | tail -n 1)"
+  fi`,
+	// the key of the old password is removed with the new one, it is already retired when the old password fails.
+	// The key list is decoded with the JSON::PP module of the perl the VolSync image ships for rrsync
+	keyRotationStepRetire: `  new=$(cat "$2")
+  if ! keys=$(restic $RESTIC_FLAGS -r "$repository" key list --json 2>&1); then
+    if RESTIC_PASSWORD="$new" restic $RESTIC_FLAGS -r "$repository" cat config >/dev/null 2>&1; then
+      echo "retire ok $repository"
+    else
+      echo "retire failed $repository $(echo "$keys" | tail -n 1)"
+    fi
+    continue
+  fi
+  id=$(echo "$keys" | perl -MJSON::PP -e 'print map { $_->{id} } grep { $_->{current} } @{decode_json(join "", <STDIN>)}' 2>/dev/null)
+  if [ -z "$id" ]; then
+    echo "retire failed $repository current key not found"
+    continue
+  fi
+  if out=$(RESTIC_PASSWORD="$new" restic $RESTIC_FLAGS -r "$repository" key remove "$id" 2>&1); then
+    echo "retire ok $repository"
+  else
+    echo "retire failed $repository $(echo "$out" | tail -n 1)"
+  fi`,
+}
+
+// buildKeyRotationJob returns the job running a step of a key rotation on every repository of the BSL, with the
+// current and new passwords of the key rotation secret
+func buildKeyRotationJob(rotation *volsnapmoverv1alpha1.ResticKeyRotation, resticRepo string, secret *corev1.Secret,
+	step string) (*batchv1.Job, error) {
+
+	if rotation == nil {
+		return nil, errors.New("nil restickeyrotation in buildKeyRotationJob")
+	}
+	commands, ok := keyRotationCommands[step]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown key rotation step %q", step))
+	}
+
+	return buildRepositoryJob(keyRotationName(rotation.Name, step), rotation.Namespace, "key-rotation",
+		map[string]string{
+			KeyRotationLabel: rotation.Name,
+		},
+		map[string]string{
+			DatamoverResticRepository: resticRepo,
+		},
+		resticRepo, secret, commands, filepath.Join(maintenanceCredentialsDir, ResticNewPassword))
+}
+
+// parseKeyRotationResults returns the number of repositories a step ran on, the repositories it failed on and the
+// error of the last failure
+func parseKeyRotationResults(output string, step string) (int32, []string, string) {
+	var repositories int32
+	failed := []string{}
+	message := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// <step> ok|failed <repository> [error]
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 4)
+		if len(parts) < 3 || parts[0] != step {
+			continue
+		}
+
+		repositories++
+		if parts[1] == "failed" {
+			failed = append(failed, parts[2])
+			if len(parts) == 4 {
+				message = parts[3]
+			}
+		}
+	}
+
+	return repositories, failed, message
+}
+
+// newKeySecretData returns the keys of the new key secret written to the restic secret of the BSL. A plain new
+// password is wrapped when the new key secret names a key provider, so the restic secret never holds it in clear
+func newKeySecretData(keyProviders KeyProviders, newKeySecret *corev1.Secret) (map[string][]byte, error) {
+	if isWrappedResticSecret(newKeySecret) {
+		return map[string][]byte{
+			ResticPasswordWrapped: newKeySecret.Data[ResticPasswordWrapped],
+			ResticKeyProvider:     newKeySecret.Data[ResticKeyProvider],
+			ResticKeyID:           newKeySecret.Data[ResticKeyID],
+		}, nil
+	}
+
+	password := newKeySecret.Data[ResticPassword]
+	if len(password) == 0 {
+		return nil, errors.New(fmt.Sprintf("secret %s has neither %s nor %s", newKeySecret.Name, ResticPassword, ResticPasswordWrapped))
+	}
+	providerName := string(newKeySecret.Data[ResticKeyProvider])
+	if len(providerName) == 0 {
+		return map[string][]byte{ResticPassword: password}, nil
+	}
+
+	provider, ok := keyProviders[providerName]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("key provider %q of secret %s is not configured", providerName, newKeySecret.Name))
+	}
+	wrapped, err := provider.Wrap(string(newKeySecret.Data[ResticKeyID]), password)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot wrap the restic password of secret %s: %v", newKeySecret.Name, err))
+	}
+	return map[string][]byte{
+		ResticPasswordWrapped: []byte(base64.StdEncoding.EncodeToString(wrapped)),
+		ResticKeyProvider:     []byte(providerName),
+		ResticKeyID:           newKeySecret.Data[ResticKeyID],
+	}, nil
+}
+
+// setResticSecretKey replaces the restic password of the restic secret of the BSL, plain or wrapped
+func setResticSecretKey(secret *corev1.Secret, keyData map[string][]byte) {
+	for _, key := range []string{ResticPassword, ResticPasswordWrapped, ResticKeyProvider, ResticKeyID} {
+		delete(secret.Data, key)
+	}
+	for key, val := range keyData {
+		secret.Data[key] = val
+	}
+}
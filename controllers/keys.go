@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// restic password wrapped by the key encryption key RESTIC_KEY_ID of the RESTIC_KEY_PROVIDER key provider
+	ResticPasswordWrapped = "RESTIC_PASSWORD_WRAPPED"
+	ResticKeyProvider     = "RESTIC_KEY_PROVIDER"
+	ResticKeyID           = "RESTIC_KEY_ID"
+
+	// Key providers
+	FileKeyProvider = "file"
+
+	DefaultKeyDir = "/etc/datamover/keys"
+)
+
+// KeyProvider wraps and unwraps restic passwords with a key encryption key
+type KeyProvider interface {
+	Wrap(keyID string, plaintext []byte) ([]byte, error)
+	Unwrap(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KeyProviders are the key providers by name, as set in RESTIC_KEY_PROVIDER
+type KeyProviders map[string]KeyProvider
+
+// isWrappedResticSecret returns whether the restic password of a secret is wrapped by a key provider
+func isWrappedResticSecret(secret *corev1.Secret) bool {
+	return len(secret.Data[ResticPasswordWrapped]) > 0
+}
+
+// UnwrapResticSecret returns the restic secret with its RESTIC_PASSWORD, unwrapped by its key provider
+func (k KeyProviders) UnwrapResticSecret(secret *corev1.Secret) (*corev1.Secret, error) {
+	if !isWrappedResticSecret(secret) {
+		return secret, nil
+	}
+
+	name := string(secret.Data[ResticKeyProvider])
+	provider, ok := k[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("key provider %q of secret %s is not configured", name, secret.Name))
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(secret.Data[ResticPasswordWrapped])))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%s of secret %s is not base64 encoded", ResticPasswordWrapped, secret.Name))
+	}
+	password, err := provider.Unwrap(string(secret.Data[ResticKeyID]), ciphertext)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot unwrap the restic password of secret %s: %v", secret.Name, err))
+	}
+
+	unwrapped := secret.DeepCopy()
+	unwrapped.Data[ResticPassword] = password
+	return unwrapped, nil
+}
+
+// fileKeyProvider wraps the restic passwords with AES-256-GCM key encryption keys read from <dir>/<key id>,
+// usually a mounted secret holding one 32 bytes key, raw or base64 encoded, per key id
+type fileKeyProvider struct {
+	dir string
+}
+
+// NewFileKeyProvider returns a KeyProvider reading the key encryption keys from the given directory
+func NewFileKeyProvider(dir string) KeyProvider {
+	return &fileKeyProvider{dir: dir}
+}
+
+func (p *fileKeyProvider) aead(keyID string) (cipher.AEAD, error) {
+	if len(keyID) == 0 || keyID != filepath.Base(keyID) || strings.HasPrefix(keyID, ".") {
+		return nil, errors.New(fmt.Sprintf("invalid key id %q", keyID))
+	}
+
+	key, err := os.ReadFile(filepath.Join(p.dir, keyID))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot read key %s: %v", keyID, err))
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(key))); err == nil && len(decoded) == 32 {
+		key = decoded
+	}
+	if len(key) != 32 {
+		return nil, errors.New(fmt.Sprintf("key %s is not a 32 bytes AES-256 key", keyID))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Wrap returns the nonce followed by the sealed plaintext, the key id being authenticated along
+func (p *fileKeyProvider) Wrap(keyID string, plaintext []byte) ([]byte, error) {
+	aead, err := p.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(keyID)), nil
+}
+
+// Unwrap opens a ciphertext returned by Wrap
+func (p *fileKeyProvider) Unwrap(keyID string, ciphertext []byte) ([]byte, error) {
+	aead, err := p.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("wrapped password is too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("key %s cannot unwrap the password", keyID))
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestKeyDir returns a key directory holding a raw and a base64 encoded key
+func newTestKeyDir(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "raw"), []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	if err := os.WriteFile(filepath.Join(dir, "encoded"), []byte(encoded+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "short"), []byte("short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	provider := NewFileKeyProvider(newTestKeyDir(t))
+
+	tests := []struct {
+		name        string
+		wrapKeyID   string
+		unwrapKeyID string
+		wantErr     bool
+	}{
+		{
+			name:        "Given raw key -> password round trips",
+			wrapKeyID:   "raw",
+			unwrapKeyID: "raw",
+		},
+		{
+			name:        "Given base64 encoded key -> password round trips",
+			wrapKeyID:   "encoded",
+			unwrapKeyID: "encoded",
+		},
+		{
+			name:        "Given another key -> unwrap fails",
+			wrapKeyID:   "raw",
+			unwrapKeyID: "encoded",
+			wantErr:     true,
+		},
+		{
+			name:      "Given key of the wrong size -> wrap fails",
+			wrapKeyID: "short",
+			wantErr:   true,
+		},
+		{
+			name:      "Given missing key -> wrap fails",
+			wrapKeyID: "missing",
+			wantErr:   true,
+		},
+		{
+			name:      "Given key id outside the key directory -> wrap fails",
+			wrapKeyID: "../raw",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped, err := provider.Wrap(tt.wrapKeyID, []byte("password"))
+			if err == nil {
+				var password []byte
+				password, err = provider.Unwrap(tt.unwrapKeyID, wrapped)
+				if err == nil && string(password) != "password" {
+					t.Errorf("Unwrap() = %s, want password", password)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyProviders_UnwrapResticSecret(t *testing.T) {
+	keyProviders := KeyProviders{FileKeyProvider: NewFileKeyProvider(newTestKeyDir(t))}
+	wrapped, err := keyProviders[FileKeyProvider].Wrap("raw", []byte("password"))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	newSecret := func(data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "restic-secret", Namespace: namespace},
+			Data:       map[string][]byte{ResticRepository: []byte("s3:s3.amazonaws.com/bucket")},
+		}
+		for key, val := range data {
+			secret.Data[key] = []byte(val)
+		}
+		return secret
+	}
+
+	tests := []struct {
+		name         string
+		secret       *corev1.Secret
+		wantPassword string
+		wantErr      bool
+	}{
+		{
+			name:         "Given plain password -> secret unchanged",
+			secret:       newSecret(map[string]string{ResticPassword: "plain"}),
+			wantPassword: "plain",
+		},
+		{
+			name: "Given wrapped password -> password unwrapped",
+			secret: newSecret(map[string]string{
+				ResticPasswordWrapped: base64.StdEncoding.EncodeToString(wrapped),
+				ResticKeyProvider:     FileKeyProvider,
+				ResticKeyID:           "raw",
+			}),
+			wantPassword: "password",
+		},
+		{
+			name: "Given unknown key provider -> error",
+			secret: newSecret(map[string]string{
+				ResticPasswordWrapped: base64.StdEncoding.EncodeToString(wrapped),
+				ResticKeyProvider:     "vault",
+				ResticKeyID:           "raw",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "Given wrapped password not base64 encoded -> error",
+			secret: newSecret(map[string]string{
+				ResticPasswordWrapped: "not base64!",
+				ResticKeyProvider:     FileKeyProvider,
+				ResticKeyID:           "raw",
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyProviders.UnwrapResticSecret(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnwrapResticSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(got.Data[ResticPassword]) != tt.wantPassword {
+				t.Errorf("UnwrapResticSecret() password = %s, want %s", got.Data[ResticPassword], tt.wantPassword)
+			}
+			if isWrappedResticSecret(tt.secret) && len(tt.secret.Data[ResticPassword]) > 0 {
+				t.Errorf("UnwrapResticSecret() modified the given secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RepoMaintenanceLabel = "datamover.oadp.openshift.io/repomaint"
+	// tasks run by a maintenance job, comma separated
+	MaintenanceTasksAnnotation = "datamover.oadp.openshift.io/maintenance-tasks"
+	maintenanceBackoffLimit    = int32(1)
+
+	// the maintenance secret is mounted for the credentials restic reads from files
+	maintenanceCredentialsDir = "/credentials"
+)
+
+// maintenanceTasks lists the tasks in the order they run, stale locks are removed first so they do not block
+// the prune, and the repository is checked once pruned
+var maintenanceTasks = []volsnapmoverv1alpha1.MaintenanceTask{
+	volsnapmoverv1alpha1.MaintenanceTaskUnlock,
+	volsnapmoverv1alpha1.MaintenanceTaskPrune,
+	volsnapmoverv1alpha1.MaintenanceTaskCheck,
+}
+
+// maintenanceJobName returns the name of the job maintaining the repositories of a repositorymaintenance
+func maintenanceJobName(name string) string {
+	return fmt.Sprintf("%s-maintenance", name)
+}
+
+// getMaintenanceInterval returns the interval of a task, nil when the task is disabled
+func getMaintenanceInterval(m *volsnapmoverv1alpha1.RepositoryMaintenance, task volsnapmoverv1alpha1.MaintenanceTask) *metav1.Duration {
+	switch task {
+	case volsnapmoverv1alpha1.MaintenanceTaskUnlock:
+		return m.Spec.UnlockInterval
+	case volsnapmoverv1alpha1.MaintenanceTaskPrune:
+		return m.Spec.PruneInterval
+	case volsnapmoverv1alpha1.MaintenanceTaskCheck:
+		return m.Spec.CheckInterval
+	}
+
+	return nil
+}
+
+// dueMaintenanceTasks returns the tasks that have to run now, along with the time left until the next
+// task is due, or a negative duration when no other task is scheduled
+func dueMaintenanceTasks(m *volsnapmoverv1alpha1.RepositoryMaintenance, now time.Time) ([]volsnapmoverv1alpha1.MaintenanceTask, time.Duration) {
+	tasks := []volsnapmoverv1alpha1.MaintenanceTask{}
+	next := time.Duration(-1)
+	for _, task := range maintenanceTasks {
+		interval := getMaintenanceInterval(m, task)
+		if interval == nil {
+			continue
+		}
+
+		status := m.GetTaskStatus(task)
+		if status == nil || status.LastRunTimestamp == nil {
+			tasks = append(tasks, task)
+			continue
+		}
+
+		wait := status.LastRunTimestamp.Add(interval.Duration).Sub(now)
+		if wait <= 0 {
+			tasks = append(tasks, task)
+			continue
+		}
+		if next < 0 || wait < next {
+			next = wait
+		}
+	}
+
+	return tasks, next
+}
+
+// buildMaintenanceJob returns the job running the given restic tasks on every repository of the BSL, printing one
+// "<task> ok|failed <repository> [error]" line per task and repository
+func buildMaintenanceJob(m *volsnapmoverv1alpha1.RepositoryMaintenance, resticRepo string, secret *corev1.Secret,
+	tasks []volsnapmoverv1alpha1.MaintenanceTask) (*batchv1.Job, error) {
+
+	if m == nil {
+		return nil, errors.New("nil repositorymaintenance in buildMaintenanceJob")
+	}
+	if len(tasks) == 0 {
+		return nil, errors.New("no maintenance task to run")
+	}
+
+	taskNames := []string{}
+	for _, task := range tasks {
+		taskNames = append(taskNames, string(task))
+	}
+
+	return buildRepositoryJob(maintenanceJobName(m.Name), m.Namespace, "maintenance",
+		map[string]string{
+			RepoMaintenanceLabel: m.Name,
+		},
+		map[string]string{
+			DatamoverResticRepository:  resticRepo,
+			MaintenanceTasksAnnotation: strings.Join(taskNames, ","),
+		},
+		resticRepo, secret, `  for task in $2; do
+    if out=$(restic $RESTIC_FLAGS -r "$repository" $task 2>&1); then
+      echo "$task ok $repository"
+    else
+      echo "$task failed $repository $(echo "$out" | tail -n 1)"
+    fi
+  done`, strings.Join(taskNames, " "))
+}
+
+// buildRepositoryJob returns a job running the given commands on every <repo>/<namespace>/<backup>/<pvc> and shared
+// <repo>/<namespace>/volumes/<pvc namespace>/<pvc uid> repository of the BSL, listed with rclone. The commands get
+// the repository in $repository, the restic flags in $RESTIC_FLAGS and the given args from $2, the secret being in
+// their env and mounted in the credentials dir
+func buildRepositoryJob(name, namespace, container string, labels, annotations map[string]string, resticRepo string,
+	secret *corev1.Secret, commands string, args ...string) (*batchv1.Job, error) {
+
+	if secret == nil {
+		return nil, errors.New(fmt.Sprintf("nil secret for job %s", name))
+	}
+
+	remote, env, err := getRepositoryRemote(resticRepo, secret.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildResticJob(name, namespace, container, labels, annotations, resticRepo, secret, env, append([]string{
+		"/bin/sh", "-c",
+		fmt.Sprintf(`repositories=$(rclone lsf --recursive --files-only --max-depth 5 --include "/*/*/*/%[1]s" --include "/*/%[2]s/*/*/%[1]s" "$0") || exit 1
+echo "$repositories" | while read -r config; do
+  [ -n "$config" ] || continue
+  repository="$1/${config%%/%[1]s}"
+%[3]s
+done`, resticRepositoryConfig, volumeRepositoryDir, commands),
+		remote, resticRepo,
+	}, args...)), nil
+}
+
+// buildResticJob returns a job running the given command with the restic image, the secret in its env and mounted
+// in the credentials dir, and the restic flags of the secret in $RESTIC_FLAGS
+func buildResticJob(name, namespace, container string, labels, annotations map[string]string, resticRepo string,
+	secret *corev1.Secret, env []corev1.EnvVar, command []string) *batchv1.Job {
+
+	// restic reads the GCP credentials and the custom CA from files
+	if strings.HasPrefix(resticRepo, "gs:") {
+		env = append(env, corev1.EnvVar{Name: GoogleApplicationCredentials, Value: filepath.Join(maintenanceCredentialsDir, GoogleApplicationCredentials)})
+	}
+	if len(secret.Data[ResticCustomCA]) > 0 {
+		env = append(env,
+			corev1.EnvVar{Name: "RESTIC_CACERT", Value: filepath.Join(maintenanceCredentialsDir, ResticCustomCA)},
+			corev1.EnvVar{Name: "RCLONE_CA_CERT", Value: filepath.Join(maintenanceCredentialsDir, ResticCustomCA)})
+	}
+
+	env = append(env, corev1.EnvVar{Name: "RESTIC_FLAGS", Value: "--no-cache"})
+
+	backoffLimit := maintenanceBackoffLimit
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    container,
+							Image:   getDataMoverVolSyncImage(),
+							Command: command,
+							Env:     env,
+							EnvFrom: []corev1.EnvFromSource{
+								{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name}}},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "credentials", MountPath: maintenanceCredentialsDir, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "credentials",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: secret.Name},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+}
+
+// getMaintenanceJobTasks returns the tasks run by a maintenance job
+func getMaintenanceJobTasks(job *batchv1.Job) []volsnapmoverv1alpha1.MaintenanceTask {
+	tasks := []volsnapmoverv1alpha1.MaintenanceTask{}
+	for _, task := range strings.Split(job.Annotations[MaintenanceTasksAnnotation], ",") {
+		if len(task) > 0 {
+			tasks = append(tasks, volsnapmoverv1alpha1.MaintenanceTask(task))
+		}
+	}
+
+	return tasks
+}
+
+// parseMaintenanceResults returns the result of each task from the output of the maintenance job
+func parseMaintenanceResults(output string, tasks []volsnapmoverv1alpha1.MaintenanceTask, now metav1.Time) []volsnapmoverv1alpha1.MaintenanceTaskStatus {
+	results := map[volsnapmoverv1alpha1.MaintenanceTask]*volsnapmoverv1alpha1.MaintenanceTaskStatus{}
+	for _, task := range tasks {
+		results[task] = &volsnapmoverv1alpha1.MaintenanceTaskStatus{Task: task, LastRunTimestamp: &now}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// <task> ok|failed <repository> [error]
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 4)
+		if len(parts) < 3 {
+			continue
+		}
+		result, ok := results[volsnapmoverv1alpha1.MaintenanceTask(parts[0])]
+		if !ok {
+			continue
+		}
+
+		result.Repositories++
+		if parts[1] == "failed" {
+			result.FailedRepositories = append(result.FailedRepositories, parts[2])
+			if len(parts) == 4 {
+				result.Message = parts[3]
+			}
+		}
+	}
+
+	statuses := []volsnapmoverv1alpha1.MaintenanceTaskStatus{}
+	for _, task := range tasks {
+		statuses = append(statuses, *results[task])
+	}
+	return statuses
+}
+
+// setMaintenanceTaskStatus records the result of a task, replacing the result of its previous run
+func setMaintenanceTaskStatus(m *volsnapmoverv1alpha1.RepositoryMaintenance, status volsnapmoverv1alpha1.MaintenanceTaskStatus) {
+	if existing := m.GetTaskStatus(status.Task); existing != nil {
+		*existing = status
+		return
+	}
+
+	m.Status.Tasks = append(m.Status.Tasks, status)
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/konveyor/volume-snapshot-mover/pkg/manifest"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const ConditionManifestWritten = "ManifestWritten"
+const ManifestWrittenReasonError = "Error"
+const ManifestWrittenReasonComplete = "Complete"
+
+// manifestEnv holds the manifest written by the manifest job
+const manifestEnv = "DATAMOVER_MANIFEST"
+
+// manifestJobName returns the name of the job writing the snapshot manifest of a vsb
+func manifestJobName(vsbName string) string {
+	return fmt.Sprintf("%s-manifest", vsbName)
+}
+
+// WriteSnapshotManifest writes the manifest of a restic vsb next to its repository once the data
+// has been moved, so the snapshot can be restored without the velero backup. Failing to write the
+// manifest does not fail the vsb, it is reported by the ManifestWritten condition
+func (r *VolumeSnapshotBackupReconciler) WriteSnapshotManifest(log logr.Logger) (bool, error) {
+	// get volumesnapshotbackup from cluster
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := r.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+		// ignore is not found error
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotbackup %s", r.req.NamespacedName))
+		return false, err
+	}
+
+	// only restic repositories have a manifest, written once the replicationsource has completed
+	if vsb.Status.Phase != volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted || !vsb.DeletionTimestamp.IsZero() ||
+		(len(vsb.Spec.Mover) > 0 && vsb.Spec.Mover != volsnapmoverv1alpha1.ResticDataMover) {
+		return true, nil
+	}
+
+	if apimeta.FindStatusCondition(vsb.Status.Conditions, ConditionManifestWritten) != nil {
+		return true, nil
+	}
+
+	job := batchv1.Job{}
+	err := r.Get(r.Context, types.NamespacedName{Namespace: vsb.Spec.ProtectedNamespace, Name: manifestJobName(vsb.Name)}, &job)
+	if k8serrors.IsNotFound(err) {
+		m := buildSnapshotManifest(&vsb)
+		job, err := buildManifestJob(&vsb, m)
+		if err != nil {
+			return true, r.setManifestWrittenCondition(&vsb, metav1.ConditionFalse, ManifestWrittenReasonError, err.Error())
+		}
+		if err := r.Create(r.Context, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		r.Log.Info(fmt.Sprintf("writing snapshot manifest %s", m.FilePath()))
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if job.Status.Succeeded > 0 {
+		return true, r.setManifestWrittenCondition(&vsb, metav1.ConditionTrue, ManifestWrittenReasonComplete,
+			fmt.Sprintf("wrote snapshot manifest %s", buildSnapshotManifest(&vsb).FilePath()))
+	}
+
+	if isJobFailed(&job) {
+		r.EventRecorder.Event(&vsb, corev1.EventTypeWarning, "SnapshotManifestFailed",
+			fmt.Sprintf("manifest job %s failed, the snapshot can only be restored with the velero backup", job.Name))
+		return true, r.setManifestWrittenCondition(&vsb, metav1.ConditionFalse, ManifestWrittenReasonError,
+			fmt.Sprintf("manifest job %s failed", job.Name))
+	}
+
+	r.Log.Info(fmt.Sprintf("waiting for manifest job %s/%s to complete", job.Namespace, job.Name))
+	return false, nil
+}
+
+// buildSnapshotManifest describes the snapshot of a vsb from its status
+func buildSnapshotManifest(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) *manifest.Manifest {
+	pvcData := vsb.Status.SourcePVCData
+	return &manifest.Manifest{
+		Version:           manifest.Version,
+		CreationTimestamp: metav1.Now(),
+		BackupName:        vsb.Labels[backupLabel],
+		VolumeSnapshotBackup: manifest.Reference{
+			Name:      vsb.Name,
+			Namespace: vsb.Namespace,
+		},
+		Mover:                   vsb.Spec.Mover,
+		Repository:              vsb.Status.ResticRepository,
+		RepositoryLayout:        vsb.Status.RepositoryLayout,
+		SnapshotTimestamp:       getSnapshotTimestamp(vsb),
+		SnapshotID:              getSnapshotID(vsb),
+		VolumeSnapshotClassName: vsb.Status.VolumeSnapshotClassName,
+		PVC: manifest.PersistentVolumeClaim{
+			Name:             pvcData.Name,
+			Namespace:        vsb.Namespace,
+			Size:             pvcData.Size,
+			StorageClassName: pvcData.StorageClassName,
+			AccessModes:      pvcData.AccessModes,
+			VolumeMode:       pvcData.VolumeMode,
+			Labels:           pvcData.Labels,
+			Annotations:      pvcData.Annotations,
+			UID:              pvcData.UID,
+		},
+		Group: vsb.Labels[GroupTagLabel],
+	}
+}
+
+// buildManifestJob returns the job uploading the manifest with the credentials of the restic secret of the vsb
+func buildManifestJob(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, m *manifest.Manifest) (*batchv1.Job, error) {
+	if vsb == nil {
+		return nil, errors.New("nil vsb in buildManifestJob")
+	}
+
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, env, err := getRepositoryRemote(m.FilePath(), fmt.Sprintf("%s-secret", vsb.Name))
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, corev1.EnvVar{Name: manifestEnv, Value: string(data)})
+
+	backoffLimit := discoveryBackoffLimit
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      manifestJobName(vsb.Name),
+			Namespace: vsb.Spec.ProtectedNamespace,
+			Labels: map[string]string{
+				VSBLabel: vsb.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						VSBLabel: vsb.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "manifest",
+							Image: getDataMoverVolSyncImage(),
+							Command: []string{
+								"/bin/sh", "-c",
+								fmt.Sprintf(`printf '%%s\n' "$%s" | rclone rcat "$0"`, manifestEnv),
+								remote,
+							},
+							Env: env,
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+
+	return job, nil
+}
+
+func (r *VolumeSnapshotBackupReconciler) setManifestWrittenCondition(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, status metav1.ConditionStatus, reason, message string) error {
+	apimeta.SetStatusCondition(&vsb.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionManifestWritten,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(r.Context, vsb)
+}
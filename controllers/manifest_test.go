@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/konveyor/volume-snapshot-mover/pkg/manifest"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotBackupReconciler_WriteSnapshotManifest(t *testing.T) {
+	newVSB := func(phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase, mover volsnapmoverv1alpha1.DataMoverType) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsb",
+				Namespace: "bar",
+				Labels: map[string]string{
+					backupLabel: "backup-1",
+				},
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				ProtectedNamespace: namespace,
+				Mover:              mover,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Phase:            phase,
+				ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-a-pvc",
+				SourcePVCData: volsnapmoverv1alpha1.PVCData{
+					Name: "data",
+					Size: "10Gi",
+				},
+				VolumeSnapshotClassName: "csi-snapclass",
+			},
+		}
+	}
+	newJob := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsb-manifest",
+				Namespace: namespace,
+			},
+			Status: status,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		objs          []client.Object
+		want          bool
+		wantErr       bool
+		wantJob       bool
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name: "Given vsb still moving data -> nothing to do",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress, "")},
+			want: true,
+		},
+		{
+			name: "Given completed rclone vsb -> no manifest",
+			objs: []client.Object{newVSB(volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, volsnapmoverv1alpha1.RcloneDataMover)},
+			want: true,
+		},
+		{
+			name:    "Given completed restic vsb -> manifest job created",
+			objs:    []client.Object{newVSB(volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, "")},
+			want:    false,
+			wantJob: true,
+		},
+		{
+			name: "Given running manifest job -> wait",
+			objs: []client.Object{
+				newVSB(volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, ""),
+				newJob(batchv1.JobStatus{Active: 1}),
+			},
+			want:    false,
+			wantJob: true,
+		},
+		{
+			name: "Given succeeded manifest job -> manifest written",
+			objs: []client.Object{
+				newVSB(volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, ""),
+				newJob(batchv1.JobStatus{Succeeded: 1}),
+			},
+			want:          true,
+			wantJob:       true,
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "Given failed manifest job -> vsb not failed",
+			objs: []client.Object{
+				newVSB(volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted, ""),
+				newJob(batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}}),
+			},
+			want:          true,
+			wantJob:       true,
+			wantCondition: metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotBackupReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(tt.name),
+				EventRecorder: record.NewFakeRecorder(10),
+				req: reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsb"},
+				},
+			}
+			got, err := r.WriteSnapshotManifest(r.Log)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteSnapshotManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("WriteSnapshotManifest() got = %v, want %v", got, tt.want)
+			}
+
+			job := batchv1.Job{}
+			err = fakeClient.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: "sample-vsb-manifest"}, &job)
+			if tt.wantJob != !k8serrors.IsNotFound(err) {
+				t.Errorf("manifest job found = %v, want %v", !k8serrors.IsNotFound(err), tt.wantJob)
+			}
+
+			vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+			if err := fakeClient.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+				t.Fatalf("unable to fetch vsb: %v", err)
+			}
+			condition := apimeta.FindStatusCondition(vsb.Status.Conditions, ConditionManifestWritten)
+			if len(tt.wantCondition) == 0 {
+				if condition != nil {
+					t.Errorf("unexpected %s condition %v", ConditionManifestWritten, condition)
+				}
+			} else if condition == nil || condition.Status != tt.wantCondition {
+				t.Errorf("%s condition = %v, want status %v", ConditionManifestWritten, condition, tt.wantCondition)
+			}
+		})
+	}
+}
+
+func TestBuildManifestJob(t *testing.T) {
+	vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsb",
+			Namespace: "bar",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+			ProtectedNamespace: namespace,
+		},
+		Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+			ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-a-pvc",
+		},
+	}
+	m := &manifest.Manifest{
+		Repository: vsb.Status.ResticRepository,
+		PVC: manifest.PersistentVolumeClaim{
+			Name: "data",
+			Size: "10Gi",
+		},
+	}
+
+	job, err := buildManifestJob(vsb, m)
+	if err != nil {
+		t.Fatalf("buildManifestJob() error = %v", err)
+	}
+	if job.Namespace != namespace || job.Labels[VSBLabel] != vsb.Name {
+		t.Errorf("buildManifestJob() job %s/%s labels %v", job.Namespace, job.Name, job.Labels)
+	}
+
+	container := job.Spec.Template.Spec.Containers[0]
+	if remote := container.Command[len(container.Command)-1]; remote != ":s3:bucket/foo/backup-1/snapcontent-a-pvc-manifest.json" {
+		t.Errorf("buildManifestJob() remote = %v", remote)
+	}
+
+	var data string
+	for _, env := range container.Env {
+		if env.Name == manifestEnv {
+			data = env.Value
+		}
+	}
+	got, err := manifest.Unmarshal([]byte(data))
+	if err != nil {
+		t.Fatalf("manifest.Unmarshal() error = %v", err)
+	}
+	if got.Repository != m.Repository || got.Version != manifest.Version {
+		t.Errorf("buildManifestJob() manifest = %v", got)
+	}
+
+	if _, err := buildManifestJob(vsb, &manifest.Manifest{Repository: vsb.Status.ResticRepository}); err == nil {
+		t.Errorf("buildManifestJob() without pvc data should return an error")
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"time"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Metric label values
+const (
+	backupMetricKind  = "backup"
+	restoreMetricKind = "restore"
+
+	metricResultCompleted       = "completed"
+	metricResultFailed          = "failed"
+	metricResultPartiallyFailed = "partially_failed"
+)
+
+var (
+	// durations range from 10 seconds to about 11 hours
+	durationBuckets = prometheus.ExponentialBuckets(10, 2, 13)
+
+	vsbDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "datamover_volumesnapshotbackup_duration_seconds",
+		Help:    "Time taken by volumesnapshotbackups to reach a phase, since they were started",
+		Buckets: durationBuckets,
+	}, []string{"phase"})
+
+	vsrDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "datamover_volumesnapshotrestore_duration_seconds",
+		Help:    "Time taken by volumesnapshotrestores to reach a phase, since they were started",
+		Buckets: durationBuckets,
+	}, []string{"phase"})
+
+	vsbResultsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "datamover_volumesnapshotbackup_results_total",
+		Help: "Number of volumesnapshotbackups that completed, failed or partially failed",
+	}, []string{"result", "storage_class", "namespace"})
+
+	vsrResultsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "datamover_volumesnapshotrestore_results_total",
+		Help: "Number of volumesnapshotrestores that completed, failed or partially failed",
+	}, []string{"result", "storage_class", "namespace"})
+
+	vsbBytesMovedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "datamover_volumesnapshotbackup_bytes_moved_total",
+		Help: "Number of bytes moved by volumesnapshotbackups",
+	}, []string{"storage_class", "namespace"})
+
+	vsrBytesMovedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "datamover_volumesnapshotrestore_bytes_moved_total",
+		Help: "Number of bytes moved by volumesnapshotrestores",
+	}, []string{"storage_class", "namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		vsbDurationSeconds,
+		vsrDurationSeconds,
+		vsbResultsTotal,
+		vsrResultsTotal,
+		vsbBytesMovedTotal,
+		vsrBytesMovedTotal,
+	)
+}
+
+// schedulerCollector reports the number of queued and processing objects of a BatchScheduler
+type schedulerCollector struct {
+	kind       string
+	scheduler  *BatchScheduler
+	queued     *prometheus.Desc
+	processing *prometheus.Desc
+}
+
+func newSchedulerCollector(kind string, scheduler *BatchScheduler) *schedulerCollector {
+	return &schedulerCollector{
+		kind:      kind,
+		scheduler: scheduler,
+		queued: prometheus.NewDesc("datamover_queued",
+			"Number of volumesnapshotbackups or volumesnapshotrestores waiting for a slot", nil, prometheus.Labels{"kind": kind}),
+		processing: prometheus.NewDesc("datamover_processing",
+			"Number of volumesnapshotbackups or volumesnapshotrestores holding a slot", nil, prometheus.Labels{"kind": kind}),
+	}
+}
+
+func (c *schedulerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queued
+	ch <- c.processing
+}
+
+func (c *schedulerCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(c.scheduler.Queued()))
+	ch <- prometheus.MustNewConstMetric(c.processing, prometheus.GaugeValue, float64(c.scheduler.InUse()))
+}
+
+// registerSchedulerMetrics exposes the batching gauges of the scheduler
+func registerSchedulerMetrics(kind string, scheduler *BatchScheduler) error {
+	return metrics.Registry.Register(newSchedulerCollector(kind, scheduler))
+}
+
+// observeVSBPhase records the metrics of a volumesnapshotbackup that just reached its phase
+func observeVSBPhase(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) {
+	if vsb == nil {
+		return
+	}
+
+	storageClass := vsb.Status.SourcePVCData.StorageClassName
+	vsbDurationSeconds.WithLabelValues(string(vsb.Status.Phase)).Observe(phaseDuration(vsb.CreationTimestamp, vsb.Status.StartTimestamp, vsb.Status.CompletionTimestamp).Seconds())
+
+	switch vsb.Status.Phase {
+	case volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted:
+		if vsb.Status.Progress != nil {
+			vsbBytesMovedTotal.WithLabelValues(storageClass, vsb.Namespace).Add(float64(vsb.Status.Progress.BytesDone))
+		}
+	case volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted:
+		vsbResultsTotal.WithLabelValues(metricResultCompleted, storageClass, vsb.Namespace).Inc()
+	case volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed:
+		vsbResultsTotal.WithLabelValues(metricResultFailed, storageClass, vsb.Namespace).Inc()
+	case volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed:
+		vsbResultsTotal.WithLabelValues(metricResultPartiallyFailed, storageClass, vsb.Namespace).Inc()
+	}
+}
+
+// observeVSRPhase records the metrics of a volumesnapshotrestore that just reached its phase
+func observeVSRPhase(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore) {
+	if vsr == nil {
+		return
+	}
+
+	storageClass := vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName
+	vsrDurationSeconds.WithLabelValues(string(vsr.Status.Phase)).Observe(phaseDuration(vsr.CreationTimestamp, vsr.Status.StartTimestamp, vsr.Status.CompletionTimestamp).Seconds())
+
+	switch vsr.Status.Phase {
+	case volsnapmoverv1alpha1.SnapMoverRestoreVolSyncPhaseCompleted:
+		if vsr.Status.Progress != nil {
+			vsrBytesMovedTotal.WithLabelValues(storageClass, vsr.Namespace).Add(float64(vsr.Status.Progress.BytesDone))
+		}
+	case volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted:
+		vsrResultsTotal.WithLabelValues(metricResultCompleted, storageClass, vsr.Namespace).Inc()
+	case volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed:
+		vsrResultsTotal.WithLabelValues(metricResultFailed, storageClass, vsr.Namespace).Inc()
+	case volsnapmoverv1alpha1.SnapMoverRestorePhasePartiallyFailed:
+		vsrResultsTotal.WithLabelValues(metricResultPartiallyFailed, storageClass, vsr.Namespace).Inc()
+	}
+}
+
+// phaseDuration returns the time between the start of an object, or its creation if it
+// was never started, and the time it reached its phase
+func phaseDuration(created metav1.Time, start, completion *metav1.Time) time.Duration {
+	from := created.Time
+	if start != nil {
+		from = start.Time
+	}
+
+	to := time.Now()
+	if completion != nil {
+		to = completion.Time
+	}
+
+	if to.Before(from) {
+		return 0
+	}
+
+	return to.Sub(from)
+}
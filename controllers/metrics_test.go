@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func histogramSampleCount(t *testing.T, h *prometheus.HistogramVec, phase string) uint64 {
+	m := dto.Metric{}
+	if err := h.WithLabelValues(phase).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("unable to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func Test_observeVSBPhase(t *testing.T) {
+	start := metav1.NewTime(time.Now().Add(-time.Minute))
+	completion := metav1.Now()
+	tests := []struct {
+		name       string
+		phase      volsnapmoverv1alpha1.VolumeSnapshotBackupPhase
+		progress   *volsnapmoverv1alpha1.DataMoverProgress
+		wantResult string
+		wantBytes  float64
+	}{
+		{
+			name:      "Given volsync completed vsb -> bytes moved counted",
+			phase:     volsnapmoverv1alpha1.SnapMoverVolSyncPhaseCompleted,
+			progress:  &volsnapmoverv1alpha1.DataMoverProgress{TotalBytes: 2048, BytesDone: 2048},
+			wantBytes: 2048,
+		},
+		{
+			name:       "Given completed vsb -> completed result counted",
+			phase:      volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted,
+			wantResult: metricResultCompleted,
+		},
+		{
+			name:       "Given failed vsb -> failed result counted",
+			phase:      volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed,
+			wantResult: metricResultFailed,
+		},
+		{
+			name:       "Given partially failed vsb -> partially failed result counted",
+			phase:      volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed,
+			wantResult: metricResultPartiallyFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "metrics-vsb",
+				},
+				Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+					Phase:               tt.phase,
+					StartTimestamp:      &start,
+					CompletionTimestamp: &completion,
+					Progress:            tt.progress,
+					SourcePVCData: volsnapmoverv1alpha1.PVCData{
+						StorageClassName: "gp2",
+					},
+				},
+			}
+
+			durationsBefore := histogramSampleCount(t, vsbDurationSeconds, string(tt.phase))
+			bytesBefore := testutil.ToFloat64(vsbBytesMovedTotal.WithLabelValues("gp2", "metrics-vsb"))
+			resultBefore := 0.0
+			if tt.wantResult != "" {
+				resultBefore = testutil.ToFloat64(vsbResultsTotal.WithLabelValues(tt.wantResult, "gp2", "metrics-vsb"))
+			}
+
+			observeVSBPhase(vsb)
+
+			if got := histogramSampleCount(t, vsbDurationSeconds, string(tt.phase)) - durationsBefore; got != 1 {
+				t.Errorf("observed %v durations, want 1", got)
+			}
+			if got := testutil.ToFloat64(vsbBytesMovedTotal.WithLabelValues("gp2", "metrics-vsb")) - bytesBefore; got != tt.wantBytes {
+				t.Errorf("bytes moved increased by %v, want %v", got, tt.wantBytes)
+			}
+			if tt.wantResult != "" {
+				if got := testutil.ToFloat64(vsbResultsTotal.WithLabelValues(tt.wantResult, "gp2", "metrics-vsb")) - resultBefore; got != 1 {
+					t.Errorf("%s results increased by %v, want 1", tt.wantResult, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_observeVSRPhase(t *testing.T) {
+	vsr := &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsr",
+			Namespace: "metrics-vsr",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+			VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+				BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+					StorageClassName: "gp2",
+				},
+			},
+		},
+		Status: volsnapmoverv1alpha1.VolumeSnapshotRestoreStatus{
+			Phase: volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed,
+		},
+	}
+
+	before := testutil.ToFloat64(vsrResultsTotal.WithLabelValues(metricResultFailed, "gp2", "metrics-vsr"))
+	observeVSRPhase(vsr)
+	if got := testutil.ToFloat64(vsrResultsTotal.WithLabelValues(metricResultFailed, "gp2", "metrics-vsr")) - before; got != 1 {
+		t.Errorf("failed results increased by %v, want 1", got)
+	}
+}
+
+func TestVolumeSnapshotBackupReconciler_updateVSBStatusPhaseMetrics(t *testing.T) {
+	vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsb",
+			Namespace: "metrics-update",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+			ProtectedNamespace: namespace,
+		},
+	}
+	fakeClient, err := getFakeClientFromObjects(vsb)
+	if err != nil {
+		t.Fatalf("error creating fake client, likely programmer error")
+	}
+	r := &VolumeSnapshotBackupReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		Log:           logr.Discard(),
+		Context:       newContextForTest("metrics"),
+		EventRecorder: record.NewFakeRecorder(10),
+		req: reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name},
+		},
+	}
+
+	before := testutil.ToFloat64(vsbResultsTotal.WithLabelValues(metricResultFailed, "", "metrics-update"))
+	if err := r.updateVSBStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, r.Client); err != nil {
+		t.Fatalf("updateVSBStatusPhase() error = %v", err)
+	}
+	if got := testutil.ToFloat64(vsbResultsTotal.WithLabelValues(metricResultFailed, "", "metrics-update")) - before; got != 1 {
+		t.Errorf("failed results increased by %v, want 1", got)
+	}
+}
+
+func Test_schedulerCollector(t *testing.T) {
+	s := NewBatchScheduler()
+	s.SetLimit(1)
+	s.TryAcquire(types.NamespacedName{Namespace: "bar", Name: "vsb-a"})
+	s.TryAcquire(types.NamespacedName{Namespace: "bar", Name: "vsb-b"})
+	s.TryAcquire(types.NamespacedName{Namespace: "bar", Name: "vsb-c"})
+
+	want := `
+# HELP datamover_processing Number of volumesnapshotbackups or volumesnapshotrestores holding a slot
+# TYPE datamover_processing gauge
+datamover_processing{kind="backup"} 1
+# HELP datamover_queued Number of volumesnapshotbackups or volumesnapshotrestores waiting for a slot
+# TYPE datamover_queued gauge
+datamover_queued{kind="backup"} 2
+`
+	if err := testutil.CollectAndCompare(newSchedulerCollector(backupMetricKind, s), strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected metrics: %v", err)
+	}
+
+	// a deleted queued object leaves the queue
+	s.Release(types.NamespacedName{Namespace: "bar", Name: "vsb-c"})
+	if s.Queued() != 1 {
+		t.Errorf("Queued() = %v, want 1", s.Queued())
+	}
+}
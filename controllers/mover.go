@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dataMover builds the VolSync resources needed by a single data mover backend
+type dataMover interface {
+	// repositoryKey is the secret key holding the base repository path
+	repositoryKey() string
+
+	// validateSecret checks the user provided secret has the values needed by the mover
+	validateSecret(secret *corev1.Secret) error
+
+	// buildSecret populates the per-VSB/VSR secret consumed by the VolSync mover
+	buildSecret(givensecret *corev1.Secret, secret *corev1.Secret, repo, pruneInterval string, rpolicy *RetainPolicy, scheduleCronExpr string) error
+
+	// buildReplicationSourceSpec builds the ReplicationSource spec for a VSB
+	buildReplicationSourceSpec(r *VolumeSnapshotBackupReconciler, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim,
+		moverSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error)
+
+	// buildReplicationDestinationSpec builds the ReplicationDestination spec for a VSR
+	buildReplicationDestinationSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
+		moverSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationSpec, error)
+
+	// isReplicationSourceCompleted returns true once the ReplicationSource has finished moving data
+	isReplicationSourceCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool
+
+	// isReplicationDestinationCompleted returns true once the ReplicationDestination has finished moving data
+	isReplicationDestinationCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool
+}
+
+// getDataMover returns the data mover for the given type, defaulting to restic
+func getDataMover(moverType volsnapmoverv1alpha1.DataMoverType) (dataMover, error) {
+	switch moverType {
+	case "", volsnapmoverv1alpha1.ResticDataMover:
+		return &resticDataMover{}, nil
+	case volsnapmoverv1alpha1.RcloneDataMover:
+		return &rcloneDataMover{}, nil
+	case volsnapmoverv1alpha1.RsyncTLSDataMover:
+		return &rsyncTLSDataMover{}, nil
+	case volsnapmoverv1alpha1.RsyncDataMover:
+		return &rsyncDataMover{}, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("unsupported data mover %s", moverType))
+}
+
+// cloneMover copies the cloned volume of a VSB to a VSR of the same cluster,
+// the VSR creates both the ReplicationDestination and the ReplicationSource
+type cloneMover interface {
+	dataMover
+
+	// buildCloneReplicationSourceSpec builds the ReplicationSource spec syncing the cloned PVC to the
+	// VSR ReplicationDestination, nil until the ReplicationDestination is ready for connections
+	buildCloneReplicationSourceSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, pvcName string,
+		repDest *volsyncv1alpha1.ReplicationDestination, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error)
+}
+
+// getCloneMover returns the clone mover for the given type
+func getCloneMover(moverType volsnapmoverv1alpha1.DataMoverType) (cloneMover, error) {
+	mover, err := getDataMover(moverType)
+	if err != nil {
+		return nil, err
+	}
+
+	cloner, ok := mover.(cloneMover)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("data mover %s does not support cloning", moverType))
+	}
+
+	return cloner, nil
+}
+
+// isSyncCompleted returns true if the manual trigger has been synced,
+// or if a scheduled sync has run at least once
+func isSyncCompleted(manual string, schedule *string, lastManualSync string, lastSyncTime, nextSyncTime *metav1.Time) bool {
+	// for manual trigger, if spec.trigger.manual == status.lastManualSync, sync has completed
+	if len(lastManualSync) > 0 && len(manual) > 0 && lastManualSync == manual {
+		return true
+	}
+	// for schedule trigger, LastSyncTime will be set at the end of every replication.
+	if schedule != nil && nextSyncTime != nil && lastSyncTime != nil {
+		return true
+	}
+	return false
+}
+
+func repSourceSyncCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool {
+	if repSource == nil || repSource.Status == nil || repSource.Spec.Trigger == nil {
+		return false
+	}
+
+	return isSyncCompleted(repSource.Spec.Trigger.Manual, repSource.Spec.Trigger.Schedule, repSource.Status.LastManualSync,
+		repSource.Status.LastSyncTime, repSource.Status.NextSyncTime)
+}
+
+func repDestSyncCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool {
+	if repDest == nil || repDest.Status == nil || repDest.Spec.Trigger == nil {
+		return false
+	}
+
+	return isSyncCompleted(repDest.Spec.Trigger.Manual, repDest.Spec.Trigger.Schedule, repDest.Status.LastManualSync,
+		repDest.Status.LastSyncTime, repDest.Status.NextSyncTime)
+}
+
+// getMoverSecurityContext returns the source application pod securityContext
+// if the storage class volume options request it
+func getMoverSecurityContext(options volsnapmoverv1alpha1.DataMoverVolumeOptions, namespace string, pvcName string, c client.Client) (*corev1.PodSecurityContext, error) {
+	if !options.MoverSecurityContext {
+		return nil, nil
+	}
+
+	return GetPodSecurityContext(namespace, pvcName, c)
+}
+
+// isBlockVolume returns true if the volume mode is Block, PVCs default to Filesystem
+func isBlockVolume(volumeMode *corev1.PersistentVolumeMode) bool {
+	return volumeMode != nil && *volumeMode == corev1.PersistentVolumeBlock
+}
+
+// validateVolumeMode returns an error for block volumes, the VolSync movers mount the volume they move as a filesystem
+func validateVolumeMode(volumeMode *corev1.PersistentVolumeMode) error {
+	if isBlockVolume(volumeMode) {
+		return errors.New("block volumes are not supported, the data movers only move filesystem volumes")
+	}
+
+	return nil
+}
+
+// getBackedUpCapacity parses the backed up PVC size of a VSR
+func getBackedUpCapacity(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore) (*resource.Quantity, error) {
+	if vsr == nil {
+		return nil, errors.New("nil vsr in getBackedUpCapacity")
+	}
+
+	stringCapacity := vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size
+	capacity, err := resource.ParseQuantity(stringCapacity)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot parse backed up pvc size %s for vsr %s/%s", stringCapacity, vsr.Namespace, vsr.Name))
+	}
+
+	return &capacity, nil
+}
+
+// resticDataMover moves data using the VolSync restic mover
+type resticDataMover struct{}
+
+func (m *resticDataMover) repositoryKey() string {
+	return ResticRepository
+}
+
+func (m *resticDataMover) validateSecret(secret *corev1.Secret) error {
+	return ValidateResticSecret(secret)
+}
+
+func (m *resticDataMover) buildSecret(givensecret *corev1.Secret, secret *corev1.Secret, repo, pruneInterval string, rpolicy *RetainPolicy, scheduleCronExpr string) error {
+	return BuildResticSecret(givensecret, secret, repo, pruneInterval, rpolicy, scheduleCronExpr)
+}
+
+func (m *resticDataMover) buildReplicationSourceSpec(r *VolumeSnapshotBackupReconciler, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim,
+	resticSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+
+	if resticSecret == nil {
+		return nil, errors.New("nil resticSecret in buildReplicationSourceSpec")
+	}
+
+	// fetch the prune interval if specified in the secret
+	var pruneIntervalInt = int64(0)
+	var err error
+	pruneInterval := resticSecret.Data[ResticPruneInterval]
+
+	//fetch the schedule crop expression specified in the secret
+	scheduleCron := ""
+	if len(resticSecret.Data[SnapshotScheduleCron]) > 0 {
+		scheduleCron = string(resticSecret.Data[SnapshotScheduleCron])
+	}
+
+	if len(pruneInterval) > 0 {
+		pruneIntervalInt, err = strconv.ParseInt(string(pruneInterval), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// fetch the retain policy from restic secret and then pass it on to replication source CR
+	rpolicy := RetainPolicy{}
+	rpolicy.hourly = string(resticSecret.Data[SnapshotRetainPolicyHourly])
+	rpolicy.monthly = string(resticSecret.Data[SnapshotRetainPolicyMonthly])
+	rpolicy.daily = string(resticSecret.Data[SnapshotRetainPolicyDaily])
+	rpolicy.weekly = string(resticSecret.Data[SnapshotRetainPolicyWeekly])
+	rpolicy.yearly = string(resticSecret.Data[SnapshotRetainPolicyYearly])
+	rpolicy.within = string(resticSecret.Data[SnapshotRetainPolicyWithin])
+
+	resticVolOptions, err := r.configureRepSourceResticVolOptions(vsb, resticSecret.Name, pvc, config, sa, rpolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationSourceSpec := r.getReplicationSourceSpec(vsb.Name, pvc.Name, scheduleCron, resticVolOptions)
+
+	if pruneIntervalInt != 0 {
+		replicationSourceSpec.Restic.PruneIntervalDays = pointer.Int32(int32(pruneIntervalInt))
+	}
+
+	// pass along a custom CA if specified
+	resticCustomCA := resticSecret.Data[ResticCustomCA]
+	if len(resticCustomCA) > 0 {
+		replicationSourceSpec.Restic.CustomCA.SecretName = resticSecret.Name
+		replicationSourceSpec.Restic.CustomCA.Key = ResticCustomCA
+	}
+
+	return &replicationSourceSpec, nil
+}
+
+func (m *resticDataMover) buildReplicationDestinationSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
+	resticSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationSpec, error) {
+
+	if resticSecret == nil {
+		return nil, errors.New("nil resticSecret in buildReplicationDestinationSpec")
+	}
+
+	capacity, err := getBackedUpCapacity(vsr)
+	if err != nil {
+		return nil, err
+	}
+
+	resticVolOptions, err := r.configureRepDestResticVolOptions(vsr, resticSecret.Name, config, capacity, sa)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationDestinationSpec := volsyncv1alpha1.ReplicationDestinationSpec{
+		Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		Restic: resticVolOptions,
+	}
+
+	// include custom CA if specified
+	resticCustomCA := resticSecret.Data[ResticCustomCA]
+	if len(resticCustomCA) > 0 {
+		replicationDestinationSpec.Restic.CustomCA.SecretName = resticSecret.Name
+		replicationDestinationSpec.Restic.CustomCA.Key = ResticCustomCA
+	}
+
+	return &replicationDestinationSpec, nil
+}
+
+func (m *resticDataMover) isReplicationSourceCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool {
+	return repSourceSyncCompleted(repSource)
+}
+
+func (m *resticDataMover) isReplicationDestinationCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool {
+	return repDestSyncCompleted(repDest)
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDataMover(t *testing.T) {
+	tests := []struct {
+		name      string
+		moverType volsnapmoverv1alpha1.DataMoverType
+		want      dataMover
+		wantErr   bool
+	}{
+		{
+			name:      "Given empty mover -> restic mover",
+			moverType: "",
+			want:      &resticDataMover{},
+			wantErr:   false,
+		},
+		{
+			name:      "Given restic mover -> restic mover",
+			moverType: volsnapmoverv1alpha1.ResticDataMover,
+			want:      &resticDataMover{},
+			wantErr:   false,
+		},
+		{
+			name:      "Given rclone mover -> rclone mover",
+			moverType: volsnapmoverv1alpha1.RcloneDataMover,
+			want:      &rcloneDataMover{},
+			wantErr:   false,
+		},
+		{
+			name:      "Given rsync-tls mover -> rsync-tls mover",
+			moverType: volsnapmoverv1alpha1.RsyncTLSDataMover,
+			want:      &rsyncTLSDataMover{},
+			wantErr:   false,
+		},
+		{
+			name:      "Given rsync mover -> rsync mover",
+			moverType: volsnapmoverv1alpha1.RsyncDataMover,
+			want:      &rsyncDataMover{},
+			wantErr:   false,
+		},
+		{
+			name:      "Given unknown mover -> error",
+			moverType: "syncthing",
+			want:      nil,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDataMover(tt.moverType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDataMover() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getDataMover() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepSourceSyncCompleted(t *testing.T) {
+	schedule := "*/5 * * * *"
+	now := v1.Now()
+	tests := []struct {
+		name      string
+		repSource *volsyncv1alpha1.ReplicationSource
+		want      bool
+	}{
+		{
+			name:      "Given nil replicationsource -> not completed",
+			repSource: nil,
+			want:      false,
+		},
+		{
+			name: "Given replicationsource without status -> not completed",
+			repSource: &volsyncv1alpha1.ReplicationSource{
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+						Manual: "sample-vsb-trigger",
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "Given manual trigger synced -> completed",
+			repSource: &volsyncv1alpha1.ReplicationSource{
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+						Manual: "sample-vsb-trigger",
+					},
+				},
+				Status: &volsyncv1alpha1.ReplicationSourceStatus{
+					LastManualSync: "sample-vsb-trigger",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "Given manual trigger not yet synced -> not completed",
+			repSource: &volsyncv1alpha1.ReplicationSource{
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+						Manual: "sample-vsb-trigger",
+					},
+				},
+				Status: &volsyncv1alpha1.ReplicationSourceStatus{},
+			},
+			want: false,
+		},
+		{
+			name: "Given schedule trigger synced -> completed",
+			repSource: &volsyncv1alpha1.ReplicationSource{
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+						Schedule: &schedule,
+					},
+				},
+				Status: &volsyncv1alpha1.ReplicationSourceStatus{
+					LastSyncTime: &now,
+					NextSyncTime: &now,
+				},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repSourceSyncCompleted(tt.repSource); got != tt.want {
+				t.Errorf("repSourceSyncCompleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVolumeMode(t *testing.T) {
+	blockMode := corev1.PersistentVolumeBlock
+	filesystemMode := corev1.PersistentVolumeFilesystem
+	tests := []struct {
+		name       string
+		volumeMode *corev1.PersistentVolumeMode
+		wantErr    bool
+	}{
+		{
+			name:       "Given unset volume mode -> no error",
+			volumeMode: nil,
+			wantErr:    false,
+		},
+		{
+			name:       "Given filesystem volume -> no error",
+			volumeMode: &filesystemMode,
+			wantErr:    false,
+		},
+		{
+			name:       "Given block volume -> error",
+			volumeMode: &blockMode,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateVolumeMode(tt.volumeMode); (err != nil) != tt.wantErr {
+				t.Errorf("validateVolumeMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
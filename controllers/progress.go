@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// number of mover log lines searched for the latest progress
+	progressLogTailLines int64 = 100
+
+	// VolSync mover jobs are named volsync-<src|dst>-<replicationsource|replicationdestination name>
+	volSyncSourceJobPrefix      = "volsync-src-"
+	volSyncDestinationJobPrefix = "volsync-dst-"
+)
+
+// resticProgressLine matches the status lines restic prints every RESTIC_PROGRESS_FPS, which the VolSync
+// mover sets to 0.1, when its output is not a terminal. The percentage and the totals are printed once the
+// files to back up are counted, e.g.
+// [0:05] 10 files, 256.000 MiB, 0 errors
+// [1:02:03] 25.00%  10 files 256.000 MiB, total 40 files 1.000 GiB, 0 errors ETA 3:04
+// [1:03:00] 40 files 1.000 GiB, total 40 files 1.000 GiB, 0 errors
+var resticProgressLine = regexp.MustCompile(`^\[((?:\d+:)?\d+:\d+)\]\s+(?:([\d.]+)%\s+)?(\d+) files,? ([\d.]+ [KMGTP]?i?B)(?:, total (\d+) files ([\d.]+ [KMGTP]?i?B))?, \d+ errors(?: ETA ((?:\d+:)?\d+:\d+))?$`)
+
+var byteUnits = map[string]float64{
+	"B":   1,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+	"PiB": 1 << 50,
+}
+
+// ProgressCollector reads the progress of a data movement from the logs of the VolSync mover pod
+type ProgressCollector struct {
+	Clientset kubernetes.Interface
+}
+
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+
+// Collect returns the latest progress reported by the running pod of the given VolSync mover job,
+// or nil if there is no running pod or it has not reported any progress yet
+func (p *ProgressCollector) Collect(ctx context.Context, c client.Client, namespace, jobName string) (*volsnapmoverv1alpha1.DataMoverProgress, error) {
+	if p == nil || p.Clientset == nil {
+		return nil, nil
+	}
+
+	podList := corev1.PodList{}
+	if err := c.List(ctx, &podList, client.InNamespace(namespace), client.MatchingLabels{"job-name": jobName}); err != nil {
+		return nil, err
+	}
+
+	// newest running pod, older pods belong to previous attempts
+	var moverPod *corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if moverPod == nil || moverPod.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			moverPod = pod
+		}
+	}
+	if moverPod == nil {
+		return nil, nil
+	}
+
+	tailLines := progressLogTailLines
+	stream, err := p.Clientset.CoreV1().Pods(namespace).GetLogs(moverPod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).Stream(ctx)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read logs of mover pod %s/%s: %v", namespace, moverPod.Name, err))
+	}
+	defer stream.Close()
+
+	return parseProgress(stream)
+}
+
+// parseProgress returns the last progress found in the mover logs. The restic 0.15 restore of the VolSync
+// mover reports no progress
+func parseProgress(logs io.Reader) (*volsnapmoverv1alpha1.DataMoverProgress, error) {
+	var progress *volsnapmoverv1alpha1.DataMoverProgress
+
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		if p := parseResticTextStatus(strings.TrimSpace(scanner.Text())); p != nil {
+			progress = p
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func parseResticTextStatus(line string) *volsnapmoverv1alpha1.DataMoverProgress {
+	match := resticProgressLine.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	elapsed, err := parseResticDuration(match[1])
+	if err != nil {
+		return nil
+	}
+	var percent float64
+	if len(match[2]) > 0 {
+		percent, err = strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil
+		}
+	}
+	filesDone, err := strconv.ParseInt(match[3], 10, 64)
+	if err != nil {
+		return nil
+	}
+	bytesDone, err := parseResticBytes(match[4])
+	if err != nil {
+		return nil
+	}
+
+	// the totals are unknown while restic counts the files
+	var totalFiles, totalBytes int64
+	if len(match[5]) > 0 {
+		totalFiles, err = strconv.ParseInt(match[5], 10, 64)
+		if err != nil {
+			return nil
+		}
+		totalBytes, err = parseResticBytes(match[6])
+		if err != nil {
+			return nil
+		}
+	}
+
+	var remaining time.Duration
+	if len(match[7]) > 0 {
+		remaining, err = parseResticDuration(match[7])
+		if err != nil {
+			return nil
+		}
+	}
+
+	return newProgress(totalBytes, bytesDone, totalFiles, filesDone, percent, elapsed, remaining)
+}
+
+// newProgress builds the progress, estimating the throughput and the remaining
+// time when the mover does not report them
+func newProgress(totalBytes, bytesDone, totalFiles, filesDone int64, percent float64, elapsed, remaining time.Duration) *volsnapmoverv1alpha1.DataMoverProgress {
+	progress := &volsnapmoverv1alpha1.DataMoverProgress{
+		TotalBytes: totalBytes,
+		BytesDone:  bytesDone,
+		TotalFiles: totalFiles,
+		FilesDone:  filesDone,
+	}
+
+	if percent == 0 && totalBytes > 0 {
+		percent = float64(bytesDone) * 100 / float64(totalBytes)
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	progress.Percentage = int32(percent)
+
+	if elapsed > 0 {
+		progress.BytesPerSecond = int64(float64(bytesDone) / elapsed.Seconds())
+	}
+
+	if remaining == 0 && progress.BytesPerSecond > 0 && totalBytes > bytesDone {
+		remaining = time.Duration(float64(totalBytes-bytesDone)/float64(progress.BytesPerSecond)) * time.Second
+	}
+	if remaining > 0 {
+		progress.EstimatedTimeRemaining = &metav1.Duration{Duration: remaining}
+	}
+
+	return progress
+}
+
+// completedProgress returns progress reporting every byte as moved
+func completedProgress(progress *volsnapmoverv1alpha1.DataMoverProgress) *volsnapmoverv1alpha1.DataMoverProgress {
+	if progress == nil {
+		return nil
+	}
+
+	completed := progress.DeepCopy()
+	completed.BytesDone = completed.TotalBytes
+	completed.FilesDone = completed.TotalFiles
+	completed.Percentage = 100
+	completed.EstimatedTimeRemaining = nil
+
+	return completed
+}
+
+// parseResticDuration parses restic durations such as 1:02:03 or 02:03
+func parseResticDuration(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	var duration time.Duration
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, err
+		}
+		duration = duration*60 + time.Duration(n)
+	}
+
+	return duration * time.Second, nil
+}
+
+// parseResticBytes parses restic sizes such as 256.000 MiB
+func parseResticBytes(value string) (int64, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, errors.New(fmt.Sprintf("invalid size %s", value))
+	}
+
+	unit, ok := byteUnits[fields[1]]
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("invalid size unit %s", fields[1]))
+	}
+
+	n, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(n * unit), nil
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_parseProgress(t *testing.T) {
+	tests := []struct {
+		name string
+		logs string
+		want *volsnapmoverv1alpha1.DataMoverProgress
+	}{
+		{
+			name: "Given restic status while counting files -> progress without totals",
+			logs: "[0:10] 10 files, 1.000 KiB, 0 errors",
+			want: &volsnapmoverv1alpha1.DataMoverProgress{
+				BytesDone:      1024,
+				FilesDone:      10,
+				BytesPerSecond: 102,
+			},
+		},
+		{
+			name: "Given restic status with totals -> progress from status",
+			logs: "[0:10] 25.00%  10 files 1.000 KiB, total 40 files 4.000 KiB, 0 errors ETA 0:30",
+			want: &volsnapmoverv1alpha1.DataMoverProgress{
+				TotalBytes:             4096,
+				BytesDone:              1024,
+				TotalFiles:             40,
+				FilesDone:              10,
+				Percentage:             25,
+				BytesPerSecond:         102,
+				EstimatedTimeRemaining: &metav1.Duration{Duration: 30 * time.Second},
+			},
+		},
+		{
+			name: "Given restic status of a finished backup -> percentage from bytes",
+			logs: "[1:00:00] 40 files 3.516 MiB, total 40 files 3.516 MiB, 0 errors",
+			want: &volsnapmoverv1alpha1.DataMoverProgress{
+				TotalBytes:     3686793,
+				BytesDone:      3686793,
+				TotalFiles:     40,
+				FilesDone:      40,
+				Percentage:     100,
+				BytesPerSecond: 1024,
+			},
+		},
+		{
+			name: "Given restic backup mover logs -> last status wins",
+			logs: strings.Join([]string{
+				"VolSync restic container version: v0.7.0",
+				"restic 0.15.1 compiled with go1.19.5 on linux/amd64",
+				"=== Starting backup ===",
+				"/data",
+				"no parent snapshot found, will read all files",
+				"[0:00] 0 files, 0 B, 0 errors",
+				"[0:10] 10.00%  1 files 10 B, total 2 files 100 B, 0 errors ETA 1:30",
+				"/data/file-1",
+				"[0:20] 20.00%  1 files 20 B, total 2 files 100 B, 0 errors ETA 1:20",
+				"/data/file-2",
+			}, "\n"),
+			want: &volsnapmoverv1alpha1.DataMoverProgress{
+				TotalBytes:             100,
+				BytesDone:              20,
+				TotalFiles:             2,
+				FilesDone:              1,
+				Percentage:             20,
+				BytesPerSecond:         1,
+				EstimatedTimeRemaining: &metav1.Duration{Duration: 80 * time.Second},
+			},
+		},
+		{
+			name: "Given logs without progress -> nil progress",
+			logs: "== Starting backup ==\nrestic backup completed",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProgress(strings.NewReader(tt.logs))
+			if err != nil {
+				t.Fatalf("parseProgress() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProgress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_completedProgress(t *testing.T) {
+	progress := &volsnapmoverv1alpha1.DataMoverProgress{
+		TotalBytes:             100,
+		BytesDone:              90,
+		TotalFiles:             10,
+		FilesDone:              9,
+		Percentage:             90,
+		BytesPerSecond:         10,
+		EstimatedTimeRemaining: &metav1.Duration{Duration: time.Second},
+	}
+	want := &volsnapmoverv1alpha1.DataMoverProgress{
+		TotalBytes:     100,
+		BytesDone:      100,
+		TotalFiles:     10,
+		FilesDone:      10,
+		Percentage:     100,
+		BytesPerSecond: 10,
+	}
+
+	if got := completedProgress(progress); !reflect.DeepEqual(got, want) {
+		t.Errorf("completedProgress() = %+v, want %+v", got, want)
+	}
+	if completedProgress(nil) != nil {
+		t.Errorf("completedProgress(nil) should be nil")
+	}
+}
+
+func TestProgressCollector_Collect(t *testing.T) {
+	newMoverPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"job-name": "volsync-src-sample-vsb-rep-src"},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		}
+	}
+	tests := []struct {
+		name      string
+		collector *ProgressCollector
+		pods      []client.Object
+		wantErr   bool
+	}{
+		{
+			name:      "Given no collector -> no progress",
+			collector: nil,
+			pods:      []client.Object{newMoverPod("mover", corev1.PodRunning)},
+		},
+		{
+			name:      "Given no running mover pod -> no progress",
+			collector: &ProgressCollector{Clientset: fake.NewSimpleClientset()},
+			pods:      []client.Object{newMoverPod("mover", corev1.PodFailed)},
+		},
+		{
+			name:      "Given running mover pod without progress in logs -> no progress",
+			collector: &ProgressCollector{Clientset: fake.NewSimpleClientset()},
+			pods:      []client.Object{newMoverPod("mover", corev1.PodRunning)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.pods...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			got, err := tt.collector.Collect(newContextForTest(tt.name), fakeClient, namespace, "volsync-src-sample-vsb-rep-src")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != nil {
+				t.Errorf("Collect() = %+v, want nil", got)
+			}
+		})
+	}
+}
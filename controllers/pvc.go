@@ -7,6 +7,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"os"
+	"strings"
 
 	"github.com/go-logr/logr"
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
@@ -17,6 +18,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// systemPVCMetadataPrefixes are the label and annotation prefixes not recorded in the source PVC data
+var systemPVCMetadataPrefixes = []string{
+	"pv.kubernetes.io/",
+	"volume.kubernetes.io/",
+	"volume.beta.kubernetes.io/",
+	"kubectl.kubernetes.io/",
+	"velero.io/",
+	"datamover.oadp.openshift.io/",
+}
+
 func (r *VolumeSnapshotBackupReconciler) MirrorPVC(log logr.Logger) (bool, error) {
 	// Get volumesnapshotbackup from cluster
 	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
@@ -57,6 +68,23 @@ func (r *VolumeSnapshotBackupReconciler) MirrorPVC(log logr.Logger) (bool, error
 		return false, nil
 	}
 
+	// the data movers cannot read block volumes, fail the vsb before cloning the volume
+	sourcePVC, err := r.getSourcePVC()
+	if err != nil {
+		return false, err
+	}
+	if sourcePVC != nil {
+		if modeErr := validateVolumeMode(sourcePVC.Spec.VolumeMode); modeErr != nil {
+			r.EventRecorder.Event(&vsb, corev1.EventTypeWarning, "BlockVolumeNotSupported",
+				fmt.Sprintf("cannot back up pvc %s/%s: %v", sourcePVC.Namespace, sourcePVC.Name, modeErr))
+			if err := r.updateVSBStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, r.Client); err != nil {
+				return false, err
+			}
+			r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s as failed", r.req.NamespacedName))
+			return false, errors.New(fmt.Sprintf("cannot back up pvc %s/%s: %v", sourcePVC.Namespace, sourcePVC.Name, modeErr))
+		}
+	}
+
 	// Create a PVC with the above volumesnapshot clone as the source
 
 	pvcClone := &corev1.PersistentVolumeClaim{
@@ -101,7 +129,7 @@ func (r *VolumeSnapshotBackupReconciler) buildPVCClone(pvcClone *corev1.Persiste
 		return err
 	}
 
-	cm, err := GetDataMoverConfigMap(vsb.Spec.ProtectedNamespace, *sourcePVC.Spec.StorageClassName, r.Log, r.Client)
+	config, err := GetDataMoverStorageClassConfig(vsb.Spec.ProtectedNamespace, *sourcePVC.Spec.StorageClassName, r.Log, r.Client)
 	if err != nil {
 		return err
 	}
@@ -128,24 +156,14 @@ func (r *VolumeSnapshotBackupReconciler) buildPVCClone(pvcClone *corev1.Persiste
 			APIGroup: &apiGroup,
 		}
 
-		var pvcCloneStorageClassName string
-		var pvcCloneAccessMove string
-
-		if cm != nil && cm.Data != nil {
-			for spec := range cm.Data {
-				// check for config storageClassName, otherwise use source PVC storageClass
-				if spec == SourceStorageClassName {
-					pvcCloneStorageClassName = cm.Data[SourceStorageClassName]
-					pvcClone.Spec.StorageClassName = &pvcCloneStorageClassName
-				}
-
-				// check for config accessMode, otherwise use source PVC accessMode
-				if spec == SourceAccessMoce {
-					pvcCloneAccessMove = cm.Data[SourceAccessMoce]
-					pvcClone.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(pvcCloneAccessMove)}
-				}
-			}
-
+		// check for config storageClassName and accessMode, otherwise use source PVC values
+		options := backupVolumeOptions(&vsb, config)
+		if len(options.StorageClassName) > 0 {
+			pvcCloneStorageClassName := options.StorageClassName
+			pvcClone.Spec.StorageClassName = &pvcCloneStorageClassName
+		}
+		if len(options.AccessMode) > 0 {
+			pvcClone.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{options.AccessMode}
 		}
 
 		if pvcClone.Spec.AccessModes == nil {
@@ -240,16 +258,16 @@ func (r *VolumeSnapshotBackupReconciler) BindPVCToDummyPod(log logr.Logger) (boo
 		},
 	}
 
-	cm, err := GetDataMoverConfigMap(vsb.Spec.ProtectedNamespace, vsb.Status.SourcePVCData.StorageClassName, r.Log, r.Client)
+	config, err := GetDataMoverStorageClassConfig(vsb.Spec.ProtectedNamespace, vsb.Status.SourcePVCData.StorageClassName, r.Log, r.Client)
 	if err != nil {
 		return false, err
 	}
 
-	if cm != nil && cm.Data != nil && cm.Data[SourceMoverSecurityContext] == "true" {
-		podSC, err := GetPodSecurityContext(vsb.Namespace, vsb.Status.SourcePVCData.Name, r.Client)
-		if err != nil {
-			return false, err
-		}
+	podSC, err := getMoverSecurityContext(backupVolumeOptions(&vsb, config), vsb.Namespace, vsb.Status.SourcePVCData.Name, r.Client)
+	if err != nil {
+		return false, err
+	}
+	if podSC != nil {
 		dp.Spec.SecurityContext = podSC
 	}
 
@@ -321,6 +339,13 @@ func (r *VolumeSnapshotBackupReconciler) getSourcePVC() (*corev1.PersistentVolum
 		vsb.Status.SourcePVCData.StorageClassName = *storageClass
 	}
 
+	// set source PVC metadata in VSB status, restored with the volume
+	vsb.Status.SourcePVCData.AccessModes = pvc.Spec.AccessModes
+	vsb.Status.SourcePVCData.VolumeMode = pvc.Spec.VolumeMode
+	vsb.Status.SourcePVCData.Labels = getUserPVCMetadata(pvc.Labels)
+	vsb.Status.SourcePVCData.Annotations = getUserPVCMetadata(pvc.Annotations)
+	vsb.Status.SourcePVCData.UID = pvc.UID
+
 	// Update VSB status
 	err := r.Status().Update(context.Background(), &vsb)
 	if err != nil {
@@ -372,3 +397,39 @@ func getDataMoverDummyPodImage() string {
 	}
 	return os.Getenv("DATA_MOVER_DUMMY_POD_IMAGE")
 }
+
+// getDataMoverVolSyncImage returns the image of the discovery, manifest, maintenance and repository server pods,
+// which run the restic and rclone binaries of the VolSync release the movers are built against
+func getDataMoverVolSyncImage() string {
+	if os.Getenv("DATA_MOVER_VOLSYNC_IMAGE") == "" {
+		return VolSyncImage
+	}
+	return os.Getenv("DATA_MOVER_VOLSYNC_IMAGE")
+}
+
+// getUserPVCMetadata returns the labels or annotations of a PVC without the ones set by
+// kubernetes, the CSI provisioners and velero, which must not be copied to the restored volume
+func getUserPVCMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	userMetadata := map[string]string{}
+	for key, value := range metadata {
+		system := false
+		for _, prefix := range systemPVCMetadataPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				system = true
+				break
+			}
+		}
+		if !system {
+			userMetadata[key] = value
+		}
+	}
+
+	if len(userMetadata) == 0 {
+		return nil
+	}
+	return userMetadata
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/go-logr/logr"
@@ -48,13 +49,14 @@ func newContextForTest(name string) context.Context {
 
 func TestVolumeSnapshotMoverBackupReconciler_getSourcePVC(t *testing.T) {
 	tests := []struct {
-		name    string
-		vsb     *volsnapmoverv1alpha1.VolumeSnapshotBackup
-		vsc     *snapv1.VolumeSnapshotContent
-		vs      *snapv1.VolumeSnapshot
-		pvc     *corev1.PersistentVolumeClaim
-		want    *corev1.PersistentVolumeClaim
-		wantErr bool
+		name        string
+		vsb         *volsnapmoverv1alpha1.VolumeSnapshotBackup
+		vsc         *snapv1.VolumeSnapshotContent
+		vs          *snapv1.VolumeSnapshot
+		pvc         *corev1.PersistentVolumeClaim
+		want        *corev1.PersistentVolumeClaim
+		wantErr     bool
+		wantPVCData volsnapmoverv1alpha1.PVCData
 	}{
 		// TODO: Add test cases.
 		{
@@ -109,6 +111,80 @@ func TestVolumeSnapshotMoverBackupReconciler_getSourcePVC(t *testing.T) {
 				},
 			},
 			wantErr: false,
+			wantPVCData: volsnapmoverv1alpha1.PVCData{
+				Name:        "sample-pvc",
+				Size:        "10Gi",
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			},
+		},
+		{
+			name: "Given source PVC with metadata, user labels and annotations should be recorded",
+			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					VolumeSnapshotContent: corev1.ObjectReference{
+						Name: "sample-snapshot",
+					},
+					ProtectedNamespace: "foo",
+				},
+			},
+			vsc: &snapv1.VolumeSnapshotContent{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "sample-snapshot",
+				},
+				Spec: snapv1.VolumeSnapshotContentSpec{
+					VolumeSnapshotRef: corev1.ObjectReference{
+						Name:      "sample-vs",
+						Namespace: "bar",
+					},
+				},
+			},
+
+			vs: &snapv1.VolumeSnapshot{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vs",
+					Namespace: "bar",
+				},
+				Spec: snapv1.VolumeSnapshotSpec{
+					Source: snapv1.VolumeSnapshotSource{
+						PersistentVolumeClaimName: &pvcName,
+					},
+				},
+			},
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-pvc",
+					Namespace: "bar",
+					Labels: map[string]string{
+						"app":                   "mysql",
+						"velero.io/backup-name": "backup-1",
+					},
+					Annotations: map[string]string{
+						"example.com/owner":                  "dba",
+						"pv.kubernetes.io/bind-completed":    "yes",
+						"volume.kubernetes.io/selected-node": "node-1",
+					},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+						},
+					},
+				},
+			},
+			wantErr: false,
+			wantPVCData: volsnapmoverv1alpha1.PVCData{
+				Name:        "sample-pvc",
+				Size:        "10Gi",
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Labels:      map[string]string{"app": "mysql"},
+				Annotations: map[string]string{"example.com/owner": "dba"},
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -160,6 +236,130 @@ func TestVolumeSnapshotMoverBackupReconciler_getSourcePVC(t *testing.T) {
 			if !reflect.DeepEqual(got.Spec, Wantpvc.Spec) {
 				t.Errorf("Spec does not match VolumeSnapshotMoverBackupReconciler.getSourcePVC() = %v, want %v", got, Wantpvc)
 			}
+
+			vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+			if err := fakeClient.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
+				t.Fatalf("unable to fetch vsb: %v", err)
+			}
+			if !reflect.DeepEqual(vsb.Status.SourcePVCData, tt.wantPVCData) {
+				t.Errorf("SourcePVCData does not match = %+v, want %+v", vsb.Status.SourcePVCData, tt.wantPVCData)
+			}
 		})
 	}
 }
+
+func TestVolumeSnapshotBackupReconciler_MirrorPVC_blockVolume(t *testing.T) {
+	blockMode := corev1.PersistentVolumeBlock
+	vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vsb",
+			Namespace: "bar",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+			VolumeSnapshotContent: corev1.ObjectReference{
+				Name: "sample-snapshot",
+			},
+			ProtectedNamespace: "foo",
+		},
+	}
+	vsc := &snapv1.VolumeSnapshotContent{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "sample-snapshot",
+		},
+		Spec: snapv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      "sample-vs",
+				Namespace: "bar",
+			},
+		},
+	}
+	vs := &snapv1.VolumeSnapshot{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vs",
+			Namespace: "bar",
+		},
+		Spec: snapv1.VolumeSnapshotSpec{
+			Source: snapv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &pvcName,
+			},
+		},
+	}
+	vscClone := &snapv1.VolumeSnapshotContent{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "sample-snapshot-clone",
+		},
+		Spec: snapv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      "sample-vs-clone",
+				Namespace: "foo",
+			},
+		},
+	}
+	ready := true
+	vsClone := &snapv1.VolumeSnapshot{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vs-clone",
+			Namespace: "foo",
+		},
+		Status: &snapv1.VolumeSnapshotStatus{
+			ReadyToUse: &ready,
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-pvc",
+			Namespace: "bar",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			VolumeMode:  &blockMode,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+				},
+			},
+		},
+	}
+
+	fakeClient, err := getFakeClientFromObjects(vsb, vsc, vs, vscClone, vsClone, pvc)
+	if err != nil {
+		t.Fatalf("error creating fake client, likely programmer error")
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &VolumeSnapshotBackupReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		Log:           logr.Discard(),
+		Context:       newContextForTest("block volume"),
+		EventRecorder: recorder,
+		req: reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: vsb.Namespace,
+				Name:      vsb.Name,
+			},
+		},
+	}
+	if got, err := r.MirrorPVC(r.Log); err == nil || got {
+		t.Fatalf("VolumeSnapshotBackupReconciler.MirrorPVC() = %v, error = %v, want an error", got, err)
+	}
+
+	gotVSB := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := fakeClient.Get(r.Context, r.req.NamespacedName, &gotVSB); err != nil {
+		t.Fatalf("unable to fetch vsb: %v", err)
+	}
+	if gotVSB.Status.Phase != volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed {
+		t.Errorf("vsb phase = %s, want %s", gotVSB.Status.Phase, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed)
+	}
+	clonedPVC := corev1.PersistentVolumeClaim{}
+	if err := fakeClient.Get(r.Context, types.NamespacedName{Namespace: "foo", Name: "sample-snapshot-pvc"}, &clonedPVC); err == nil {
+		t.Errorf("VolumeSnapshotBackupReconciler.MirrorPVC() cloned the block volume")
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "BlockVolumeNotSupported") {
+			t.Errorf("event = %s, want BlockVolumeNotSupported", event)
+		}
+	default:
+		t.Errorf("no warning event recorded for the block volume")
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rcloneDataMover moves data using the VolSync rclone mover
+type rcloneDataMover struct{}
+
+func (m *rcloneDataMover) repositoryKey() string {
+	return RcloneDestPath
+}
+
+func (m *rcloneDataMover) validateSecret(secret *corev1.Secret) error {
+	return ValidateRcloneSecret(secret)
+}
+
+func (m *rcloneDataMover) buildSecret(givensecret *corev1.Secret, secret *corev1.Secret, repo, pruneInterval string, rpolicy *RetainPolicy, scheduleCronExpr string) error {
+	return BuildRcloneSecret(givensecret, secret, repo, scheduleCronExpr)
+}
+
+func (m *rcloneDataMover) buildReplicationSourceSpec(r *VolumeSnapshotBackupReconciler, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim,
+	rcloneSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+
+	if rcloneSecret == nil {
+		return nil, errors.New("nil rcloneSecret in buildReplicationSourceSpec")
+	}
+
+	optionsSpec, err := r.configureRepSourceVolOptions(vsb, pvc, config)
+	if err != nil {
+		return nil, err
+	}
+
+	podSC, err := getMoverSecurityContext(backupVolumeOptions(vsb, config), vsb.Namespace, vsb.Status.SourcePVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	configSection := string(rcloneSecret.Data[RcloneConfigSection])
+	destPath := string(rcloneSecret.Data[RcloneDestPath])
+
+	replicationSourceSpec := r.getReplicationSourceSpec(vsb.Name, pvc.Name, string(rcloneSecret.Data[SnapshotScheduleCron]), nil)
+	replicationSourceSpec.Rclone = &volsyncv1alpha1.ReplicationSourceRcloneSpec{
+		ReplicationSourceVolumeOptions: *optionsSpec,
+		RcloneConfigSection:            &configSection,
+		RcloneDestPath:                 &destPath,
+		RcloneConfig:                   &rcloneSecret.Name,
+		MoverSecurityContext:           podSC,
+		MoverServiceAccount:            &sa.Name,
+	}
+
+	return &replicationSourceSpec, nil
+}
+
+func (m *rcloneDataMover) buildReplicationDestinationSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
+	rcloneSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationSpec, error) {
+
+	if rcloneSecret == nil {
+		return nil, errors.New("nil rcloneSecret in buildReplicationDestinationSpec")
+	}
+
+	capacity, err := getBackedUpCapacity(vsr)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsSpec, err := r.configureRepDestVolOptions(vsr, capacity, config)
+	if err != nil {
+		return nil, err
+	}
+
+	podSC, err := getMoverSecurityContext(restoreVolumeOptions(vsr, config), vsr.Namespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	configSection := string(rcloneSecret.Data[RcloneConfigSection])
+	destPath := string(rcloneSecret.Data[RcloneDestPath])
+
+	replicationDestinationSpec := volsyncv1alpha1.ReplicationDestinationSpec{
+		Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		Rclone: &volsyncv1alpha1.ReplicationDestinationRcloneSpec{
+			ReplicationDestinationVolumeOptions: *optionsSpec,
+			RcloneConfigSection:                 &configSection,
+			RcloneDestPath:                      &destPath,
+			RcloneConfig:                        &rcloneSecret.Name,
+			MoverSecurityContext:                podSC,
+			MoverServiceAccount:                 &sa.Name,
+		},
+	}
+
+	return &replicationDestinationSpec, nil
+}
+
+func (m *rcloneDataMover) isReplicationSourceCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool {
+	return repSourceSyncCompleted(repSource)
+}
+
+func (m *rcloneDataMover) isReplicationDestinationCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool {
+	return repDestSyncCompleted(repDest)
+}
+
+func BuildRcloneSecret(givensecret *corev1.Secret, secret *corev1.Secret, destPath, scheduleCronExpr string) error {
+	if givensecret == nil {
+		return errors.New("nil givensecret in BuildRcloneSecret")
+	}
+	if secret == nil {
+		return errors.New("nil secret in BuildRcloneSecret")
+	}
+
+	// rclone.conf is mounted as is by the VolSync rclone mover
+	secret.Data = map[string][]byte{
+		RcloneConfig:         givensecret.Data[RcloneConfig],
+		RcloneConfigSection:  givensecret.Data[RcloneConfigSection],
+		RcloneDestPath:       []byte(destPath),
+		SnapshotScheduleCron: []byte(scheduleCronExpr),
+	}
+
+	return nil
+}
+
+func ValidateRcloneSecret(rclonesecret *corev1.Secret) error {
+	if rclonesecret == nil {
+		return errors.New("empty rclone secret. Please create a rclone secret")
+	}
+
+	if rclonesecret.Data == nil {
+		return errors.New("secret data is empty")
+	}
+
+	for _, key := range []string{RcloneConfig, RcloneConfigSection, RcloneDestPath} {
+		if !checkByteArrayIsEmpty(rclonesecret.Data[key]) {
+			return errors.New(fmt.Sprintf("%s value cannot be empty", key))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildRcloneSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		givensecret *corev1.Secret
+		secret      *corev1.Secret
+		destPath    string
+		want        map[string][]byte
+		wantErr     bool
+	}{
+		{
+			name:        "Given nil givensecret -> error",
+			givensecret: nil,
+			secret:      &corev1.Secret{},
+			wantErr:     true,
+		},
+		{
+			name: "Given nil secret -> error",
+			givensecret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "rclone-secret",
+					Namespace: namespace,
+				},
+			},
+			secret:  nil,
+			wantErr: true,
+		},
+		{
+			name: "Given valid rclone secret -> rclone config copied with dest path",
+			givensecret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "rclone-secret",
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					RcloneConfig:        []byte("[s3]\ntype = s3\n"),
+					RcloneConfigSection: []byte("s3"),
+					RcloneDestPath:      []byte("bucket/prefix"),
+				},
+			},
+			secret:   &corev1.Secret{},
+			destPath: "bucket/prefix/bar/backup/mysql",
+			want: map[string][]byte{
+				RcloneConfig:         []byte("[s3]\ntype = s3\n"),
+				RcloneConfigSection:  []byte("s3"),
+				RcloneDestPath:       []byte("bucket/prefix/bar/backup/mysql"),
+				SnapshotScheduleCron: []byte(""),
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildRcloneSecret(tt.givensecret, tt.secret, tt.destPath, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BuildRcloneSecret() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.secret.Data, tt.want) {
+				t.Errorf("BuildRcloneSecret() got = %v, want %v", tt.secret.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRcloneSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		wantErr bool
+	}{
+		{
+			name:    "Given nil secret -> error",
+			secret:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "Given secret without data -> error",
+			secret:  &corev1.Secret{},
+			wantErr: true,
+		},
+		{
+			name: "Given secret without dest path -> error",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{
+					RcloneConfig:        []byte("[s3]\ntype = s3\n"),
+					RcloneConfigSection: []byte("s3"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Given valid rclone secret -> no error",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{
+					RcloneConfig:        []byte("[s3]\ntype = s3\n"),
+					RcloneConfigSection: []byte("s3"),
+					RcloneDestPath:      []byte("bucket/prefix"),
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRcloneSecret(tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRcloneSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 
@@ -42,21 +43,27 @@ func (r *VolumeSnapshotRestoreReconciler) CreateReplicationDestination(log logr.
 		},
 	}
 
-	// get restic secret created by controller
-	dmresticSecretName := fmt.Sprintf("%s-secret", vsr.Name)
-	resticSecret := corev1.Secret{}
-	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.NamespacedName.Namespace, Name: dmresticSecretName}, &resticSecret); err != nil {
-		if k8serrors.IsNotFound(err) {
-			return false, nil
+	// get restic secret created by controller, rsync movers do not use one
+	var resticSecret *corev1.Secret
+	if !vsr.Spec.Mover.IsClone() {
+		dmresticSecretName := fmt.Sprintf("%s-secret", vsr.Name)
+		resticSecret = &corev1.Secret{}
+		if err := r.Get(r.Context, types.NamespacedName{Namespace: r.NamespacedName.Namespace, Name: dmresticSecretName}, resticSecret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return false, nil
+			}
+			r.Log.Error(err, fmt.Sprintf("unable to fetch restic secret %s/%s", r.NamespacedName.Namespace, dmresticSecretName))
+			return false, err
 		}
-		r.Log.Error(err, fmt.Sprintf("unable to fetch restic secret %s/%s", r.NamespacedName.Namespace, dmresticSecretName))
-		return false, err
 	}
 
-	cm, err := GetDataMoverConfigMap(vsr.Spec.ProtectedNamespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName, r.Log, r.Client)
+	config, err := GetDataMoverStorageClassConfig(vsr.Spec.ProtectedNamespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName, r.Log, r.Client)
 	if err != nil {
 		return false, err
 	}
+	if err := recordStorageClassConfigUse(config, &vsr, r.Client); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to record the use of datamoverstorageclassconfig %s", config.Name))
+	}
 
 	veleroSA, err := GetVeleroServiceAccount(vsr.Spec.ProtectedNamespace, r.Client)
 	if err != nil {
@@ -66,7 +73,7 @@ func (r *VolumeSnapshotRestoreReconciler) CreateReplicationDestination(log logr.
 	// Create ReplicationDestination in protected namespace
 	op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, repDestination, func() error {
 
-		return r.buildReplicationDestination(repDestination, &vsr, &resticSecret, cm, veleroSA)
+		return r.buildReplicationDestination(repDestination, &vsr, resticSecret, config, veleroSA)
 	})
 	if err != nil {
 		return false, err
@@ -83,7 +90,7 @@ func (r *VolumeSnapshotRestoreReconciler) CreateReplicationDestination(log logr.
 }
 
 func (r *VolumeSnapshotRestoreReconciler) buildReplicationDestination(replicationDestination *volsyncv1alpha1.ReplicationDestination, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
-	resticSecret *corev1.Secret, cm *corev1.ConfigMap, sa *corev1.ServiceAccount) error {
+	resticSecret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) error {
 	if vsr == nil {
 		return errors.New("nil vsr in buildReplicationDestination")
 	}
@@ -92,7 +99,7 @@ func (r *VolumeSnapshotRestoreReconciler) buildReplicationDestination(replicatio
 		return errors.New("nil replicationDestination in buildReplicationDestination")
 	}
 
-	if resticSecret == nil {
+	if resticSecret == nil && !vsr.Spec.Mover.IsClone() {
 		return errors.New("nil resticSecret in buildReplicationDestination")
 	}
 
@@ -100,31 +107,23 @@ func (r *VolumeSnapshotRestoreReconciler) buildReplicationDestination(replicatio
 		return errors.New("nil serviceAccount in buildReplicationDestination")
 	}
 
-	stringCapacity := vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size
-	capacity := resource.MustParse(stringCapacity)
-
-	resticVolOptions, err := r.configureRepDestResticVolOptions(vsr, resticSecret.Name, cm, &capacity, sa)
+	mover, err := getDataMover(vsr.Spec.Mover)
 	if err != nil {
 		return err
 	}
 
-	// build ReplicationDestination
-	replicationDestinationSpec := volsyncv1alpha1.ReplicationDestinationSpec{
-		Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
-			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
-		},
-		Restic: resticVolOptions,
+	if err := validateVolumeMode(vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.VolumeMode); err != nil {
+		return err
 	}
 
-	if replicationDestination.CreationTimestamp.IsZero() {
-		replicationDestination.Spec = replicationDestinationSpec
+	// build ReplicationDestination
+	replicationDestinationSpec, err := mover.buildReplicationDestinationSpec(r, vsr, resticSecret, config, sa)
+	if err != nil {
+		return err
 	}
 
-	// include custom CA if specified
-	resticCustomCA := resticSecret.Data[ResticCustomCA]
-	if len(resticCustomCA) > 0 {
-		replicationDestination.Spec.Restic.CustomCA.SecretName = resticSecret.Name
-		replicationDestination.Spec.Restic.CustomCA.Key = ResticCustomCA
+	if replicationDestination.CreationTimestamp.IsZero() {
+		replicationDestination.Spec = *replicationDestinationSpec
 	}
 
 	return nil
@@ -188,36 +187,55 @@ func (r *VolumeSnapshotRestoreReconciler) SetVSRStatus(log logr.Logger) (bool, e
 			}
 		}
 
-		// for manual trigger, if spec.trigger.manual == status.lastManualSync, sync has completed
-		// VSR is completed
-		if len(repDest.Status.LastManualSync) > 0 && len(repDest.Spec.Trigger.Manual) > 0 {
-			sourceStatus := repDest.Status.LastManualSync
-			sourceSpec := repDest.Spec.Trigger.Manual
-			if sourceStatus == sourceSpec {
-
-				r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s batching status as completed", vsr.Name))
-				err := r.updateVSRBatchingStatus(volsnapmoverv1alpha1.SnapMoverRestoreBatchingCompleted, r.Client)
-				if err != nil {
-					return false, err
-				}
+		mover, err := getDataMover(vsr.Spec.Mover)
+		if err != nil {
+			return false, err
+		}
+
+		// VSR is completed once the mover has finished the sync
+		if mover.isReplicationDestinationCompleted(&repDest) {
 
-				r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as VolSync phase completed", r.req.NamespacedName))
-				err = r.updateVSRStatusPhase(&repDest, volsnapmoverv1alpha1.SnapMoverRestoreVolSyncPhaseCompleted, r.Client)
-				if err != nil {
-					return false, err
+			// the snapshot restored from a shared repository must be the one of the backup
+			if err := verifyRestoredSnapshot(&vsr, &repDest); err != nil {
+				r.EventRecorder.Event(&vsr, corev1.EventTypeWarning, "RestoredSnapshotMismatch", err.Error())
+				if statusErr := r.updateVSRStatusPhase(&repDest, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, r.Client); statusErr != nil {
+					return false, statusErr
 				}
+				r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
+				return false, err
+			}
 
-				processingVSRs--
+			r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s batching status as completed", vsr.Name))
+			err := r.updateVSRBatchingStatus(volsnapmoverv1alpha1.SnapMoverRestoreBatchingCompleted, r.Client)
+			if err != nil {
+				return false, err
+			}
 
-				return true, nil
+			r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as VolSync phase completed", r.req.NamespacedName))
+			err = r.updateVSRStatusPhase(&repDest, volsnapmoverv1alpha1.SnapMoverRestoreVolSyncPhaseCompleted, r.Client)
+			if err != nil {
+				return false, err
 			}
 
+			r.Scheduler.Release(r.req.NamespacedName)
+
+			return true, nil
+
 			// VSR is in progress
 		} else if reconConditionProgress.Status == metav1.ConditionTrue && reconConditionProgress.Reason == volsyncv1alpha1.SynchronizingReasonSync {
 
 			vsr.Status.Phase = volsnapmoverv1alpha1.SnapMoverRestorePhaseInProgress
 			vsr.Status.ReplicationDestinationData.StartTimestamp = repDest.Status.LastSyncStartTime
-			err := r.Status().Update(context.Background(), &vsr)
+
+			// progress is informational, failing to collect it does not fail the restore
+			progress, err := r.ProgressCollector.Collect(r.Context, r.Client, vsr.Spec.ProtectedNamespace, volSyncDestinationJobPrefix+repDest.Name)
+			if err != nil {
+				r.Log.Info(fmt.Sprintf("unable to collect progress of replicationdestination %s/%s: %v", vsr.Spec.ProtectedNamespace, repDest.Name, err))
+			} else if progress != nil {
+				vsr.Status.Progress = progress
+			}
+
+			err = r.Status().Update(context.Background(), &vsr)
 			if err != nil {
 				return false, err
 			}
@@ -235,6 +253,7 @@ func (r *VolumeSnapshotRestoreReconciler) SetVSRStatus(log logr.Logger) (bool, e
 			if err != nil {
 				return false, err
 			}
+			observeVSRPhase(&vsr)
 			r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
 			return false, nil
 		}
@@ -244,7 +263,7 @@ func (r *VolumeSnapshotRestoreReconciler) SetVSRStatus(log logr.Logger) (bool, e
 	return false, nil
 }
 
-func (r *VolumeSnapshotRestoreReconciler) configureRepDestVolOptions(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, capacity *resource.Quantity, cm *corev1.ConfigMap) (*volsyncv1alpha1.ReplicationDestinationVolumeOptions, error) {
+func (r *VolumeSnapshotRestoreReconciler) configureRepDestVolOptions(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, capacity *resource.Quantity, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) (*volsyncv1alpha1.ReplicationDestinationVolumeOptions, error) {
 
 	if vsr == nil {
 		return nil, errors.New("nil vsb in configureRepDestVolOptions")
@@ -254,35 +273,42 @@ func (r *VolumeSnapshotRestoreReconciler) configureRepDestVolOptions(vsr *volsna
 		return nil, errors.New("nil pvc in configureRepDestVolOptions")
 	}
 
+	// map the backed up classes and size with the DataMoverConfig
+	dmConfig, err := GetDataMoverConfig(r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	repDestVolumeSnapshotClass, err := getRestoreVolumeSnapshotClass(vsr, dmConfig, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
 	// we do not want users to change these
 	repDestVolOptions := volsyncv1alpha1.ReplicationDestinationVolumeOptions{
 		CopyMethod:              volsyncv1alpha1.CopyMethodSnapshot,
-		VolumeSnapshotClassName: &vsr.Spec.VolumeSnapshotMoverBackupref.VolumeSnapshotClassName,
-		Capacity:                capacity,
+		VolumeSnapshotClassName: &repDestVolumeSnapshotClass,
+		Capacity:                getRestoreCapacity(capacity, dmConfig),
 	}
 
-	var repDestAccessMode string
-	// use source PVC storageClass as default
-	repDestStorageClass := vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName
-	// use source PVC accessMode as default
+	// use source PVC accessMode as default, backups taken before it was recorded use ReadWriteOnce
 	repDestAccessModeAM := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	if len(vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.AccessModes) > 0 {
+		repDestAccessModeAM = vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.AccessModes
+	}
 
-	// if datamover configmap has data, use these values
-	if cm != nil && cm.Data != nil {
-		for spec := range cm.Data {
-
-			// check for config storageClassName, otherwise use source PVC storageClass
-			if spec == DestinationStorageClassName {
-				repDestStorageClass = cm.Data[DestinationStorageClassName]
-			}
-
-			// check for config accessMode, otherwise use source PVC accessMode
-			if spec == DestinationAccessMoce {
-				repDestAccessMode = cm.Data[DestinationAccessMoce]
-				repDestAccessModeAM = []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(repDestAccessMode)}
-			}
+	// use the storage class config values when set, otherwise the mapped or the source PVC storageClass
+	options := restoreVolumeOptions(vsr, config)
+	repDestStorageClass := options.StorageClassName
+	if len(repDestStorageClass) == 0 {
+		repDestStorageClass, err = getRestoreStorageClass(vsr, dmConfig, r.Client)
+		if err != nil {
+			return nil, err
 		}
 	}
+	if len(options.AccessMode) > 0 {
+		repDestAccessModeAM = []corev1.PersistentVolumeAccessMode{options.AccessMode}
+	}
 
 	repDestVolOptions.StorageClassName = &repDestStorageClass
 	repDestVolOptions.AccessModes = repDestAccessModeAM
@@ -291,7 +317,7 @@ func (r *VolumeSnapshotRestoreReconciler) configureRepDestVolOptions(vsr *volsna
 }
 
 func (r *VolumeSnapshotRestoreReconciler) configureRepDestResticVolOptions(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, resticSecretName string,
-	cm *corev1.ConfigMap, capacity *resource.Quantity, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationResticSpec, error) {
+	config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, capacity *resource.Quantity, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationResticSpec, error) {
 
 	if vsr == nil {
 		return nil, errors.New("nil vsr in configureRepDestResticVolOptions")
@@ -306,52 +332,32 @@ func (r *VolumeSnapshotRestoreReconciler) configureRepDestResticVolOptions(vsr *
 
 	repDestResticVolOptions.MoverServiceAccount = &sa.Name
 
-	var repDestCacheStorageClass string
-	var repDestCaceheStorageClassPt *string
-
-	var repDestCacheAccessMode string
-
-	var repDestCacheCapacity string
-	var repDestCacheCapacityCp resource.Quantity
-
-	if cm != nil && cm.Data != nil {
-		for spec := range cm.Data {
-
-			// check for config cacheStorageClassName, otherwise use source PVC storageClass
-			if spec == DestinationCacheStorageClassName {
-				repDestCacheStorageClass = cm.Data[DestinationCacheStorageClassName]
-				repDestCaceheStorageClassPt = &repDestCacheStorageClass
-
-				repDestResticVolOptions.CacheStorageClassName = repDestCaceheStorageClassPt
-			}
-
-			// check for config cacheAccessMode, otherwise use source PVC accessMode
-			if spec == DestinationCacheAccessMoce {
-				repDestCacheAccessMode = cm.Data[DestinationCacheAccessMoce]
-				repDestResticVolOptions.CacheAccessModes = []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(repDestCacheAccessMode)}
-			}
-
-			// check for config cacheCapacity, otherwise use source PVC capacity
-			if spec == DestinationCacheCapacity {
-				repDestCacheCapacity = cm.Data[DestinationCacheCapacity]
-				repDestCacheCapacityCp = resource.MustParse(repDestCacheCapacity)
+	// restore the snapshot of the backup from a repository shared by the backups of the volume
+	if snapshotTimestamp := vsr.Spec.VolumeSnapshotMoverBackupref.SnapshotTimestamp; snapshotTimestamp != nil {
+		restoreAsOf := snapshotTimestamp.UTC().Format(time.RFC3339)
+		repDestResticVolOptions.RestoreAsOf = &restoreAsOf
+	}
 
-				repDestResticVolOptions.CacheCapacity = &repDestCacheCapacityCp
-			}
+	options := restoreVolumeOptions(vsr, config)
+	if len(options.CacheStorageClassName) > 0 {
+		cacheStorageClassName := options.CacheStorageClassName
+		repDestResticVolOptions.CacheStorageClassName = &cacheStorageClassName
+	}
+	if len(options.CacheAccessMode) > 0 {
+		repDestResticVolOptions.CacheAccessModes = []corev1.PersistentVolumeAccessMode{options.CacheAccessMode}
+	}
+	if options.CacheCapacity != nil {
+		cacheCapacity := options.CacheCapacity.DeepCopy()
+		repDestResticVolOptions.CacheCapacity = &cacheCapacity
+	}
 
-			if spec == DestinationMoverSecurityContext {
-				if cm.Data[DestinationMoverSecurityContext] == "true" {
-					podSC, err := GetPodSecurityContext(vsr.Namespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, r.Client)
-					if err != nil {
-						return nil, err
-					}
-					repDestResticVolOptions.MoverSecurityContext = podSC
-				}
-			}
-		}
+	podSC, err := getMoverSecurityContext(options, vsr.Namespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
 	}
+	repDestResticVolOptions.MoverSecurityContext = podSC
 
-	optionsSpec, err := r.configureRepDestVolOptions(vsr, capacity, cm)
+	optionsSpec, err := r.configureRepDestVolOptions(vsr, capacity, config)
 	if err != nil {
 		return nil, err
 	}
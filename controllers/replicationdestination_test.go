@@ -19,13 +19,16 @@ import (
 )
 
 func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.T) {
+	blockMode := corev1.PersistentVolumeBlock
+	filesystemMode := corev1.PersistentVolumeFilesystem
+	snapshotTimestamp := v1.Unix(1700000000, 0)
 
 	tests := []struct {
 		name           string
 		vsr            *volsnapmoverv1alpha1.VolumeSnapshotRestore
 		repDest        *volsyncv1alpha1.ReplicationDestination
 		secret         *corev1.Secret
-		configMap      *corev1.ConfigMap
+		config         *volsnapmoverv1alpha1.DataMoverStorageClassConfig
 		serviceAcct    *corev1.ServiceAccount
 		Client         client.Client
 		Log            logr.Logger
@@ -40,7 +43,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 	}{
 		// TODO: Add test cases
 		{
-			name: "Given vsr and repdest and secret and configmap, should pass",
+			name: "Given vsr and repdest and secret and storage class config, should pass",
 			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "sample-vsr",
@@ -72,7 +75,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 					Namespace: "test-ns",
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -102,7 +105,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 					Namespace: "test-ns",
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -145,7 +148,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 					Namespace: "test-ns",
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -188,7 +191,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 				},
 			},
 			secret: nil,
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -239,7 +242,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 					ResticCustomCA: []byte("test-secret"),
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -263,9 +266,352 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 				return nil
 			},
 		},
+		{
+			name: "Should restore the backed up access modes",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+						},
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    true,
+			wantErr: false,
+			validate: func(rd *volsyncv1alpha1.ReplicationDestination) error {
+				if len(rd.Spec.Restic.AccessModes) != 1 || rd.Spec.Restic.AccessModes[0] != corev1.ReadWriteMany {
+					return fmt.Errorf("access modes mismatch, got %v, expected %v", rd.Spec.Restic.AccessModes, corev1.ReadWriteMany)
+				}
+				if rd.Spec.Restic.DestinationPVC != nil {
+					return fmt.Errorf("unexpected destination pvc %s", *rd.Spec.Restic.DestinationPVC)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Should let the storage class config override the backed up access modes",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+						},
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+				Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+					Destination: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+						AccessMode: corev1.ReadWriteOnce,
+					},
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    true,
+			wantErr: false,
+			validate: func(rd *volsyncv1alpha1.ReplicationDestination) error {
+				if len(rd.Spec.Restic.AccessModes) != 1 || rd.Spec.Restic.AccessModes[0] != corev1.ReadWriteOnce {
+					return fmt.Errorf("access modes mismatch, got %v, expected %v", rd.Spec.Restic.AccessModes, corev1.ReadWriteOnce)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Should let volsync provision the volume of a backed up pvc with labels",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+							Labels:           map[string]string{"app": "mysql"},
+						},
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    true,
+			wantErr: false,
+			validate: func(rd *volsyncv1alpha1.ReplicationDestination) error {
+				if rd.Spec.Restic.DestinationPVC != nil {
+					return fmt.Errorf("unexpected destination pvc %s", *rd.Spec.Restic.DestinationPVC)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Should let volsync provision the volume of a backed up filesystem pvc",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+							VolumeMode:       &filesystemMode,
+						},
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    true,
+			wantErr: false,
+			validate: func(rd *volsyncv1alpha1.ReplicationDestination) error {
+				if rd.Spec.Restic.DestinationPVC != nil {
+					return fmt.Errorf("unexpected destination pvc %s", *rd.Spec.Restic.DestinationPVC)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Should fail to restore a backed up block pvc with the restic mover",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+							VolumeMode:       &blockMode,
+						},
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "Should restore the snapshot taken at the snapshot timestamp of a shared repository",
+			vsr: &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+					ResticSecretRef: corev1.LocalObjectReference{
+						Name: "secret",
+					},
+					VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+						BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+							Name:             "test-pvc",
+							Size:             "1G",
+							StorageClassName: "test-class",
+						},
+						SnapshotTimestamp: &snapshotTimestamp,
+					},
+					ProtectedNamespace: "test-ns",
+				},
+			},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsr-rep-dest",
+					Namespace: namespace,
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "test-secret",
+					Namespace: "test-ns",
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			want:    true,
+			wantErr: false,
+			validate: func(rd *volsyncv1alpha1.ReplicationDestination) error {
+				if rd.Spec.Restic.RestoreAsOf == nil || *rd.Spec.Restic.RestoreAsOf != "2023-11-14T22:13:20Z" {
+					return fmt.Errorf("restoreAsOf mismatch, got %v, expected %s", rd.Spec.Restic.RestoreAsOf, "2023-11-14T22:13:20Z")
+				}
+				return nil
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// the volume options read the DataMoverConfig
+			if tt.Client == nil {
+				fakeClient, err := getFakeClientFromObjects()
+				if err != nil {
+					t.Fatalf("error creating fake client, likely programmer error")
+				}
+				tt.Client = fakeClient
+			}
 			r := &VolumeSnapshotRestoreReconciler{
 				Client:         tt.Client,
 				Scheme:         tt.Scheme,
@@ -276,7 +622,7 @@ func TestVolumeSnapshotRestoreReconciler_buildReplicationDestination(t *testing.
 				req:            tt.req,
 			}
 
-			err := r.buildReplicationDestination(tt.repDest, tt.vsr, tt.secret, tt.configMap, tt.serviceAcct)
+			err := r.buildReplicationDestination(tt.repDest, tt.vsr, tt.secret, tt.config, tt.serviceAcct)
 			if err != nil && tt.wantErr {
 				t.Logf("buildReplicationDestination() error = %v, wantErr %v", err, tt.wantErr)
 				return
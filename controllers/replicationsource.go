@@ -11,7 +11,6 @@ import (
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -36,6 +35,11 @@ func (r *VolumeSnapshotBackupReconciler) CreateReplicationSource(log logr.Logger
 		return true, nil
 	}
 
+	// the replicationsource of rsync movers is created by the volumesnapshotrestore consuming the cloned PVC
+	if vsb.Spec.Mover.IsClone() {
+		return true, nil
+	}
+
 	// get cloned pvc
 	pvcName := fmt.Sprintf("%s-pvc", vsb.Spec.VolumeSnapshotContent.Name)
 	clonedPVC := corev1.PersistentVolumeClaim{}
@@ -48,10 +52,13 @@ func (r *VolumeSnapshotBackupReconciler) CreateReplicationSource(log logr.Logger
 		return false, nil
 	}
 
-	cm, err := GetDataMoverConfigMap(vsb.Spec.ProtectedNamespace, vsb.Status.SourcePVCData.StorageClassName, r.Log, r.Client)
+	config, err := GetDataMoverStorageClassConfig(vsb.Spec.ProtectedNamespace, vsb.Status.SourcePVCData.StorageClassName, r.Log, r.Client)
 	if err != nil {
 		return false, err
 	}
+	if err := recordStorageClassConfigUse(config, &vsb, r.Client); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to record the use of datamoverstorageclassconfig %s", config.Name))
+	}
 
 	veleroSA, err := GetVeleroServiceAccount(vsb.Spec.ProtectedNamespace, r.Client)
 	if err != nil {
@@ -72,7 +79,7 @@ func (r *VolumeSnapshotBackupReconciler) CreateReplicationSource(log logr.Logger
 	// Create ReplicationSource in OADP namespace
 	op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, repSource, func() error {
 
-		return r.buildReplicationSource(repSource, &vsb, &clonedPVC, cm, veleroSA)
+		return r.buildReplicationSource(repSource, &vsb, &clonedPVC, config, veleroSA)
 	})
 	if err != nil {
 		return false, err
@@ -89,7 +96,7 @@ func (r *VolumeSnapshotBackupReconciler) CreateReplicationSource(log logr.Logger
 }
 
 func (r *VolumeSnapshotBackupReconciler) buildReplicationSource(replicationSource *volsyncv1alpha1.ReplicationSource, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup,
-	pvc *corev1.PersistentVolumeClaim, cm *corev1.ConfigMap, sa *corev1.ServiceAccount) error {
+	pvc *corev1.PersistentVolumeClaim, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) error {
 
 	if vsb == nil {
 		return errors.New("nil vsb in buildReplicationSource")
@@ -115,54 +122,23 @@ func (r *VolumeSnapshotBackupReconciler) buildReplicationSource(replicationSourc
 		return err
 	}
 
-	// fetch the prune interval if specified in the secret
-	var pruneIntervalInt = int64(0)
-	var err error
-	pruneInterval := resticSecret.Data["restic-prune-interval"]
-
-	//fetch the schedule crop expression specified in the secret
-	scheduleCron := ""
-	if len(resticSecret.Data[SnapshotScheduleCron]) > 0 {
-		scheduleCron = string(resticSecret.Data[SnapshotScheduleCron])
+	mover, err := getDataMover(vsb.Spec.Mover)
+	if err != nil {
+		return err
 	}
 
-	if len(pruneInterval) > 0 {
-		pruneIntervalInt, err = strconv.ParseInt(string(pruneInterval), 10, 32)
-		if err != nil {
-			return err
-		}
+	if err := validateVolumeMode(pvc.Spec.VolumeMode); err != nil {
+		return err
 	}
 
-	// fetch the retain policy from restic secret and then pass it on to replication source CR
-	// modify the configureRepSourceResticVolOptions function to
-	rpolicy := RetainPolicy{}
-	rpolicy.hourly = string(resticSecret.Data[SnapshotRetainPolicyHourly])
-	rpolicy.monthly = string(resticSecret.Data[SnapshotRetainPolicyMonthly])
-	rpolicy.daily = string(resticSecret.Data[SnapshotRetainPolicyDaily])
-	rpolicy.weekly = string(resticSecret.Data[SnapshotRetainPolicyWeekly])
-	rpolicy.yearly = string(resticSecret.Data[SnapshotRetainPolicyYearly])
-	rpolicy.within = string(resticSecret.Data[SnapshotRetainPolicyWithin])
-
-	resticVolOptions, err := r.configureRepSourceResticVolOptions(vsb, resticSecret.Name, pvc, cm, sa, rpolicy)
+	// build ReplicationSource
+	replicationSourceSpec, err := mover.buildReplicationSourceSpec(r, vsb, pvc, &resticSecret, config, sa)
 	if err != nil {
 		return err
 	}
 
-	// build ReplicationSource
-	replicationSourceSpec := r.getReplicationSourceSpec(vsb.Name, pvc.Name, scheduleCron, resticVolOptions)
-
-	if pruneIntervalInt != 0 {
-		replicationSourceSpec.Restic.PruneIntervalDays = pointer.Int32(int32(pruneIntervalInt))
-	}
 	if replicationSource.CreationTimestamp.IsZero() {
-		replicationSource.Spec = replicationSourceSpec
-	}
-
-	// pass along a custom CA if specified
-	resticCustomCA := resticSecret.Data[ResticCustomCA]
-	if len(resticCustomCA) > 0 {
-		replicationSource.Spec.Restic.CustomCA.SecretName = resticSecret.Name
-		replicationSource.Spec.Restic.CustomCA.Key = ResticCustomCA
+		replicationSource.Spec = *replicationSourceSpec
 	}
 
 	return nil
@@ -182,7 +158,7 @@ func (r *VolumeSnapshotBackupReconciler) getReplicationSourceSpec(vsbName, pvcNa
 	return volsyncv1alpha1.ReplicationSourceSpec{
 		SourcePVC: pvcName,
 		Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
-			Manual: fmt.Sprintf("%s-trigger", vsbName),
+			Manual: manualTrigger(vsbName, 1),
 		},
 		Restic: volOpts,
 	}
@@ -211,6 +187,7 @@ func (r *VolumeSnapshotBackupReconciler) setStatusFromRepSource(vsb *volsnapmove
 
 	reconConditionCompleted := metav1.Condition{}
 	reconConditionProgress := metav1.Condition{}
+	reconConditionError := metav1.Condition{}
 
 	for i := range repSource.Status.Conditions {
 		if repSource.Status.Conditions[i].Status == metav1.ConditionFalse {
@@ -219,6 +196,9 @@ func (r *VolumeSnapshotBackupReconciler) setStatusFromRepSource(vsb *volsnapmove
 		if repSource.Status.Conditions[i].Reason == volsyncv1alpha1.SynchronizingReasonSync {
 			reconConditionProgress = repSource.Status.Conditions[i]
 		}
+		if repSource.Status.Conditions[i].Reason == volsyncv1alpha1.SynchronizingReasonError {
+			reconConditionError = repSource.Status.Conditions[i]
+		}
 	}
 
 	if (len(repSource.Spec.Trigger.Manual) > 0 && repSourceCompleted && reconConditionCompleted.Type == volsyncv1alpha1.ConditionSynchronizing && vsb.Status.Phase != volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted) ||
@@ -236,7 +216,7 @@ func (r *VolumeSnapshotBackupReconciler) setStatusFromRepSource(vsb *volsnapmove
 			return false, err
 		}
 
-		processingVSBs--
+		r.Scheduler.Release(types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name})
 
 		return true, nil
 
@@ -244,26 +224,46 @@ func (r *VolumeSnapshotBackupReconciler) setStatusFromRepSource(vsb *volsnapmove
 	} else if !repSourceCompleted && reconConditionProgress.Status == metav1.ConditionTrue {
 		vsb.Status.Phase = volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress
 		vsb.Status.ReplicationSourceData.StartTimestamp = repSource.Status.LastSyncStartTime
+		if vsb.Status.Attempts == 0 {
+			vsb.Status.Attempts = 1
+		}
+
+		// progress is informational, failing to collect it does not fail the backup
+		progress, err := r.ProgressCollector.Collect(r.Context, r.Client, vsb.Spec.ProtectedNamespace, volSyncSourceJobPrefix+repSource.Name)
+		if err != nil {
+			r.Log.Info(fmt.Sprintf("unable to collect progress of replicationsource %s/%s: %v", vsb.Spec.ProtectedNamespace, repSource.Name, err))
+		} else if progress != nil {
+			vsb.Status.Progress = progress
+		}
 
 		// Update VSB status as in progress
-		err := r.Status().Update(context.Background(), vsb)
+		err = r.Status().Update(context.Background(), vsb)
 		if err != nil {
 			return false, err
 		}
 		r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s as in progress", r.req.NamespacedName))
 		return false, nil
 
-		//if not in progress or completed, phase failed
-	} else if reconConditionProgress.Reason == volsyncv1alpha1.SynchronizingReasonError {
+		//if not in progress or completed, retry the sync or mark phase failed
+	} else if reconConditionError.Reason == volsyncv1alpha1.SynchronizingReasonError {
+		retrying, err := r.retryRepSource(vsb, repSource, reconConditionError)
+		if err != nil {
+			return false, err
+		}
+		if retrying {
+			return false, nil
+		}
+
 		vsb.Status.Phase = volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed
 		// recording completion timestamp for VSB as failed is a terminal state
 		now := metav1.Now()
 		vsb.Status.CompletionTimestamp = &now
 		// Update VSB status
-		err := r.Status().Update(context.Background(), vsb)
+		err = r.Status().Update(context.Background(), vsb)
 		if err != nil {
 			return false, err
 		}
+		observeVSBPhase(vsb)
 		r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s as failed", r.req.NamespacedName))
 		return false, nil
 	}
@@ -285,26 +285,20 @@ func (r *VolumeSnapshotBackupReconciler) isRepSourceCompleted(vsb *volsnapmoverv
 		return false, err
 	}
 
-	if repSource.Status != nil {
-		// for manual trigger, if spec.trigger.manual == status.lastManualSync, sync has completed
-		if len(repSource.Status.LastManualSync) > 0 && len(repSource.Spec.Trigger.Manual) > 0 {
-			sourceStatus := repSource.Status.LastManualSync
-			sourceSpec := repSource.Spec.Trigger.Manual
-			if sourceStatus == sourceSpec {
-				return true, nil
-			}
-		}
-		// for schedule trigger, LastSyncTime will be set at the end of every replication.
-		if repSource.Spec.Trigger.Schedule != nil && repSource.Status.NextSyncTime != nil && repSource.Status.LastSyncTime != nil {
-			return true, nil
-		}
+	mover, err := getDataMover(vsb.Spec.Mover)
+	if err != nil {
+		return false, err
+	}
+
+	if mover.isReplicationSourceCompleted(&repSource) {
+		return true, nil
 	}
 
 	// ReplicationSource has not yet completed but is not failed
 	return false, nil
 }
 
-func (r *VolumeSnapshotBackupReconciler) configureRepSourceVolOptions(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim, cm *corev1.ConfigMap) (*volsyncv1alpha1.ReplicationSourceVolumeOptions, error) {
+func (r *VolumeSnapshotBackupReconciler) configureRepSourceVolOptions(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) (*volsyncv1alpha1.ReplicationSourceVolumeOptions, error) {
 
 	if vsb == nil {
 		return nil, errors.New("nil vsb in configureRepSourceVolOptions")
@@ -325,23 +319,13 @@ func (r *VolumeSnapshotBackupReconciler) configureRepSourceVolOptions(vsb *volsn
 	// use source PVC accessMode as default
 	repSourceAccessModeAM := pvc.Spec.AccessModes
 
-	var repSourceAccessMode string
-
-	// if datamover configmap has data, use these values
-	if cm != nil && cm.Data != nil {
-		for spec := range cm.Data {
-
-			// check for config storageClassName, otherwise use source PVC storageClass
-			if spec == SourceStorageClassName {
-				repSourceStorageClass = cm.Data[SourceStorageClassName]
-			}
-
-			// check for config accessMode, otherwise use source PVC accessMode
-			if spec == SourceAccessMoce {
-				repSourceAccessMode = cm.Data[SourceAccessMoce]
-				repSourceAccessModeAM = []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(repSourceAccessMode)}
-			}
-		}
+	// use the storage class config values when set
+	options := backupVolumeOptions(vsb, config)
+	if len(options.StorageClassName) > 0 {
+		repSourceStorageClass = options.StorageClassName
+	}
+	if len(options.AccessMode) > 0 {
+		repSourceAccessModeAM = []corev1.PersistentVolumeAccessMode{options.AccessMode}
 	}
 
 	repSrcVolOptions.StorageClassName = &repSourceStorageClass
@@ -422,8 +406,14 @@ func (r *VolumeSnapshotBackupReconciler) configureRepSourceSnapshotRetainPolicy(
 	return &retainPolicy, nil
 }
 
+// isEmptyRetainPolicy returns true if the restic secret set no snapshot retain policy
+func isEmptyRetainPolicy(policy *volsyncv1alpha1.ResticRetainPolicy) bool {
+	return policy.Hourly == nil && policy.Daily == nil && policy.Weekly == nil && policy.Monthly == nil &&
+		policy.Yearly == nil && policy.Within == nil
+}
+
 func (r *VolumeSnapshotBackupReconciler) configureRepSourceResticVolOptions(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, resticSecretName string,
-	pvc *corev1.PersistentVolumeClaim, cm *corev1.ConfigMap, sa *corev1.ServiceAccount, rpolicy RetainPolicy) (*volsyncv1alpha1.ReplicationSourceResticSpec, error) {
+	pvc *corev1.PersistentVolumeClaim, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount, rpolicy RetainPolicy) (*volsyncv1alpha1.ReplicationSourceResticSpec, error) {
 
 	if vsb == nil {
 		return nil, errors.New("nil vsr in configureRepSourceResticVolOptions")
@@ -438,53 +428,26 @@ func (r *VolumeSnapshotBackupReconciler) configureRepSourceResticVolOptions(vsb
 
 	repSrcResticVolOptions.MoverServiceAccount = &sa.Name
 
-	var repSourceCacheStorageClass string
-	var repSourceCaceheStorageClassPt *string
-
-	var repSourceCacheAccessMode string
-
-	var repSourceCacheCapacity string
-	var repSourceCacheCapacityCp resource.Quantity
-
-	if cm != nil && cm.Data != nil {
-		for spec := range cm.Data {
-
-			// check for config cacheStorageClassName, otherwise use source PVC storageClass
-			if spec == SourceCacheStorageClassName {
-				repSourceCacheStorageClass = cm.Data[SourceCacheStorageClassName]
-				repSourceCaceheStorageClassPt = &repSourceCacheStorageClass
-
-				repSrcResticVolOptions.CacheStorageClassName = repSourceCaceheStorageClassPt
-			}
-
-			// check for config cacheAccessMode, otherwise use source PVC accessMode
-			if spec == SourceCacheAccessMoce {
-				repSourceCacheAccessMode = cm.Data[SourceCacheAccessMoce]
-				repSrcResticVolOptions.CacheAccessModes = []corev1.PersistentVolumeAccessMode{corev1.PersistentVolumeAccessMode(repSourceCacheAccessMode)}
-			}
-
-			// check for config cacheCapacity, otherwise use source PVC capacity
-			if spec == SourceCacheCapacity {
-				repSourceCacheCapacity = cm.Data[SourceCacheCapacity]
-				repSourceCacheCapacityCp = resource.MustParse(repSourceCacheCapacity)
-
-				repSrcResticVolOptions.CacheCapacity = &repSourceCacheCapacityCp
-			}
-
-			if spec == SourceMoverSecurityContext {
-				if cm.Data[SourceMoverSecurityContext] == "true" {
-					podSC, err := GetPodSecurityContext(vsb.Namespace, vsb.Status.SourcePVCData.Name, r.Client)
-					if err != nil {
-						return nil, err
-					}
-					repSrcResticVolOptions.MoverSecurityContext = podSC
-				}
-			}
+	options := backupVolumeOptions(vsb, config)
+	if len(options.CacheStorageClassName) > 0 {
+		cacheStorageClassName := options.CacheStorageClassName
+		repSrcResticVolOptions.CacheStorageClassName = &cacheStorageClassName
+	}
+	if len(options.CacheAccessMode) > 0 {
+		repSrcResticVolOptions.CacheAccessModes = []corev1.PersistentVolumeAccessMode{options.CacheAccessMode}
+	}
+	if options.CacheCapacity != nil {
+		cacheCapacity := options.CacheCapacity.DeepCopy()
+		repSrcResticVolOptions.CacheCapacity = &cacheCapacity
+	}
 
-		}
+	podSC, err := getMoverSecurityContext(options, vsb.Namespace, vsb.Status.SourcePVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
 	}
+	repSrcResticVolOptions.MoverSecurityContext = podSC
 
-	optionsSpec, err := r.configureRepSourceVolOptions(vsb, pvc, cm)
+	optionsSpec, err := r.configureRepSourceVolOptions(vsb, pvc, config)
 	if err != nil {
 		return nil, err
 	}
@@ -498,6 +461,12 @@ func (r *VolumeSnapshotBackupReconciler) configureRepSourceResticVolOptions(vsb
 		repSrcResticVolOptions.ReplicationSourceVolumeOptions = *optionsSpec
 	}
 
+	// VolSync keeps only the last snapshot without a retain policy, which would forget the snapshots
+	// of the other backups of a shared repository
+	if vsb.Status.RepositoryLayout == volsnapmoverv1alpha1.RepositoryLayoutVolume && isEmptyRetainPolicy(retainPolicySpec) {
+		retainPolicySpec.Within = pointer.String(volumeRepositoryRetainWithin)
+	}
+
 	if retainPolicySpec != nil {
 		repSrcResticVolOptions.Retain = retainPolicySpec
 	}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -69,13 +70,14 @@ func getFakeClientFromObjectsRepSrc(objs ...client.Object) (client.WithWatch, er
 }
 
 func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T) {
+	blockMode := corev1.PersistentVolumeBlock
 	tests := []struct {
 		name        string
 		vsb         *volsnapmoverv1alpha1.VolumeSnapshotBackup
 		pvc         *corev1.PersistentVolumeClaim
 		repsrc      *volsyncv1alpha1.ReplicationSource
 		secret      *corev1.Secret
-		configMap   *corev1.ConfigMap
+		config      *volsnapmoverv1alpha1.DataMoverStorageClassConfig
 		serviceAcct *corev1.ServiceAccount
 		wantErr     bool
 		validate    func(*volsyncv1alpha1.ReplicationSource) error
@@ -122,7 +124,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					Namespace: namespace,
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -136,6 +138,273 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 			},
 			wantErr: false,
 		},
+		{
+			name: "given volume layout without retain policy -> every snapshot kept",
+			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					VolumeSnapshotContent: corev1.ObjectReference{
+						Name: "sample-snapshot",
+					},
+					ProtectedNamespace: "foo",
+				},
+				Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+					RepositoryLayout: volsnapmoverv1alpha1.RepositoryLayoutVolume,
+				},
+			},
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-pvc",
+					Namespace: namespace,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-secret",
+					Namespace: namespace,
+				},
+				Data: secretData,
+			},
+			repsrc: &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-rep-src",
+					Namespace: namespace,
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			wantErr: false,
+			validate: func(rs *volsyncv1alpha1.ReplicationSource) error {
+				if rs.Spec.Restic.Retain.Within == nil || *rs.Spec.Restic.Retain.Within != volumeRepositoryRetainWithin {
+					return fmt.Errorf("retain within mismatch, got %v, expected %s", rs.Spec.Restic.Retain.Within, volumeRepositoryRetainWithin)
+				}
+				return nil
+			},
+		},
+		{
+			name: "given volume layout with retain policy -> retain policy set",
+			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					VolumeSnapshotContent: corev1.ObjectReference{
+						Name: "sample-snapshot",
+					},
+					ProtectedNamespace: "foo",
+				},
+				Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+					RepositoryLayout: volsnapmoverv1alpha1.RepositoryLayoutVolume,
+				},
+			},
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-pvc",
+					Namespace: namespace,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-secret",
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					"AWS_ACCESS_KEY_ID":       []byte(aws_access_key_id),
+					"AWS_SECRET_ACCESS_KEY":   []byte(aws_secret_access_key),
+					"RESTIC_PASSWORD":         []byte(restic_password),
+					"RESTIC_REPOSITORY":       []byte(restic_repo),
+					SnapshotRetainPolicyDaily: []byte("7"),
+				},
+			},
+			repsrc: &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-rep-src",
+					Namespace: namespace,
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			wantErr: false,
+			validate: func(rs *volsyncv1alpha1.ReplicationSource) error {
+				if rs.Spec.Restic.Retain.Daily == nil || *rs.Spec.Restic.Retain.Daily != 7 {
+					return fmt.Errorf("retain daily mismatch, got %v, expected 7", rs.Spec.Restic.Retain.Daily)
+				}
+				if rs.Spec.Restic.Retain.Within != nil {
+					return fmt.Errorf("retain within mismatch, got %s, expected none", *rs.Spec.Restic.Retain.Within)
+				}
+				return nil
+			},
+		},
+		{
+			name: "given storage class config cache options -> cache options set",
+			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					VolumeSnapshotContent: corev1.ObjectReference{
+						Name: "sample-snapshot",
+					},
+					ProtectedNamespace: "foo",
+				},
+			},
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-pvc",
+					Namespace: namespace,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-secret",
+					Namespace: namespace,
+				},
+				Data: secretData,
+			},
+			repsrc: &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-rep-src",
+					Namespace: namespace,
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "gp2",
+					Namespace: namespace,
+				},
+				Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+					Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+						CacheStorageClassName: "gp3",
+						CacheAccessMode:       corev1.ReadWriteOnce,
+						CacheCapacity:         resource.NewQuantity(2*1024*1024*1024, resource.BinarySI),
+					},
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			wantErr: false,
+			validate: func(rs *volsyncv1alpha1.ReplicationSource) error {
+				if rs.Spec.Restic.CacheStorageClassName == nil || *rs.Spec.Restic.CacheStorageClassName != "gp3" {
+					return fmt.Errorf("cache storage class mismatch, got %v, expected gp3", rs.Spec.Restic.CacheStorageClassName)
+				}
+				if !reflect.DeepEqual(rs.Spec.Restic.CacheAccessModes, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}) {
+					return fmt.Errorf("cache access modes mismatch, got %v", rs.Spec.Restic.CacheAccessModes)
+				}
+				if rs.Spec.Restic.CacheCapacity == nil || rs.Spec.Restic.CacheCapacity.Cmp(resource.MustParse("2Gi")) != 0 {
+					return fmt.Errorf("cache capacity mismatch, got %v, expected 2Gi", rs.Spec.Restic.CacheCapacity)
+				}
+				return nil
+			},
+		},
+		{
+			name: "given block pvc and restic mover -> err",
+			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					VolumeSnapshotContent: corev1.ObjectReference{
+						Name: "sample-snapshot",
+					},
+					ProtectedNamespace: "foo",
+				},
+			},
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-pvc",
+					Namespace: namespace,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					VolumeMode:  &blockMode,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceStorage): resource.MustParse("10Gi"),
+						},
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-secret",
+					Namespace: namespace,
+				},
+				Data: secretData,
+			},
+			repsrc: &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "sample-vsb-rep-src",
+					Namespace: namespace,
+				},
+			},
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "datamover-config",
+					Namespace: namespace,
+				},
+			},
+			serviceAcct: &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "velero",
+					Namespace: namespace,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "given invalid secret -> err",
 			vsb: &volsnapmoverv1alpha1.VolumeSnapshotBackup{
@@ -177,7 +446,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					Namespace: namespace,
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -226,7 +495,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					Namespace: "test-ns",
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -280,7 +549,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					Namespace: "test-ns",
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -330,7 +599,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					Namespace: namespace,
 				},
 			},
-			configMap: &corev1.ConfigMap{
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "datamover-config",
 					Namespace: namespace,
@@ -378,7 +647,7 @@ func TestVolumeSnapshotMoverBackupReconciler_BuildReplicationSource(t *testing.T
 					},
 				},
 			}
-			err = r.buildReplicationSource(tt.repsrc, tt.vsb, tt.pvc, tt.configMap, tt.serviceAcct)
+			err = r.buildReplicationSource(tt.repsrc, tt.vsb, tt.pvc, tt.config, tt.serviceAcct)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("VolumeSnapshotMoverBackupReconciler.buildReplicationSource() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	"github.com/konveyor/volume-snapshot-mover/pkg/manifest"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	VSRepoLabel           = "datamover.oadp.openshift.io/vsrepo"
+	discoveryBackoffLimit = int32(2)
+
+	// every restic repository holds a config file at its root
+	resticRepositoryConfig = "config"
+
+	// directory of the repositories shared by the backups of a volume, <namespace>/volumes/<pvc namespace>/<pvc uid>
+	volumeRepositoryDir = "volumes"
+	// volumeRepositoryRetainWithin keeps every snapshot of a shared repository when no retain policy is set,
+	// they are removed with their volumesnapshotbackups
+	volumeRepositoryRetainWithin = "100y"
+)
+
+// discoveryJobName returns the name of the job listing the repository of a volumesnapshotrepository
+func discoveryJobName(repoName string) string {
+	return fmt.Sprintf("%s-discovery", repoName)
+}
+
+// buildDiscoveryJob returns the job listing the <repo>/<namespace>/<backup>/<pvc> and shared
+// <repo>/<namespace>/volumes/<pvc namespace>/<pvc uid> restic repositories written by CreateVSBResticSecret.
+// The job prints the config file of every repository found, followed by the content of their snapshot manifests
+func buildDiscoveryJob(repo *volsnapmoverv1alpha1.VolumeSnapshotRepository, resticRepo string) (*batchv1.Job, error) {
+	if repo == nil {
+		return nil, errors.New("nil repo in buildDiscoveryJob")
+	}
+
+	remote, env, err := getRepositoryRemote(resticRepo, repo.Spec.ResticSecretRef.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	backoffLimit := discoveryBackoffLimit
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      discoveryJobName(repo.Name),
+			Namespace: repo.Namespace,
+			Labels: map[string]string{
+				VSRepoLabel: repo.Name,
+			},
+			Annotations: map[string]string{
+				DatamoverResticRepository: resticRepo,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						VSRepoLabel: repo.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "discovery",
+							Image: getDataMoverVolSyncImage(),
+							Command: []string{
+								"/bin/sh", "-c",
+								fmt.Sprintf(`rclone lsf --recursive --files-only --max-depth 5 --include "/*/*/*/%[1]s" --include "/*/%[2]s/*/*/%[1]s" "$0" && `+
+									`rclone cat --max-depth 4 --include "/*/*/*%[3]s" --include "/*/%[2]s/*/*%[3]s" "$0"`,
+									resticRepositoryConfig, volumeRepositoryDir, manifest.FileSuffix),
+								remote,
+							},
+							Env: env,
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+		},
+	}
+
+	return job, nil
+}
+
+// getRepositoryRemote translates a restic repository path into its rclone remote, along with the
+// rclone environment read from the restic secret. Only object storage repositories are supported
+func getRepositoryRemote(resticRepo string, secretName string) (string, []corev1.EnvVar, error) {
+	secretEnv := func(name, key string) corev1.EnvVar {
+		optional := true
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+					Optional:             &optional,
+				},
+			},
+		}
+	}
+
+	backend, location, found := strings.Cut(resticRepo, ":")
+	if !found || len(location) == 0 {
+		return "", nil, errors.New(fmt.Sprintf("cannot discover restic repository %q without a backend", resticRepo))
+	}
+
+	switch backend {
+	case "s3":
+		env := []corev1.EnvVar{
+			{Name: "RCLONE_S3_ENV_AUTH", Value: "true"},
+			secretEnv(AWSAccessKey, AWSAccessKey),
+			secretEnv(AWSSecretKey, AWSSecretKey),
+			secretEnv("RCLONE_S3_REGION", AWSDefaultRegion),
+			secretEnv("RCLONE_NO_CHECK_CERTIFICATE", InsecureSkipTLSVerify),
+		}
+
+		// s3:https://host:port/bucket/prefix or s3:host/bucket/prefix
+		var endpoint, path string
+		if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+			u, err := url.Parse(location)
+			if err != nil {
+				return "", nil, errors.New(fmt.Sprintf("cannot parse restic repository %q: %v", resticRepo, err))
+			}
+			endpoint = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+			path = strings.Trim(u.Path, "/")
+		} else {
+			host, rest, _ := strings.Cut(location, "/")
+			if host != "s3.amazonaws.com" {
+				endpoint = fmt.Sprintf("https://%s", host)
+			}
+			path = strings.Trim(rest, "/")
+		}
+		if len(path) == 0 {
+			return "", nil, errors.New(fmt.Sprintf("restic repository %q has no bucket", resticRepo))
+		}
+
+		if len(endpoint) == 0 {
+			env = append(env, corev1.EnvVar{Name: "RCLONE_S3_PROVIDER", Value: "AWS"})
+		} else {
+			env = append(env,
+				corev1.EnvVar{Name: "RCLONE_S3_PROVIDER", Value: "Other"},
+				corev1.EnvVar{Name: "RCLONE_S3_ENDPOINT", Value: endpoint})
+		}
+		return fmt.Sprintf(":s3:%s", path), env, nil
+
+	case "azure":
+		// azure:container:/prefix
+		env := []corev1.EnvVar{
+			secretEnv("RCLONE_AZUREBLOB_ACCOUNT", AzureAccountName),
+			secretEnv("RCLONE_AZUREBLOB_KEY", AzureAccountKey),
+		}
+		return fmt.Sprintf(":azureblob:%s", strings.Trim(strings.Replace(location, ":/", "/", 1), "/")), env, nil
+
+	case "gs":
+		// gs:bucket:/prefix
+		env := []corev1.EnvVar{
+			secretEnv("RCLONE_GCS_SERVICE_ACCOUNT_CREDENTIALS", GoogleApplicationCredentials),
+		}
+		return fmt.Sprintf(":gcs:%s", strings.Trim(strings.Replace(location, ":/", "/", 1), "/")), env, nil
+	}
+
+	return "", nil, errors.New(fmt.Sprintf("cannot discover restic repository %q, the %s backend is not supported", resticRepo, backend))
+}
+
+// parseRepositorySnapshots returns the snapshots listed by the discovery job, one
+// <namespace>/<backup>/<pvc>/config line per restic repository, sorted by name. The
+// snapshots are completed with the manifests found in the output, one JSON line each.
+// Shared <namespace>/volumes/<pvc namespace>/<pvc uid> repositories hold one snapshot
+// per backup, only known from their manifests
+func parseRepositorySnapshots(output string, resticRepo string) []volsnapmoverv1alpha1.RepositorySnapshot {
+	snapshots := []volsnapmoverv1alpha1.RepositorySnapshot{}
+	manifests := map[string]*manifest.Manifest{}
+	sharedManifests := []*manifest.Manifest{}
+	sharedRepositories := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "{") {
+			// manifests of unknown versions are ignored, the snapshot can still be restored from the vsr spec
+			if m, err := manifest.Unmarshal([]byte(line)); err == nil {
+				if m.RepositoryLayout == volsnapmoverv1alpha1.RepositoryLayoutVolume {
+					sharedManifests = append(sharedManifests, m)
+				} else {
+					manifests[m.Repository] = m
+				}
+			}
+			continue
+		}
+
+		parts := strings.Split(line, "/")
+		if len(parts) == 5 && parts[1] == volumeRepositoryDir && parts[4] == resticRepositoryConfig {
+			sharedRepositories[fmt.Sprintf("%s/%s", resticRepo, strings.Join(parts[:4], "/"))] = parts[0]
+			continue
+		}
+		if len(parts) != 4 || parts[3] != resticRepositoryConfig {
+			continue
+		}
+
+		namespace, backupName, pvcName := parts[0], parts[1], parts[2]
+		snapshots = append(snapshots, volsnapmoverv1alpha1.RepositorySnapshot{
+			Name:               fmt.Sprintf("%s-%s", backupName, pvcName),
+			ProtectedNamespace: namespace,
+			BackupName:         backupName,
+			PVCName:            pvcName,
+			ResticRepository:   fmt.Sprintf("%s/%s/%s/%s", resticRepo, namespace, backupName, pvcName),
+		})
+	}
+
+	for i := range snapshots {
+		m, ok := manifests[snapshots[i].ResticRepository]
+		if !ok {
+			continue
+		}
+		pvcData := m.PVCData()
+		snapshots[i].SourcePVCData = &pvcData
+		snapshots[i].VolumeSnapshotClassName = m.VolumeSnapshotClassName
+		snapshots[i].Group = m.Group
+	}
+
+	for _, m := range sharedManifests {
+		namespace, ok := sharedRepositories[m.Repository]
+		if !ok {
+			continue
+		}
+		pvcData := m.PVCData()
+		snapshots = append(snapshots, volsnapmoverv1alpha1.RepositorySnapshot{
+			Name:                    fmt.Sprintf("%s-%s-%s", m.BackupName, m.PVC.Namespace, m.PVC.Name),
+			ProtectedNamespace:      namespace,
+			BackupName:              m.BackupName,
+			PVCName:                 m.PVC.Name,
+			ResticRepository:        m.Repository,
+			SourcePVCData:           &pvcData,
+			VolumeSnapshotClassName: m.VolumeSnapshotClassName,
+			Group:                   m.Group,
+			SnapshotTimestamp:       m.SnapshotTimestamp,
+			SnapshotID:              m.SnapshotID,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	return snapshots
+}
+
+// ResolveRepositorySnapshot fills the restic repository and secret of a vsr referencing a snapshot
+// of a volumesnapshotrepository, before the vsr is validated and started. The backed up PVC data
+// and volumesnapshotclass are taken from the manifest of the snapshot when unset
+func (r *VolumeSnapshotRestoreReconciler) ResolveRepositorySnapshot(log logr.Logger) (bool, error) {
+	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+	if err := r.Get(r.Context, r.req.NamespacedName, &vsr); err != nil {
+		// ignore is not found error
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotrestore %s", r.req.NamespacedName))
+		return false, err
+	}
+
+	// nothing to resolve, or already resolved
+	ref := vsr.Spec.RepositorySnapshotRef
+	if ref == nil || len(vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository) > 0 {
+		return true, nil
+	}
+
+	repo := volsnapmoverv1alpha1.VolumeSnapshotRepository{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: vsr.Spec.ProtectedNamespace, Name: ref.Repository}, &repo); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch volumesnapshotrepository %s/%s", vsr.Spec.ProtectedNamespace, ref.Repository))
+		return false, err
+	}
+
+	// wait for the first discovery of the repository
+	if repo.Status.LastDiscoveryTimestamp == nil {
+		r.Log.Info(fmt.Sprintf("waiting for volumesnapshotrepository %s/%s to be discovered", repo.Namespace, repo.Name))
+		return false, nil
+	}
+
+	snapshot := repo.GetSnapshot(ref.Name)
+	if snapshot == nil {
+		if repo.Status.Phase == volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering {
+			return false, nil
+		}
+
+		if err := r.updateVSRStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, r.Client); err != nil {
+			return false, err
+		}
+		r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
+		return false, errors.New(fmt.Sprintf("snapshot %s not found in volumesnapshotrepository %s/%s", ref.Name, repo.Namespace, repo.Name))
+	}
+
+	backupRef := &vsr.Spec.VolumeSnapshotMoverBackupref
+	backupRef.ResticRepository = snapshot.ResticRepository
+	if len(vsr.Spec.ResticSecretRef.Name) == 0 {
+		vsr.Spec.ResticSecretRef = repo.Spec.ResticSecretRef
+	}
+	if backupRef.SnapshotTimestamp == nil {
+		backupRef.SnapshotTimestamp = snapshot.SnapshotTimestamp
+	}
+	if len(backupRef.SnapshotID) == 0 {
+		backupRef.SnapshotID = snapshot.SnapshotID
+	}
+	if len(backupRef.BackedUpPVCData.Name) == 0 && snapshot.SourcePVCData != nil {
+		backupRef.BackedUpPVCData = *snapshot.SourcePVCData
+	}
+	if len(backupRef.VolumeSnapshotClassName) == 0 {
+		backupRef.VolumeSnapshotClassName = snapshot.VolumeSnapshotClassName
+	}
+
+	// snapshots written before manifests were introduced need the PVC data in the vsr spec
+	if len(backupRef.BackedUpPVCData.Name) == 0 || len(backupRef.BackedUpPVCData.Size) == 0 {
+		if err := r.updateVSRStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, r.Client); err != nil {
+			return false, err
+		}
+		r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
+		return false, errors.New(fmt.Sprintf("snapshot %s of volumesnapshotrepository %s/%s has no manifest, the backed up pvc data must be set", ref.Name, repo.Namespace, repo.Name))
+	}
+	if err := r.Update(r.Context, &vsr); err != nil {
+		return false, err
+	}
+
+	r.Log.Info(fmt.Sprintf("resolved snapshot %s of volumesnapshotrepository %s/%s to %s", ref.Name, repo.Namespace, repo.Name, snapshot.ResticRepository))
+	return true, nil
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestGetRepositoryListing(t *testing.T) {
+	tests := []struct {
+		name         string
+		resticRepo   string
+		wantRemote   string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{
+			name:       "Given aws s3 repository -> s3 remote",
+			resticRepo: "s3:s3.amazonaws.com/bucket/prefix",
+			wantRemote: ":s3:bucket/prefix",
+		},
+		{
+			name:         "Given s3 compatible repository -> s3 remote with endpoint",
+			resticRepo:   "s3:http://minio.minio.svc:9000/bucket",
+			wantRemote:   ":s3:bucket",
+			wantEndpoint: "http://minio.minio.svc:9000",
+		},
+		{
+			name:         "Given s3 repository on another host -> https endpoint",
+			resticRepo:   "s3:s3.example.com/bucket/prefix",
+			wantRemote:   ":s3:bucket/prefix",
+			wantEndpoint: "https://s3.example.com",
+		},
+		{
+			name:       "Given azure repository -> azureblob remote",
+			resticRepo: "azure:container:/prefix",
+			wantRemote: ":azureblob:container/prefix",
+		},
+		{
+			name:       "Given gcs repository -> gcs remote",
+			resticRepo: "gs:bucket:/prefix",
+			wantRemote: ":gcs:bucket/prefix",
+		},
+		{
+			name:       "Given s3 repository without bucket -> error",
+			resticRepo: "s3:s3.amazonaws.com",
+			wantErr:    true,
+		},
+		{
+			name:       "Given sftp repository -> error",
+			resticRepo: "sftp:user@host:/srv/restic",
+			wantErr:    true,
+		},
+		{
+			name:       "Given empty repository -> error",
+			resticRepo: "",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, env, err := getRepositoryRemote(tt.resticRepo, "restic-secret")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRepositoryRemote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if remote != tt.wantRemote {
+				t.Errorf("getRepositoryRemote() remote = %v, want %v", remote, tt.wantRemote)
+			}
+
+			endpoint := ""
+			for _, e := range env {
+				if e.Name == "RCLONE_S3_ENDPOINT" {
+					endpoint = e.Value
+				}
+				if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef.Name != "restic-secret" {
+					t.Errorf("env %s read from secret %s, want restic-secret", e.Name, e.ValueFrom.SecretKeyRef.Name)
+				}
+			}
+			if endpoint != tt.wantEndpoint {
+				t.Errorf("getRepositoryRemote() endpoint = %v, want %v", endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
+
+func TestParseRepositorySnapshots(t *testing.T) {
+	sharedSnapshotTimestamps := []v1.Time{
+		v1.NewTime(time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC).Local()),
+		v1.NewTime(time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC).Local()),
+	}
+	tests := []struct {
+		name   string
+		output string
+		want   []volsnapmoverv1alpha1.RepositorySnapshot
+	}{
+		{
+			name:   "Given empty output -> no snapshots",
+			output: "",
+			want:   []volsnapmoverv1alpha1.RepositorySnapshot{},
+		},
+		{
+			name:   "Given repositories and unrelated files -> sorted snapshots",
+			output: "openshift-adp/backup-2/snapcontent-b-pvc/config\nopenshift-adp/backup-1/snapcontent-a-pvc/config\nopenshift-adp/backup-1/notes/README\ntop/config\n",
+			want: []volsnapmoverv1alpha1.RepositorySnapshot{
+				{
+					Name:               "backup-1-snapcontent-a-pvc",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-1",
+					PVCName:            "snapcontent-a-pvc",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc",
+				},
+				{
+					Name:               "backup-2-snapcontent-b-pvc",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-2",
+					PVCName:            "snapcontent-b-pvc",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/backup-2/snapcontent-b-pvc",
+				},
+			},
+		},
+		{
+			name: "Given repositories and manifests -> snapshots with pvc data",
+			output: "openshift-adp/backup-1/snapcontent-a-pvc/config\nopenshift-adp/backup-2/snapcontent-b-pvc/config\n" +
+				`{"version":"v1","creationTimestamp":null,"backupName":"backup-1","volumeSnapshotBackup":{"name":"vsb-a","namespace":"app"},` +
+				`"repository":"s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc","volumeSnapshotClassName":"csi-snapclass",` +
+				`"pvc":{"name":"data","namespace":"app","size":"10Gi","storageClassName":"gp2"}}` + "\n" +
+				`{"version":"v2","repository":"s3:s3.amazonaws.com/bucket/openshift-adp/backup-2/snapcontent-b-pvc"}` + "\n",
+			want: []volsnapmoverv1alpha1.RepositorySnapshot{
+				{
+					Name:               "backup-1-snapcontent-a-pvc",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-1",
+					PVCName:            "snapcontent-a-pvc",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc",
+					SourcePVCData: &volsnapmoverv1alpha1.PVCData{
+						Name:             "data",
+						Size:             "10Gi",
+						StorageClassName: "gp2",
+					},
+					VolumeSnapshotClassName: "csi-snapclass",
+				},
+				{
+					Name:               "backup-2-snapcontent-b-pvc",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-2",
+					PVCName:            "snapcontent-b-pvc",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/backup-2/snapcontent-b-pvc",
+				},
+			},
+		},
+		{
+			name: "Given shared repository and manifests -> one snapshot per backup",
+			output: "openshift-adp/volumes/app/pvc-uid/config\nopenshift-adp/volumes/app/pvc-uid/data/00/0001\n" +
+				`{"version":"v1","creationTimestamp":null,"backupName":"backup-2","volumeSnapshotBackup":{"name":"vsb-b","namespace":"app"},` +
+				`"repository":"s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid","repositoryLayout":"volume","snapshotTimestamp":"2023-11-15T00:00:00Z",` +
+				`"pvc":{"name":"data","namespace":"app","size":"10Gi","uid":"pvc-uid"}}` + "\n" +
+				`{"version":"v1","creationTimestamp":null,"backupName":"backup-1","volumeSnapshotBackup":{"name":"vsb-a","namespace":"app"},` +
+				`"repository":"s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid","repositoryLayout":"volume","snapshotTimestamp":"2023-11-14T00:00:00Z","snapshotID":"0ff74383",` +
+				`"pvc":{"name":"data","namespace":"app","size":"10Gi","uid":"pvc-uid"}}` + "\n" +
+				`{"version":"v1","creationTimestamp":null,"backupName":"backup-1","volumeSnapshotBackup":{"name":"vsb-c","namespace":"app"},` +
+				`"repository":"s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/deleted-uid","repositoryLayout":"volume","snapshotTimestamp":"2023-11-14T00:00:00Z",` +
+				`"pvc":{"name":"logs","namespace":"app","size":"1Gi","uid":"deleted-uid"}}` + "\n",
+			want: []volsnapmoverv1alpha1.RepositorySnapshot{
+				{
+					Name:               "backup-1-app-data",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-1",
+					PVCName:            "data",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid",
+					SourcePVCData: &volsnapmoverv1alpha1.PVCData{
+						Name: "data",
+						Size: "10Gi",
+						UID:  "pvc-uid",
+					},
+					SnapshotTimestamp: &sharedSnapshotTimestamps[0],
+					SnapshotID:        "0ff74383",
+				},
+				{
+					Name:               "backup-2-app-data",
+					ProtectedNamespace: "openshift-adp",
+					BackupName:         "backup-2",
+					PVCName:            "data",
+					ResticRepository:   "s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid",
+					SourcePVCData: &volsnapmoverv1alpha1.PVCData{
+						Name: "data",
+						Size: "10Gi",
+						UID:  "pvc-uid",
+					},
+					SnapshotTimestamp: &sharedSnapshotTimestamps[1],
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRepositorySnapshots(tt.output, "s3:s3.amazonaws.com/bucket")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRepositorySnapshots() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolumeSnapshotRestoreReconciler_ResolveRepositorySnapshot(t *testing.T) {
+	now := v1.Now()
+	newVSR := func(ref *volsnapmoverv1alpha1.RepositorySnapshotReference) *volsnapmoverv1alpha1.VolumeSnapshotRestore {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "sample-vsr",
+				Namespace: "bar",
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+				RepositorySnapshotRef: ref,
+				ProtectedNamespace:    namespace,
+			},
+		}
+	}
+	newRepo := func(phase volsnapmoverv1alpha1.VolumeSnapshotRepositoryPhase, discovered *v1.Time) *volsnapmoverv1alpha1.VolumeSnapshotRepository {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRepository{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "bsl",
+				Namespace: namespace,
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotRepositorySpec{
+				ResticSecretRef: corev1.LocalObjectReference{Name: "restic-secret"},
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotRepositoryStatus{
+				Phase:                  phase,
+				LastDiscoveryTimestamp: discovered,
+				Snapshots: []volsnapmoverv1alpha1.RepositorySnapshot{
+					{
+						Name:             "backup-1-snapcontent-a-pvc",
+						ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-a-pvc",
+						SourcePVCData: &volsnapmoverv1alpha1.PVCData{
+							Name: "data",
+							Size: "10Gi",
+						},
+						VolumeSnapshotClassName: "csi-snapclass",
+					},
+					{
+						Name:             "backup-0-snapcontent-c-pvc",
+						ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-0/snapcontent-c-pvc",
+					},
+				},
+			},
+		}
+	}
+	ref := &volsnapmoverv1alpha1.RepositorySnapshotReference{Repository: "bsl", Name: "backup-1-snapcontent-a-pvc"}
+	missingRef := &volsnapmoverv1alpha1.RepositorySnapshotReference{Repository: "bsl", Name: "backup-2-snapcontent-b-pvc"}
+	noManifestRef := &volsnapmoverv1alpha1.RepositorySnapshotReference{Repository: "bsl", Name: "backup-0-snapcontent-c-pvc"}
+
+	tests := []struct {
+		name       string
+		objs       []client.Object
+		want       bool
+		wantErr    bool
+		wantRepo   string
+		wantSecret string
+		wantPVC    string
+		wantPhase  volsnapmoverv1alpha1.VolumeSnapshotRestorePhase
+	}{
+		{
+			name:    "Given vsr without repository snapshot ref -> nothing to do",
+			objs:    []client.Object{newVSR(nil)},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "Given missing repository -> error",
+			objs:    []client.Object{newVSR(ref)},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name:    "Given repository not discovered yet -> wait",
+			objs:    []client.Object{newVSR(ref), newRepo(volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering, nil)},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:       "Given discovered snapshot -> repository and secret resolved",
+			objs:       []client.Object{newVSR(ref), newRepo(volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady, &now)},
+			want:       true,
+			wantErr:    false,
+			wantRepo:   "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-a-pvc",
+			wantSecret: "restic-secret",
+			wantPVC:    "data",
+		},
+		{
+			name:      "Given snapshot without manifest and no pvc data -> vsr failed",
+			objs:      []client.Object{newVSR(noManifestRef), newRepo(volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady, &now)},
+			want:      false,
+			wantErr:   true,
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed,
+		},
+		{
+			name:    "Given unknown snapshot while rediscovering -> wait",
+			objs:    []client.Object{newVSR(missingRef), newRepo(volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering, &now)},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:      "Given unknown snapshot -> vsr failed",
+			objs:      []client.Object{newVSR(missingRef), newRepo(volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady, &now)},
+			want:      false,
+			wantErr:   true,
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotRestoreReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(tt.name),
+				EventRecorder: record.NewFakeRecorder(10),
+				req: reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsr"},
+				},
+			}
+			got, err := r.ResolveRepositorySnapshot(r.Log)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveRepositorySnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveRepositorySnapshot() got = %v, want %v", got, tt.want)
+			}
+
+			vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+			if err := fakeClient.Get(r.Context, r.req.NamespacedName, &vsr); err != nil {
+				t.Fatalf("unable to fetch vsr: %v", err)
+			}
+			if vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository != tt.wantRepo {
+				t.Errorf("resticrepository = %v, want %v", vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository, tt.wantRepo)
+			}
+			if vsr.Spec.ResticSecretRef.Name != tt.wantSecret {
+				t.Errorf("resticSecretRef = %v, want %v", vsr.Spec.ResticSecretRef.Name, tt.wantSecret)
+			}
+			if vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name != tt.wantPVC {
+				t.Errorf("backedUpPVCData name = %v, want %v", vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, tt.wantPVC)
+			}
+			if vsr.Status.Phase != tt.wantPhase {
+				t.Errorf("vsr phase = %v, want %v", vsr.Status.Phase, tt.wantPhase)
+			}
+		})
+	}
+}
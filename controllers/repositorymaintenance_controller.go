@@ -0,0 +1,268 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const ConditionMaintained = "Maintained"
+const MaintainedReasonError = "Error"
+const MaintainedReasonComplete = "Complete"
+
+// RepositoryMaintenanceReconciler runs the scheduled restic unlock, prune and check tasks on the repositories of a BSL
+type RepositoryMaintenanceReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Log           logr.Logger
+	EventRecorder record.EventRecorder
+	Clientset     kubernetes.Interface
+	KeyProviders  KeyProviders
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=repositorymaintenances,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=repositorymaintenances/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile runs a maintenance job whenever a task is due, and records the result of every task in its status
+func (r *RepositoryMaintenanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("repomaint", req.NamespacedName)
+
+	m := volsnapmoverv1alpha1.RepositoryMaintenance{}
+	if err := r.Get(ctx, req.NamespacedName, &m); err != nil {
+		// ignore is not found error, the maintenance job and secret are garbage collected with their owner
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch RepositoryMaintenance CR")
+		return ctrl.Result{}, err
+	}
+
+	if !m.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	job := batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: maintenanceJobName(m.Name)}, &job)
+	if k8serrors.IsNotFound(err) {
+		tasks, wait := dueMaintenanceTasks(&m, time.Now())
+		if len(tasks) == 0 {
+			if wait > 0 {
+				return ctrl.Result{RequeueAfter: wait}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.startMaintenance(ctx, &m, tasks)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// wait for the maintenance job to finish, its updates trigger a new reconcile
+	if job.Status.Succeeded == 0 && !isJobFailed(&job) {
+		return ctrl.Result{}, nil
+	}
+
+	now := metav1.Now()
+	tasks := getMaintenanceJobTasks(&job)
+	var results []volsnapmoverv1alpha1.MaintenanceTaskStatus
+	if job.Status.Succeeded > 0 {
+		output, err := r.getMaintenanceOutput(ctx, &job)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		results = parseMaintenanceResults(output, tasks, now)
+	} else {
+		r.Log.Info(fmt.Sprintf("maintenance job %s/%s failed", job.Namespace, job.Name))
+		results = parseMaintenanceResults("", tasks, now)
+		for i := range results {
+			results[i].Message = fmt.Sprintf("maintenance job %s failed", job.Name)
+		}
+	}
+
+	failed := []string{}
+	for _, result := range results {
+		setMaintenanceTaskStatus(&m, result)
+		if len(result.FailedRepositories) > 0 || len(result.Message) > 0 {
+			failed = append(failed, string(result.Task))
+		}
+	}
+
+	if len(failed) > 0 {
+		message := fmt.Sprintf("%s failed on repository %s", strings.Join(failed, ", "), job.Annotations[DatamoverResticRepository])
+		r.EventRecorder.Event(&m, corev1.EventTypeWarning, "RepositoryMaintenanceFailed", message)
+		err = r.setMaintenanceStatus(ctx, &m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, message)
+	} else {
+		err = r.setMaintenanceStatus(ctx, &m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseCompleted, metav1.ConditionTrue, MaintainedReasonComplete,
+			fmt.Sprintf("ran %s on repository %s", job.Annotations[MaintenanceTasksAnnotation], job.Annotations[DatamoverResticRepository]))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// remove the finished job so the next maintenance can run
+	propagation := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, &job, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if _, wait := dueMaintenanceTasks(&m, time.Now()); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// startMaintenance creates the maintenance secret from the restic secret of the BSL, and the job running the given tasks
+func (r *RepositoryMaintenanceReconciler) startMaintenance(ctx context.Context, m *volsnapmoverv1alpha1.RepositoryMaintenance,
+	tasks []volsnapmoverv1alpha1.MaintenanceTask) error {
+
+	resticSecret := corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: m.Spec.ResticSecretRef.Name}, &resticSecret); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch restic secret %s/%s", m.Namespace, m.Spec.ResticSecretRef.Name))
+		return r.setMaintenanceStatus(ctx, m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, err.Error())
+	}
+
+	unwrapped, err := r.KeyProviders.UnwrapResticSecret(&resticSecret)
+	if err != nil {
+		return r.setMaintenanceStatus(ctx, m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, err.Error())
+	}
+	resticSecret = *unwrapped
+
+	if err := validateMaintenanceSecret(&resticSecret); err != nil {
+		return r.setMaintenanceStatus(ctx, m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, err.Error())
+	}
+
+	// compose the repository the same way the volumesnapshotbackups do
+	resticRepo, err := getS3ResticRepository(string(resticSecret.Data[ResticRepository]), &resticSecret)
+	if err != nil {
+		return r.setMaintenanceStatus(ctx, m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, err.Error())
+	}
+
+	secret, err := PopulateResticSecret(maintenanceJobName(m.Name), m.Namespace, RepoMaintenanceLabel)
+	if err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := controllerutil.SetControllerReference(m, secret, r.Scheme); err != nil {
+			return err
+		}
+		return BuildResticSecret(&resticSecret, secret, resticRepo, "", &RetainPolicy{}, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	job, err := buildMaintenanceJob(m, resticRepo, secret, tasks)
+	if err != nil {
+		return r.setMaintenanceStatus(ctx, m, volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed, metav1.ConditionFalse, MaintainedReasonError, err.Error())
+	}
+
+	if err := controllerutil.SetControllerReference(m, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	r.Log.Info(fmt.Sprintf("started maintenance job %s/%s running %s on repository %s", job.Namespace, job.Name,
+		job.Annotations[MaintenanceTasksAnnotation], resticRepo))
+
+	m.Status.Phase = volsnapmoverv1alpha1.SnapMoverMaintenancePhaseRunning
+	return r.Status().Update(ctx, m)
+}
+
+// validateMaintenanceSecret returns an error if the restic secret cannot be used to maintain the repositories
+func validateMaintenanceSecret(resticSecret *corev1.Secret) error {
+	if err := ValidateResticSecret(resticSecret); err != nil {
+		return err
+	}
+
+	if len(resticSecret.Labels[OADPBSLProviderName]) == 0 {
+		return errors.New(fmt.Sprintf("restic secret %s has no %s label", resticSecret.Name, OADPBSLProviderName))
+	}
+
+	if len(resticSecret.Data[ResticRepository]) == 0 {
+		return errors.New(fmt.Sprintf("restic secret %s has no repository", resticSecret.Name))
+	}
+
+	return nil
+}
+
+// setMaintenanceStatus records the result of a maintenance
+func (r *RepositoryMaintenanceReconciler) setMaintenanceStatus(ctx context.Context, m *volsnapmoverv1alpha1.RepositoryMaintenance,
+	phase volsnapmoverv1alpha1.RepositoryMaintenancePhase, status metav1.ConditionStatus, reason, message string) error {
+
+	m.Status.Phase = phase
+	apimeta.SetStatusCondition(&m.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionMaintained,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(ctx, m)
+}
+
+// getMaintenanceOutput returns the logs of the succeeded pod of the maintenance job
+func (r *RepositoryMaintenanceReconciler) getMaintenanceOutput(ctx context.Context, job *batchv1.Job) (string, error) {
+	podList := corev1.PodList{}
+	if err := r.List(ctx, &podList, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+
+		logs, err := r.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		if err != nil {
+			return "", err
+		}
+		return string(logs), nil
+	}
+
+	return "", k8serrors.NewNotFound(corev1.Resource("pods"), fmt.Sprintf("succeeded pod of job %s", job.Name))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RepositoryMaintenanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.RepositoryMaintenance{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRepositoryMaintenanceReconciler_Reconcile(t *testing.T) {
+	lastRun := v1.NewTime(time.Now().Add(-time.Hour))
+	newMaintenance := func(tasks ...volsnapmoverv1alpha1.MaintenanceTaskStatus) *volsnapmoverv1alpha1.RepositoryMaintenance {
+		return &volsnapmoverv1alpha1.RepositoryMaintenance{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "bsl",
+				Namespace: namespace,
+			},
+			Spec: volsnapmoverv1alpha1.RepositoryMaintenanceSpec{
+				ResticSecretRef: corev1.LocalObjectReference{Name: "restic-secret"},
+				UnlockInterval:  &v1.Duration{Duration: time.Minute},
+				PruneInterval:   &v1.Duration{Duration: 24 * time.Hour},
+			},
+			Status: volsnapmoverv1alpha1.RepositoryMaintenanceStatus{
+				Tasks: tasks,
+			},
+		}
+	}
+	newSecret := func(provider string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "restic-secret",
+				Namespace: namespace,
+				Labels:    map[string]string{OADPBSLProviderName: provider},
+			},
+			Data: map[string][]byte{
+				AWSAccessKey:     []byte("access"),
+				AWSSecretKey:     []byte("secret"),
+				ResticPassword:   []byte("password"),
+				ResticRepository: []byte("s3:s3.amazonaws.com/bucket/"),
+			},
+		}
+	}
+	newJob := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "bsl-maintenance",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					DatamoverResticRepository:  "s3:s3.amazonaws.com/bucket",
+					MaintenanceTasksAnnotation: "unlock,prune",
+				},
+			},
+			Status: status,
+		}
+	}
+	succeededPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "bsl-maintenance-abcde",
+			Namespace: namespace,
+			Labels:    map[string]string{"job-name": "bsl-maintenance"},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		objs       []client.Object
+		wantPhase  volsnapmoverv1alpha1.RepositoryMaintenancePhase
+		wantJob    bool
+		wantTasks  string
+		wantSecret bool
+	}{
+		{
+			name:       "Given new maintenance -> maintenance job started with all scheduled tasks",
+			objs:       []client.Object{newMaintenance(), newSecret(AWSProvider)},
+			wantPhase:  volsnapmoverv1alpha1.SnapMoverMaintenancePhaseRunning,
+			wantJob:    true,
+			wantTasks:  "unlock,prune",
+			wantSecret: true,
+		},
+		{
+			name: "Given prune not due -> maintenance job started with unlock only",
+			objs: []client.Object{newMaintenance(
+				volsnapmoverv1alpha1.MaintenanceTaskStatus{Task: volsnapmoverv1alpha1.MaintenanceTaskUnlock, LastRunTimestamp: &lastRun},
+				volsnapmoverv1alpha1.MaintenanceTaskStatus{Task: volsnapmoverv1alpha1.MaintenanceTaskPrune, LastRunTimestamp: &lastRun},
+			), newSecret(AWSProvider)},
+			wantPhase:  volsnapmoverv1alpha1.SnapMoverMaintenancePhaseRunning,
+			wantJob:    true,
+			wantTasks:  "unlock",
+			wantSecret: true,
+		},
+		{
+			name:      "Given maintenance without secret -> failed",
+			objs:      []client.Object{newMaintenance()},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed,
+		},
+		{
+			name:      "Given secret without provider -> failed",
+			objs:      []client.Object{newMaintenance(), newSecret("")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed,
+		},
+		{
+			name:      "Given running maintenance job -> still running",
+			objs:      []client.Object{newMaintenance(), newJob(batchv1.JobStatus{Active: 1})},
+			wantPhase: "",
+			wantJob:   true,
+		},
+		{
+			name:      "Given succeeded maintenance job -> completed and job removed",
+			objs:      []client.Object{newMaintenance(), newJob(batchv1.JobStatus{Succeeded: 1}), succeededPod},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverMaintenancePhaseCompleted,
+		},
+		{
+			name: "Given failed maintenance job -> failed and job removed",
+			objs: []client.Object{newMaintenance(), newJob(batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			})},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverMaintenancePhaseFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &RepositoryMaintenanceReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				EventRecorder: record.NewFakeRecorder(10),
+				Clientset:     fake.NewSimpleClientset(),
+			}
+			ctx := newContextForTest(tt.name)
+			if _, err := r.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: "bsl"},
+			}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			m := volsnapmoverv1alpha1.RepositoryMaintenance{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "bsl"}, &m); err != nil {
+				t.Fatalf("unable to fetch repositorymaintenance: %v", err)
+			}
+			if m.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %v, want %v", m.Status.Phase, tt.wantPhase)
+			}
+
+			job := batchv1.Job{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "bsl-maintenance"}, &job)
+			if tt.wantJob && err != nil {
+				t.Errorf("expected maintenance job, got error %v", err)
+			}
+			if !tt.wantJob && !k8serrors.IsNotFound(err) {
+				t.Errorf("expected no maintenance job, got error %v", err)
+			}
+			if len(tt.wantTasks) > 0 && job.Annotations[MaintenanceTasksAnnotation] != tt.wantTasks {
+				t.Errorf("maintenance job tasks = %v, want %v", job.Annotations[MaintenanceTasksAnnotation], tt.wantTasks)
+			}
+
+			secret := corev1.Secret{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "bsl-maintenance-secret"}, &secret)
+			if tt.wantSecret && (err != nil || string(secret.Data[ResticRepository]) != "s3:s3.amazonaws.com/bucket") {
+				t.Errorf("expected maintenance secret, got %v error %v", secret.Data, err)
+			}
+		})
+	}
+}
+
+func TestDueMaintenanceTasks(t *testing.T) {
+	now := time.Now()
+	lastRun := v1.NewTime(now.Add(-time.Hour))
+	tests := []struct {
+		name      string
+		spec      volsnapmoverv1alpha1.RepositoryMaintenanceSpec
+		tasks     []volsnapmoverv1alpha1.MaintenanceTaskStatus
+		wantTasks []volsnapmoverv1alpha1.MaintenanceTask
+		wantWait  time.Duration
+	}{
+		{
+			name:      "Given no interval -> nothing scheduled",
+			wantTasks: []volsnapmoverv1alpha1.MaintenanceTask{},
+			wantWait:  -1,
+		},
+		{
+			name: "Given tasks that never ran -> all due in order",
+			spec: volsnapmoverv1alpha1.RepositoryMaintenanceSpec{
+				CheckInterval:  &v1.Duration{Duration: time.Hour},
+				UnlockInterval: &v1.Duration{Duration: time.Hour},
+				PruneInterval:  &v1.Duration{Duration: time.Hour},
+			},
+			wantTasks: []volsnapmoverv1alpha1.MaintenanceTask{
+				volsnapmoverv1alpha1.MaintenanceTaskUnlock,
+				volsnapmoverv1alpha1.MaintenanceTaskPrune,
+				volsnapmoverv1alpha1.MaintenanceTaskCheck,
+			},
+			wantWait: -1,
+		},
+		{
+			name: "Given tasks not due -> wait for the earliest",
+			spec: volsnapmoverv1alpha1.RepositoryMaintenanceSpec{
+				UnlockInterval: &v1.Duration{Duration: 2 * time.Hour},
+				CheckInterval:  &v1.Duration{Duration: 3 * time.Hour},
+			},
+			tasks: []volsnapmoverv1alpha1.MaintenanceTaskStatus{
+				{Task: volsnapmoverv1alpha1.MaintenanceTaskUnlock, LastRunTimestamp: &lastRun},
+				{Task: volsnapmoverv1alpha1.MaintenanceTaskCheck, LastRunTimestamp: &lastRun},
+			},
+			wantTasks: []volsnapmoverv1alpha1.MaintenanceTask{},
+			wantWait:  time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &volsnapmoverv1alpha1.RepositoryMaintenance{
+				Spec:   tt.spec,
+				Status: volsnapmoverv1alpha1.RepositoryMaintenanceStatus{Tasks: tt.tasks},
+			}
+			tasks, wait := dueMaintenanceTasks(m, now)
+			if !reflect.DeepEqual(tasks, tt.wantTasks) {
+				t.Errorf("dueMaintenanceTasks() tasks = %v, want %v", tasks, tt.wantTasks)
+			}
+			if wait.Round(time.Second) != tt.wantWait.Round(time.Second) {
+				t.Errorf("dueMaintenanceTasks() wait = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceResults(t *testing.T) {
+	now := v1.Now()
+	output := strings.Join([]string{
+		"unlock ok s3:s3.amazonaws.com/bucket/foo/backup-1/data",
+		"check ok s3:s3.amazonaws.com/bucket/foo/backup-1/data",
+		"unlock ok s3:s3.amazonaws.com/bucket/foo/volumes/bar/uid-1",
+		"check failed s3:s3.amazonaws.com/bucket/foo/volumes/bar/uid-1 Fatal: repository contains errors",
+		"unexpected",
+	}, "\n")
+
+	results := parseMaintenanceResults(output, []volsnapmoverv1alpha1.MaintenanceTask{
+		volsnapmoverv1alpha1.MaintenanceTaskUnlock,
+		volsnapmoverv1alpha1.MaintenanceTaskCheck,
+	}, now)
+	want := []volsnapmoverv1alpha1.MaintenanceTaskStatus{
+		{
+			Task:             volsnapmoverv1alpha1.MaintenanceTaskUnlock,
+			LastRunTimestamp: &now,
+			Repositories:     2,
+		},
+		{
+			Task:               volsnapmoverv1alpha1.MaintenanceTaskCheck,
+			LastRunTimestamp:   &now,
+			Repositories:       2,
+			FailedRepositories: []string{"s3:s3.amazonaws.com/bucket/foo/volumes/bar/uid-1"},
+			Message:            "Fatal: repository contains errors",
+		},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("parseMaintenanceResults() = %v, want %v", results, want)
+	}
+}
+
+func TestBuildMaintenanceJob(t *testing.T) {
+	m := &volsnapmoverv1alpha1.RepositoryMaintenance{
+		ObjectMeta: v1.ObjectMeta{Name: "bsl", Namespace: namespace},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "bsl-maintenance-secret", Namespace: namespace},
+		Data:       map[string][]byte{ResticCustomCA: []byte("ca")},
+	}
+	tasks := []volsnapmoverv1alpha1.MaintenanceTask{volsnapmoverv1alpha1.MaintenanceTaskUnlock, volsnapmoverv1alpha1.MaintenanceTaskCheck}
+
+	if _, err := buildMaintenanceJob(m, "/srv/restic", secret, tasks); err == nil {
+		t.Errorf("buildMaintenanceJob() expected error for local repository")
+	}
+	if _, err := buildMaintenanceJob(m, "gs:bucket:/prefix", secret, nil); err == nil {
+		t.Errorf("buildMaintenanceJob() expected error without tasks")
+	}
+
+	job, err := buildMaintenanceJob(m, "gs:bucket:/prefix", secret, tasks)
+	if err != nil {
+		t.Fatalf("buildMaintenanceJob() error = %v", err)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if got := container.Command[len(container.Command)-1]; got != "unlock check" {
+		t.Errorf("buildMaintenanceJob() tasks argument = %v", got)
+	}
+	if !strings.Contains(container.Command[2], `repository="$1/${config%/config}"`) {
+		t.Errorf("buildMaintenanceJob() script = %v", container.Command[2])
+	}
+	if container.EnvFrom[0].SecretRef.Name != secret.Name {
+		t.Errorf("buildMaintenanceJob() envFrom = %v", container.EnvFrom)
+	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env[GoogleApplicationCredentials] != "/credentials/GOOGLE_APPLICATION_CREDENTIALS" || env["RESTIC_CACERT"] != "/credentials/RESTIC_CUSTOM_CA" {
+		t.Errorf("buildMaintenanceJob() env = %v", env)
+	}
+}
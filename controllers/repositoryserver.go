@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	RepositoryServerLabel = "datamover.oadp.openshift.io/repository-server"
+	repositoryServerPort  = int32(8000)
+
+	// the PVC of local repositories is mounted in the repository server
+	repositoryServerDataDir = "/data"
+	// the sftp known hosts are read from the mounted restic secret
+	repositoryServerCredentialsDir = "/credentials"
+)
+
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch
+
+// repositoryServerName returns the name of the deployment and service serving the repository of a restic secret
+func repositoryServerName(secretName string) string {
+	return fmt.Sprintf("%s-repository-server", secretName)
+}
+
+// isRepositoryServerProvider returns whether the repositories of a provider are served to the movers by a
+// repository server, as the volsync mover pods cannot mount a PVC or an ssh key
+func isRepositoryServerProvider(provider string) bool {
+	return provider == LocalProvider || provider == SFTPProvider
+}
+
+// getRepositoryServerURL returns the rest repository served by the repository server of a restic secret
+func getRepositoryServerURL(secret *corev1.Secret) string {
+	return fmt.Sprintf("rest:http://%s.%s.svc:%d", repositoryServerName(secret.Name), secret.Namespace, repositoryServerPort)
+}
+
+// rebaseRepositoryServerPath moves a repository path written through the repository server of another
+// restic secret or cluster onto the given repository server
+func rebaseRepositoryServerPath(resticRepo string, serverURL string) (string, error) {
+	location, found := strings.CutPrefix(resticRepo, "rest:")
+	if !found {
+		return "", errors.New(fmt.Sprintf("restic repository %q was not written through a repository server", resticRepo))
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("cannot parse restic repository %q: %v", resticRepo, err))
+	}
+
+	return serverURL + u.Path, nil
+}
+
+// getRESTRepository returns the rest repository with the RESTIC_REST_USERNAME and RESTIC_REST_PASSWORD of the
+// restic secret, restic only reads the credentials of a rest server from its url in the volsync movers
+func getRESTRepository(resticRepo string, secret *corev1.Secret) (string, error) {
+	location, found := strings.CutPrefix(resticRepo, "rest:")
+	if !found {
+		return "", errors.New(fmt.Sprintf("restic repository %q is not a rest repository", resticRepo))
+	}
+
+	u, err := url.Parse(location)
+	if err != nil || len(u.Host) == 0 {
+		return "", errors.New(fmt.Sprintf("cannot parse restic repository %q", resticRepo))
+	}
+
+	u.User = nil
+	if len(secret.Data[ResticRestUsername]) > 0 {
+		u.User = url.UserPassword(string(secret.Data[ResticRestUsername]), string(secret.Data[ResticRestPassword]))
+	}
+
+	return fmt.Sprintf("rest:%s", u.String()), nil
+}
+
+// parseSFTPRepository returns the user, host, port and path of a sftp:user@host:/path or
+// sftp://user@host:port//path restic repository
+func parseSFTPRepository(resticRepo string) (string, string, string, string, error) {
+	location, found := strings.CutPrefix(resticRepo, "sftp:")
+	if !found || len(location) == 0 {
+		return "", "", "", "", errors.New(fmt.Sprintf("restic repository %q is not a sftp repository", resticRepo))
+	}
+
+	var user, host, port, path string
+	if strings.HasPrefix(location, "//") {
+		u, err := url.Parse(fmt.Sprintf("sftp:%s", location))
+		if err != nil {
+			return "", "", "", "", errors.New(fmt.Sprintf("cannot parse restic repository %q: %v", resticRepo, err))
+		}
+		user, host, port = u.User.Username(), u.Hostname(), u.Port()
+		// the path of the url form is relative unless it starts with a second slash
+		path = strings.TrimPrefix(u.Path, "/")
+	} else {
+		var address string
+		address, path, found = strings.Cut(location, ":")
+		if !found {
+			return "", "", "", "", errors.New(fmt.Sprintf("restic repository %q has no path", resticRepo))
+		}
+		user, host, found = strings.Cut(address, "@")
+		if !found {
+			user, host = "", address
+		}
+	}
+
+	if len(host) == 0 || len(path) == 0 {
+		return "", "", "", "", errors.New(fmt.Sprintf("restic repository %q has no host or path", resticRepo))
+	}
+	return user, host, port, path, nil
+}
+
+// buildRepositoryServer returns the deployment and service serving the local or sftp repository of a restic
+// secret as a rest repository with rclone
+func buildRepositoryServer(secret *corev1.Secret) (*appsv1.Deployment, *corev1.Service, error) {
+	if secret == nil {
+		return nil, nil, errors.New("nil secret in buildRepositoryServer")
+	}
+
+	name := repositoryServerName(secret.Name)
+	labels := map[string]string{
+		RepositoryServerLabel: secret.Name,
+	}
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	var remote string
+	env := []corev1.EnvVar{}
+	mounts := []corev1.VolumeMount{}
+	volumes := []corev1.Volume{}
+	switch provider := secret.Labels[OADPBSLProviderName]; provider {
+	case LocalProvider:
+		remote = repositoryServerDataDir
+		mounts = append(mounts, corev1.VolumeMount{Name: "data", MountPath: repositoryServerDataDir})
+		volumes = append(volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: string(secret.Data[ResticRepositoryPVC])},
+			},
+		})
+
+	case SFTPProvider:
+		user, host, port, path, err := parseSFTPRepository(string(secret.Data[ResticRepository]))
+		if err != nil {
+			return nil, nil, err
+		}
+		remote = fmt.Sprintf(":sftp:%s", path)
+		env = append(env,
+			corev1.EnvVar{Name: "RCLONE_SFTP_HOST", Value: host},
+			corev1.EnvVar{Name: "RCLONE_SFTP_USER", Value: user},
+			secretEnv("RCLONE_SFTP_KEY_PEM", SFTPPrivateKey))
+		if len(port) > 0 {
+			env = append(env, corev1.EnvVar{Name: "RCLONE_SFTP_PORT", Value: port})
+		}
+		if len(secret.Data[SFTPKnownHosts]) > 0 {
+			env = append(env, corev1.EnvVar{Name: "RCLONE_SFTP_KNOWN_HOSTS_FILE", Value: filepath.Join(repositoryServerCredentialsDir, SFTPKnownHosts)})
+			mounts = append(mounts, corev1.VolumeMount{Name: "credentials", MountPath: repositoryServerCredentialsDir, ReadOnly: true})
+			volumes = append(volumes, corev1.Volume{
+				Name: "credentials",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: secret.Name, DefaultMode: pointer.Int32(0400)},
+				},
+			})
+		}
+
+	default:
+		return nil, nil, errors.New(fmt.Sprintf("the repositories of provider %q are not served by a repository server", provider))
+	}
+
+	command := []string{"rclone", "serve", "restic", remote, "--addr", fmt.Sprintf(":%d", repositoryServerPort)}
+	// the credentials are expanded from the env, only set when the secret holds them
+	if len(secret.Data[ResticRestUsername]) > 0 {
+		env = append(env, secretEnv("RESTIC_REST_USERNAME", ResticRestUsername), secretEnv("RESTIC_REST_PASSWORD", ResticRestPassword))
+		command = append(command, "--user", "$(RESTIC_REST_USERNAME)", "--pass", "$(RESTIC_REST_PASSWORD)")
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: secret.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(1),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			// the PVC of a local repository is usually ReadWriteOnce
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:         "repository-server",
+							Image:        getDataMoverVolSyncImage(),
+							Command:      command,
+							Env:          env,
+							VolumeMounts: mounts,
+							Ports: []corev1.ContainerPort{
+								{Name: "rest", ContainerPort: repositoryServerPort, Protocol: corev1.ProtocolTCP},
+							},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: secret.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "rest", Port: repositoryServerPort, TargetPort: intstr.FromInt(int(repositoryServerPort)), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	return deployment, service, nil
+}
+
+// ensureRepositoryServer creates or updates the repository server of a restic secret, which is garbage
+// collected with the secret
+func ensureRepositoryServer(ctx context.Context, c client.Client, scheme *runtime.Scheme, secret *corev1.Secret) error {
+	deployment, service, err := buildRepositoryServer(secret)
+	if err != nil {
+		return err
+	}
+
+	desiredPodSpec := deployment.Spec.Template.Spec
+	_, err = controllerutil.CreateOrUpdate(ctx, c, deployment, func() error {
+		if deployment.CreationTimestamp.IsZero() {
+			deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: deployment.Labels}
+			deployment.Spec.Template.Labels = deployment.Labels
+			deployment.Spec.Template.Spec = desiredPodSpec
+		}
+		// only update the fields set from the restic secret, leaving the defaulted ones untouched
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Image = desiredPodSpec.Containers[0].Image
+		container.Command = desiredPodSpec.Containers[0].Command
+		container.Env = desiredPodSpec.Containers[0].Env
+		container.VolumeMounts = desiredPodSpec.Containers[0].VolumeMounts
+		deployment.Spec.Template.Spec.Volumes = desiredPodSpec.Volumes
+		return controllerutil.SetOwnerReference(secret, deployment, scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	desiredServiceSpec := service.Spec
+	_, err = controllerutil.CreateOrUpdate(ctx, c, service, func() error {
+		service.Spec.Selector = desiredServiceSpec.Selector
+		service.Spec.Ports = desiredServiceSpec.Ports
+		return controllerutil.SetOwnerReference(secret, service, scheme)
+	})
+	return err
+}
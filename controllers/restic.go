@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/go-logr/logr"
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -24,6 +28,13 @@ const (
 	SnapMoverSourcePVCSize    = "datamover.io/source-pvc-size"
 )
 
+var (
+	// resticSnapshotSavedRegex matches the snapshot saved by restic backup in the mover logs
+	resticSnapshotSavedRegex = regexp.MustCompile(`(?m)^\s*snapshot ([0-9a-f]+) saved`)
+	// resticSnapshotRestoredRegex matches the snapshot restored by restic restore in the mover logs
+	resticSnapshotRestoredRegex = regexp.MustCompile(`(?m)^\s*restoring <Snapshot ([0-9a-f]+) of`)
+)
+
 type RetainPolicy struct {
 	daily   string
 	weekly  string
@@ -51,6 +62,11 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 		return true, nil
 	}
 
+	// rsync movers do not write to a repository
+	if vsb.Spec.Mover.IsClone() {
+		return true, nil
+	}
+
 	// get cloned pvc
 	pvcName := fmt.Sprintf("%s-pvc", vsb.Spec.VolumeSnapshotContent.Name)
 	pvc := corev1.PersistentVolumeClaim{}
@@ -67,25 +83,40 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 
 	// get restic secret from user
 	resticSecret := corev1.Secret{}
-	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.NamespacedName.Namespace, Name: credName}, &resticSecret); err != nil {
-		r.Log.Error(err, fmt.Sprintf("unable to fetch restic  secret %s/%s", r.NamespacedName.Namespace, credName))
+	if err := r.Get(r.Context, getResticSecretKey(vsb.Spec.ProtectedNamespace, vsb.Spec.ResticSecretRef), &resticSecret); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch restic  secret %s/%s", vsb.Spec.ProtectedNamespace, credName))
+		return false, err
+	}
+
+	// restic passwords wrapped by a key provider are unwrapped before building the mover secret
+	unwrapped, err := r.KeyProviders.UnwrapResticSecret(&resticSecret)
+	if err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to unwrap restic secret %s/%s", r.NamespacedName.Namespace, credName))
+		return false, err
+	}
+	resticSecret = *unwrapped
+
+	mover, err := getDataMover(vsb.Spec.Mover)
+	if err != nil {
 		return false, err
 	}
 
-	err := ValidateResticSecret(&resticSecret)
+	err = mover.validateSecret(&resticSecret)
 	if err != nil {
 		r.Log.Error(err, fmt.Sprintf("restic secret %s/%s is malformed", r.NamespacedName.Namespace, credName))
 		return false, err
 	}
 
+	var resticRepoValue = ""
 	var pruneInterval = ""
 	var scheduleCronExpr = ""
+	layout := volsnapmoverv1alpha1.RepositoryLayoutBackup
 	rpolicy := RetainPolicy{}
 	for key, val := range resticSecret.Data {
 		stringVal := string(val)
-		if key == ResticRepository {
-			// if trailing '/' in user-created Restic repo, remove it
-			ResticRepoValue = strings.TrimRight(stringVal, "/")
+		if key == mover.repositoryKey() {
+			// if trailing '/' in user-created repo, remove it
+			resticRepoValue = strings.TrimRight(stringVal, "/")
 		}
 		if key == ResticPruneInterval {
 			pruneInterval = stringVal
@@ -93,6 +124,9 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 		if key == SnapshotScheduleCron {
 			scheduleCronExpr = stringVal
 		}
+		if key == ResticRepositoryLayout && len(stringVal) > 0 {
+			layout = volsnapmoverv1alpha1.RepositoryLayout(stringVal)
+		}
 		if key == SnapshotRetainPolicyMonthly {
 			rpolicy.monthly = stringVal
 		}
@@ -113,7 +147,25 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 		}
 	}
 
-	resticrepo := fmt.Sprintf("%s/%s/%s/%s", ResticRepoValue, pvc.Namespace, vsb.Labels["velero.io/backup-name"], pvc.Name)
+	if mover.repositoryKey() == ResticRepository {
+		resticRepoValue, err = getS3ResticRepository(resticRepoValue, &resticSecret)
+		if err != nil {
+			return false, err
+		}
+
+		// local and sftp repositories are written through the repository server of the restic secret
+		if isRepositoryServerProvider(resticSecret.Labels[OADPBSLProviderName]) {
+			if err := ensureRepositoryServer(r.Context, r.Client, r.Scheme, &resticSecret); err != nil {
+				return false, err
+			}
+			resticRepoValue = getRepositoryServerURL(&resticSecret)
+		}
+	}
+
+	resticrepo, err := getResticRepositoryPath(resticRepoValue, layout, &vsb, &pvc)
+	if err != nil {
+		return false, err
+	}
 
 	rsecret, err := PopulateResticSecret(vsb.Name, vsb.Spec.ProtectedNamespace, VSBLabel)
 	if err != nil {
@@ -122,15 +174,19 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 
 	// Create Restic secret in OADP namespace
 	op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, rsecret, func() error {
-
-		return BuildResticSecret(&resticSecret, rsecret, resticrepo, pruneInterval, &rpolicy, scheduleCronExpr)
+		givensecret, err := resolveResticSecret(r.Context, r.CredentialResolver, &resticSecret, rsecret, resticrepo, time.Now())
+		if err != nil {
+			return err
+		}
+		return mover.buildSecret(givensecret, rsecret, resticrepo, pruneInterval, &rpolicy, scheduleCronExpr)
 	})
 	if err != nil {
 		return false, err
 	}
 
-	// set created Restic repo to VSB status
-	vsb.Status.ResticRepository = string(rsecret.Data[ResticRepository])
+	// set created repo to VSB status, without the rest server credentials of the restic secret
+	vsb.Status.ResticRepository = resticrepo
+	vsb.Status.RepositoryLayout = layout
 
 	// Update VSB status
 	err = r.Status().Update(context.Background(), &vsb)
@@ -148,6 +204,91 @@ func (r *VolumeSnapshotBackupReconciler) CreateVSBResticSecret(log logr.Logger)
 	return true, nil
 }
 
+// getResticSecretKey returns the restic secret of the BSL read by the mover of a vsb or vsr, in the protected
+// namespace where its mover secret is written
+func getResticSecretKey(protectedNamespace string, ref corev1.LocalObjectReference) types.NamespacedName {
+	return types.NamespacedName{Namespace: protectedNamespace, Name: ref.Name}
+}
+
+// getResticRepositoryPath returns the restic repository the volume of the vsb is written to. The backup layout
+// creates a repository per backup, the volume layout shares one repository between the backups of the source
+// PVC so they are deduplicated. Only the restic mover can restore a given snapshot of a shared repository
+func getResticRepositoryPath(repo string, layout volsnapmoverv1alpha1.RepositoryLayout, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup,
+	pvc *corev1.PersistentVolumeClaim) (string, error) {
+
+	switch layout {
+	case volsnapmoverv1alpha1.RepositoryLayoutBackup:
+		return fmt.Sprintf("%s/%s/%s/%s", repo, pvc.Namespace, vsb.Labels[backupLabel], pvc.Name), nil
+
+	case volsnapmoverv1alpha1.RepositoryLayoutVolume:
+		if vsb.Spec.Mover != "" && vsb.Spec.Mover != volsnapmoverv1alpha1.ResticDataMover {
+			return "", errors.New(fmt.Sprintf("restic repository layout %s is not supported by the %s mover", layout, vsb.Spec.Mover))
+		}
+		if len(vsb.Status.SourcePVCData.UID) == 0 {
+			return "", errors.New(fmt.Sprintf("source PVC uid of vsb %s/%s is unknown", vsb.Namespace, vsb.Name))
+		}
+		return fmt.Sprintf("%s/%s/%s/%s/%s", repo, pvc.Namespace, volumeRepositoryDir, vsb.Namespace, vsb.Status.SourcePVCData.UID), nil
+	}
+
+	return "", errors.New(fmt.Sprintf("unknown restic repository layout %q", layout))
+}
+
+// getSnapshotTimestamp returns the time the restic snapshot of a vsb written to a shared repository
+// was taken, nil for the backup layout where the repository holds a single snapshot
+func getSnapshotTimestamp(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) *metav1.Time {
+	if vsb.Status.RepositoryLayout != volsnapmoverv1alpha1.RepositoryLayoutVolume {
+		return nil
+	}
+
+	return vsb.Status.ReplicationSourceData.CompletionTimestamp
+}
+
+// getSnapshotID returns the id of the restic snapshot of a vsb written to a shared repository,
+// empty for the backup layout where the repository holds a single snapshot
+func getSnapshotID(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup) string {
+	if vsb.Status.RepositoryLayout != volsnapmoverv1alpha1.RepositoryLayoutVolume {
+		return ""
+	}
+
+	return vsb.Status.ReplicationSourceData.SnapshotID
+}
+
+// getResticSnapshotID returns the id of the restic snapshot found by the regex in the logs VolSync keeps
+// in the status of a successful mover, empty if it was not logged
+func getResticSnapshotID(status *volsyncv1alpha1.MoverStatus, regex *regexp.Regexp) string {
+	if status == nil {
+		return ""
+	}
+
+	match := regex.FindStringSubmatch(status.Logs)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// verifyRestoredSnapshot returns an error if the replicationdestination of a vsr restored another snapshot of a
+// shared repository than the one of the backup, forgotten since or selected instead of a concurrent backup
+func verifyRestoredSnapshot(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, repDest *volsyncv1alpha1.ReplicationDestination) error {
+	want := vsr.Spec.VolumeSnapshotMoverBackupref.SnapshotID
+	if len(want) == 0 {
+		return nil
+	}
+
+	var got string
+	if repDest.Status != nil {
+		got = getResticSnapshotID(repDest.Status.LatestMoverStatus, resticSnapshotRestoredRegex)
+	}
+	if len(got) == 0 {
+		return errors.New(fmt.Sprintf("replicationdestination %s/%s did not log the restored snapshot, expected snapshot %s", repDest.Namespace, repDest.Name, want))
+	}
+	if got != want {
+		return errors.New(fmt.Sprintf("replicationdestination %s/%s restored snapshot %s instead of snapshot %s of the backup", repDest.Namespace, repDest.Name, got, want))
+	}
+
+	return nil
+}
+
 func (r *VolumeSnapshotRestoreReconciler) CreateVSRResticSecret(log logr.Logger) (bool, error) {
 	// get volumesnapshotrestore from cluster
 	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
@@ -160,6 +301,11 @@ func (r *VolumeSnapshotRestoreReconciler) CreateVSRResticSecret(log logr.Logger)
 		return false, err
 	}
 
+	// rsync movers do not read from a repository
+	if vsr.Spec.Mover.IsClone() {
+		return true, nil
+	}
+
 	// get restic secret name
 	credName := vsr.Spec.ResticSecretRef.Name
 	if credName == "" {
@@ -173,12 +319,25 @@ func (r *VolumeSnapshotRestoreReconciler) CreateVSRResticSecret(log logr.Logger)
 	}
 	// get restic secret from user
 	resticSecret := corev1.Secret{}
-	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.NamespacedName.Namespace, Name: credName}, &resticSecret); err != nil {
-		r.Log.Error(err, fmt.Sprintf("unable to fetch restic  secret %s/%s", r.NamespacedName.Namespace, credName))
+	if err := r.Get(r.Context, getResticSecretKey(vsr.Spec.ProtectedNamespace, vsr.Spec.ResticSecretRef), &resticSecret); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch restic  secret %s/%s", vsr.Spec.ProtectedNamespace, credName))
+		return false, err
+	}
+
+	// restic passwords wrapped by a key provider are unwrapped before building the mover secret
+	unwrapped, err := r.KeyProviders.UnwrapResticSecret(&resticSecret)
+	if err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to unwrap restic secret %s/%s", r.NamespacedName.Namespace, credName))
 		return false, err
 	}
+	resticSecret = *unwrapped
 
-	err := ValidateResticSecret(&resticSecret)
+	mover, err := getDataMover(vsr.Spec.Mover)
+	if err != nil {
+		return false, err
+	}
+
+	err = mover.validateSecret(&resticSecret)
 	if err != nil {
 		r.Log.Error(err, fmt.Sprintf("restic secret %s/%s is malformed", r.NamespacedName.Namespace, credName))
 		return false, err
@@ -190,11 +349,25 @@ func (r *VolumeSnapshotRestoreReconciler) CreateVSRResticSecret(log logr.Logger)
 	}
 	resticrepo := vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository
 
+	// local and sftp repositories are read through the repository server of the restic secret of this cluster
+	if mover.repositoryKey() == ResticRepository && isRepositoryServerProvider(resticSecret.Labels[OADPBSLProviderName]) {
+		if err := ensureRepositoryServer(r.Context, r.Client, r.Scheme, &resticSecret); err != nil {
+			return false, err
+		}
+		resticrepo, err = rebaseRepositoryServerPath(resticrepo, getRepositoryServerURL(&resticSecret))
+		if err != nil {
+			return false, err
+		}
+	}
+
 	var rpolicy = RetainPolicy{}
 	// Create Restic secret in OADP namespace
 	op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, newResticSecret, func() error {
-
-		return BuildResticSecret(&resticSecret, newResticSecret, resticrepo, "", &rpolicy, "")
+		givensecret, err := resolveResticSecret(r.Context, r.CredentialResolver, &resticSecret, newResticSecret, resticrepo, time.Now())
+		if err != nil {
+			return err
+		}
+		return mover.buildSecret(givensecret, newResticSecret, resticrepo, "", &rpolicy, "")
 	})
 	if err != nil {
 		return false, err
@@ -3,6 +3,7 @@ package controllers
 import (
 	"testing"
 
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	"github.com/go-logr/logr"
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -105,6 +106,7 @@ func TestVolumeSnapshotBackupReconciler_CreateVSBResticSecret(t *testing.T) {
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "bsl2-name",
 					Namespace: namespace,
+					Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
 				},
 				Data: secretData,
 			},
@@ -228,6 +230,7 @@ func TestVolumeSnapshotRestoreReconciler_CreateVSRResticSecret(t *testing.T) {
 				ObjectMeta: v1.ObjectMeta{
 					Name:      "bsl-volsync",
 					Namespace: "foo",
+					Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
 				},
 				Data: secretData,
 			},
@@ -269,3 +272,161 @@ func TestVolumeSnapshotRestoreReconciler_CreateVSRResticSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestGetResticRepositoryPath(t *testing.T) {
+	newVSB := func(mover volsnapmoverv1alpha1.DataMoverType, uid types.UID) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "sample-vsb",
+				Namespace: "app",
+				Labels: map[string]string{
+					backupLabel: "backup-1",
+				},
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				Mover: mover,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				SourcePVCData: volsnapmoverv1alpha1.PVCData{
+					Name: "data",
+					UID:  uid,
+				},
+			},
+		}
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "snapcontent-a-pvc",
+			Namespace: "openshift-adp",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		layout  volsnapmoverv1alpha1.RepositoryLayout
+		vsb     *volsnapmoverv1alpha1.VolumeSnapshotBackup
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Given backup layout -> repository per backup",
+			layout: volsnapmoverv1alpha1.RepositoryLayoutBackup,
+			vsb:    newVSB("", "pvc-uid"),
+			want:   "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc",
+		},
+		{
+			name:   "Given volume layout -> repository shared by the backups of the pvc",
+			layout: volsnapmoverv1alpha1.RepositoryLayoutVolume,
+			vsb:    newVSB(volsnapmoverv1alpha1.ResticDataMover, "pvc-uid"),
+			want:   "s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid",
+		},
+		{
+			name:    "Given volume layout without source pvc uid -> error",
+			layout:  volsnapmoverv1alpha1.RepositoryLayoutVolume,
+			vsb:     newVSB("", ""),
+			wantErr: true,
+		},
+		{
+			name:    "Given volume layout with rclone mover -> error",
+			layout:  volsnapmoverv1alpha1.RepositoryLayoutVolume,
+			vsb:     newVSB(volsnapmoverv1alpha1.RcloneDataMover, "pvc-uid"),
+			wantErr: true,
+		},
+		{
+			name:    "Given unknown layout -> error",
+			layout:  "daily",
+			vsb:     newVSB("", "pvc-uid"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getResticRepositoryPath("s3:s3.amazonaws.com/bucket", tt.layout, tt.vsb, pvc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getResticRepositoryPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getResticRepositoryPath() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRestoredSnapshot(t *testing.T) {
+	newVSR := func(snapshotID string) *volsnapmoverv1alpha1.VolumeSnapshotRestore {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+				VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+					SnapshotID: snapshotID,
+				},
+			},
+		}
+	}
+	newRepDest := func(logs string) *volsyncv1alpha1.ReplicationDestination {
+		return &volsyncv1alpha1.ReplicationDestination{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "sample-vsr-rep-dest",
+				Namespace: "openshift-adp",
+			},
+			Status: &volsyncv1alpha1.ReplicationDestinationStatus{
+				LatestMoverStatus: &volsyncv1alpha1.MoverStatus{
+					Result: volsyncv1alpha1.MoverResultSuccessful,
+					Logs:   logs,
+				},
+			},
+		}
+	}
+	restoreLogs := func(id string) string {
+		return "repository 1d75de4c opened (version 2, compression level auto)\n" +
+			"restoring <Snapshot " + id + " of [/data] at 2023-01-09 21:45:42.512 +0000 UTC by root@volsync> to .\n" +
+			"Restic completed in 12s"
+	}
+
+	tests := []struct {
+		name    string
+		vsr     *volsnapmoverv1alpha1.VolumeSnapshotRestore
+		repDest *volsyncv1alpha1.ReplicationDestination
+		wantErr bool
+	}{
+		{
+			name:    "Given no snapshot id -> no error",
+			vsr:     newVSR(""),
+			repDest: newRepDest(""),
+		},
+		{
+			name:    "Given snapshot of the backup restored -> no error",
+			vsr:     newVSR("0ff74383"),
+			repDest: newRepDest(restoreLogs("0ff74383")),
+		},
+		{
+			name:    "Given other snapshot restored -> error",
+			vsr:     newVSR("0ff74383"),
+			repDest: newRepDest(restoreLogs("8a2b1c3d")),
+			wantErr: true,
+		},
+		{
+			name:    "Given no snapshot restored -> error",
+			vsr:     newVSR("0ff74383"),
+			repDest: newRepDest("No eligible snapshots found"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyRestoredSnapshot(tt.vsr, tt.repDest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyRestoredSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetResticSnapshotID(t *testing.T) {
+	logs := "processed 25 files, 36.658 MiB in 0:12\nsnapshot 0ff74383 saved\nRestic completed in 18s"
+	if got := getResticSnapshotID(&volsyncv1alpha1.MoverStatus{Logs: logs}, resticSnapshotSavedRegex); got != "0ff74383" {
+		t.Errorf("getResticSnapshotID() = %q, want %q", got, "0ff74383")
+	}
+	if got := getResticSnapshotID(nil, resticSnapshotSavedRegex); got != "" {
+		t.Errorf("getResticSnapshotID() = %q, want empty", got)
+	}
+}
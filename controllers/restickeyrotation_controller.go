@@ -0,0 +1,441 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const ConditionKeyRotated = "KeyRotated"
+const KeyRotatedReasonError = "Error"
+const KeyRotatedReasonComplete = "Complete"
+
+// the old key is retired once no mover started with it is running
+const keyRetirementRequeue = 30 * time.Second
+
+// ResticKeyRotationReconciler adds a new key to the restic repositories of a BSL, moves the restic secrets to it
+// and retires the previous key
+type ResticKeyRotationReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Log           logr.Logger
+	EventRecorder record.EventRecorder
+	Clientset     kubernetes.Interface
+	KeyProviders  KeyProviders
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=restickeyrotations,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=restickeyrotations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile runs the add and retire jobs of a key rotation, moving the restic secrets to the new key in between
+func (r *ResticKeyRotationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("keyrotation", req.NamespacedName)
+
+	rotation := volsnapmoverv1alpha1.ResticKeyRotation{}
+	if err := r.Get(ctx, req.NamespacedName, &rotation); err != nil {
+		// ignore is not found error, the jobs and secret are garbage collected with their owner
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch ResticKeyRotation CR")
+		return ctrl.Result{}, err
+	}
+
+	if !rotation.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	switch rotation.Status.Phase {
+	case "":
+		return ctrl.Result{}, r.startKeyRotation(ctx, &rotation)
+	case volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey:
+		return r.reconcileKeyRotationStep(ctx, &rotation, keyRotationStepAdd)
+	case volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey:
+		return r.reconcileKeyRotationStep(ctx, &rotation, keyRotationStepRetire)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// startKeyRotation creates the key rotation secret holding the current and new passwords, and the job adding the
+// new key to the repositories
+func (r *ResticKeyRotationReconciler) startKeyRotation(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation) error {
+	resticSecret, newKeySecret, err := r.getKeyRotationSecrets(ctx, rotation)
+	if err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+
+	if err := validateMaintenanceSecret(resticSecret); err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+	if _, err := newKeySecretData(r.KeyProviders, newKeySecret); err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+
+	// only the wrapping of the password changes, the repositories keep their key
+	if bytes.Equal(resticSecret.Data[ResticPassword], newKeySecret.Data[ResticPassword]) {
+		r.Log.Info(fmt.Sprintf("restic password of secret %s is unchanged, only updating its wrapping", resticSecret.Name))
+		return r.completeKeyAdd(ctx, rotation, true)
+	}
+
+	// compose the repository the same way the volumesnapshotbackups do
+	resticRepo, err := getS3ResticRepository(string(resticSecret.Data[ResticRepository]), resticSecret)
+	if err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+
+	secret, err := PopulateResticSecret(keyRotationName(rotation.Name, ""), rotation.Namespace, KeyRotationLabel)
+	if err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := controllerutil.SetControllerReference(rotation, secret, r.Scheme); err != nil {
+			return err
+		}
+		if err := BuildResticSecret(resticSecret, secret, resticRepo, "", &RetainPolicy{}, ""); err != nil {
+			return err
+		}
+		secret.Data[ResticNewPassword] = newKeySecret.Data[ResticPassword]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.createKeyRotationJob(ctx, rotation, keyRotationStepAdd); err != nil {
+		return err
+	}
+
+	rotation.Status.Phase = volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey
+	return r.Status().Update(ctx, rotation)
+}
+
+// getKeyRotationSecrets returns the restic secret of the BSL and the new key secret, with their passwords unwrapped
+func (r *ResticKeyRotationReconciler) getKeyRotationSecrets(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation) (*corev1.Secret, *corev1.Secret, error) {
+	secrets := []*corev1.Secret{}
+	for _, name := range []string{rotation.Spec.ResticSecretRef.Name, rotation.Spec.NewKeySecretRef.Name} {
+		secret := corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: rotation.Namespace, Name: name}, &secret); err != nil {
+			r.Log.Error(err, fmt.Sprintf("unable to fetch secret %s/%s", rotation.Namespace, name))
+			return nil, nil, err
+		}
+		unwrapped, err := r.KeyProviders.UnwrapResticSecret(&secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(unwrapped.Data[ResticPassword]) == 0 {
+			return nil, nil, errors.New(fmt.Sprintf("secret %s has no restic password", name))
+		}
+		secrets = append(secrets, unwrapped)
+	}
+
+	return secrets[0], secrets[1], nil
+}
+
+// createKeyRotationJob creates the job running a step of the key rotation with the key rotation secret
+func (r *ResticKeyRotationReconciler) createKeyRotationJob(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation, step string) error {
+	secret := corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: rotation.Namespace, Name: fmt.Sprintf("%s-secret", keyRotationName(rotation.Name, ""))}, &secret); err != nil {
+		return err
+	}
+
+	job, err := buildKeyRotationJob(rotation, string(secret.Data[ResticRepository]), &secret, step)
+	if err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+
+	if err := controllerutil.SetControllerReference(rotation, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	r.Log.Info(fmt.Sprintf("started key rotation job %s/%s running %s on repository %s", job.Namespace, job.Name, step,
+		job.Annotations[DatamoverResticRepository]))
+	return nil
+}
+
+// reconcileKeyRotationStep records the result of the job of a step once finished, and moves to the next step
+func (r *ResticKeyRotationReconciler) reconcileKeyRotationStep(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation, step string) (ctrl.Result, error) {
+	job := batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: rotation.Namespace, Name: keyRotationName(rotation.Name, step)}, &job)
+	if k8serrors.IsNotFound(err) {
+		if step == keyRotationStepRetire {
+			// movers started before the restic secrets moved to the new key still use the old one
+			running, err := r.getRunningMovers(ctx, rotation)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if len(running) > 0 {
+				r.Log.Info(fmt.Sprintf("waiting for %s to complete before retiring the old key", strings.Join(running, ", ")))
+				return ctrl.Result{RequeueAfter: keyRetirementRequeue}, nil
+			}
+		}
+		return ctrl.Result{}, r.createKeyRotationJob(ctx, rotation, step)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// wait for the key rotation job to finish, its updates trigger a new reconcile
+	if job.Status.Succeeded == 0 && !isJobFailed(&job) {
+		return ctrl.Result{}, nil
+	}
+
+	if isJobFailed(&job) {
+		message := fmt.Sprintf("key rotation job %s failed", job.Name)
+		r.EventRecorder.Event(rotation, corev1.EventTypeWarning, "ResticKeyRotationFailed", message)
+		return ctrl.Result{}, r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, message)
+	}
+
+	output, err := r.getKeyRotationOutput(ctx, &job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	repositories, failed, message := parseKeyRotationResults(output, step)
+	rotation.Status.Repositories = repositories
+	rotation.Status.FailedRepositories = failed
+	if len(failed) > 0 {
+		message = fmt.Sprintf("%s failed on %d repositories: %s", step, len(failed), message)
+		r.EventRecorder.Event(rotation, corev1.EventTypeWarning, "ResticKeyRotationFailed", message)
+		return ctrl.Result{}, r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, message)
+	}
+
+	// remove the finished job, the next step runs its own
+	propagation := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, &job, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if step == keyRotationStepAdd {
+		return ctrl.Result{}, r.completeKeyAdd(ctx, rotation, rotation.Spec.RetainOldKey)
+	}
+
+	r.EventRecorder.Event(rotation, corev1.EventTypeNormal, "ResticKeyRotationCompleted", fmt.Sprintf("retired the old key of %d repositories", repositories))
+	return ctrl.Result{}, r.completeKeyRotation(ctx, rotation, fmt.Sprintf("rotated the key of %d repositories", repositories))
+}
+
+// completeKeyAdd writes the new key to the restic secret of the BSL, which the movers of the volumesnapshotbackups
+// and volumesnapshotrestores of its namespace read, and to their mover secrets, then retires the old key unless it is kept
+func (r *ResticKeyRotationReconciler) completeKeyAdd(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation, keepOldKey bool) error {
+	_, newKeySecret, err := r.getKeyRotationSecrets(ctx, rotation)
+	if err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+	keyData, err := newKeySecretData(r.KeyProviders, newKeySecret)
+	if err != nil {
+		return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed, metav1.ConditionFalse, KeyRotatedReasonError, err.Error())
+	}
+
+	resticSecret := corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: rotation.Namespace, Name: rotation.Spec.ResticSecretRef.Name}, &resticSecret); err != nil {
+		return err
+	}
+	setResticSecretKey(&resticSecret, keyData)
+	if err := r.Update(ctx, &resticSecret); err != nil {
+		return err
+	}
+
+	migrated, err := r.migrateMoverSecrets(ctx, rotation, newKeySecret.Data[ResticPassword])
+	if err != nil {
+		return err
+	}
+	rotation.Status.MigratedSecrets = migrated
+	r.EventRecorder.Event(rotation, corev1.EventTypeNormal, "ResticSecretsMigrated",
+		fmt.Sprintf("moved restic secret %s and %d mover secrets to the new key", resticSecret.Name, migrated))
+
+	if keepOldKey {
+		return r.completeKeyRotation(ctx, rotation, fmt.Sprintf("added the new key to %d repositories", rotation.Status.Repositories))
+	}
+
+	rotation.Status.Phase = volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey
+	return r.Status().Update(ctx, rotation)
+}
+
+// migrateMoverSecrets sets the new password in the mover secrets of the restic volumesnapshotbackups and
+// volumesnapshotrestores reading the restic secret of the key rotation, returning the number of secrets updated
+func (r *ResticKeyRotationReconciler) migrateMoverSecrets(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation, password []byte) (int32, error) {
+	vsbs, vsrs, err := r.getMovers(ctx, rotation)
+	if err != nil {
+		return 0, err
+	}
+
+	// the mover secret of a vsb or vsr is written next to the restic secret it reads
+	keys := []types.NamespacedName{}
+	for _, vsb := range vsbs {
+		keys = append(keys, types.NamespacedName{Namespace: vsb.Spec.ProtectedNamespace, Name: fmt.Sprintf("%s-secret", vsb.Name)})
+	}
+	for _, vsr := range vsrs {
+		keys = append(keys, types.NamespacedName{Namespace: vsr.Spec.ProtectedNamespace, Name: fmt.Sprintf("%s-secret", vsr.Name)})
+	}
+
+	var migrated int32
+	for _, key := range keys {
+		secret := corev1.Secret{}
+		if err := r.Get(ctx, key, &secret); err != nil {
+			// the secret is built from the restic secret of the BSL if the mover did not start yet
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return migrated, err
+		}
+		if bytes.Equal(secret.Data[ResticPassword], password) {
+			continue
+		}
+
+		secret.Data[ResticPassword] = password
+		if err := r.Update(ctx, &secret); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// getRunningMovers returns the volumesnapshotbackups and volumesnapshotrestores of the restic secret in progress
+func (r *ResticKeyRotationReconciler) getRunningMovers(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation) ([]string, error) {
+	vsbs, vsrs, err := r.getMovers(ctx, rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	running := []string{}
+	for _, vsb := range vsbs {
+		if vsb.Status.Phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress {
+			running = append(running, fmt.Sprintf("volumesnapshotbackup %s/%s", vsb.Namespace, vsb.Name))
+		}
+	}
+	for _, vsr := range vsrs {
+		if vsr.Status.Phase == volsnapmoverv1alpha1.SnapMoverRestorePhaseInProgress {
+			running = append(running, fmt.Sprintf("volumesnapshotrestore %s/%s", vsr.Namespace, vsr.Name))
+		}
+	}
+
+	return running, nil
+}
+
+// getMovers returns the restic volumesnapshotbackups and volumesnapshotrestores whose movers read the restic
+// secret of the key rotation
+func (r *ResticKeyRotationReconciler) getMovers(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation) (
+	[]volsnapmoverv1alpha1.VolumeSnapshotBackup, []volsnapmoverv1alpha1.VolumeSnapshotRestore, error) {
+
+	resticSecret := types.NamespacedName{Namespace: rotation.Namespace, Name: rotation.Spec.ResticSecretRef.Name}
+	readsKey := func(protectedNamespace string, ref corev1.LocalObjectReference, mover volsnapmoverv1alpha1.DataMoverType) bool {
+		return getResticSecretKey(protectedNamespace, ref) == resticSecret &&
+			(mover == "" || mover == volsnapmoverv1alpha1.ResticDataMover)
+	}
+
+	vsbList := volsnapmoverv1alpha1.VolumeSnapshotBackupList{}
+	if err := r.List(ctx, &vsbList); err != nil {
+		return nil, nil, err
+	}
+	vsbs := []volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	for _, vsb := range vsbList.Items {
+		if readsKey(vsb.Spec.ProtectedNamespace, vsb.Spec.ResticSecretRef, vsb.Spec.Mover) {
+			vsbs = append(vsbs, vsb)
+		}
+	}
+
+	vsrList := volsnapmoverv1alpha1.VolumeSnapshotRestoreList{}
+	if err := r.List(ctx, &vsrList); err != nil {
+		return nil, nil, err
+	}
+	vsrs := []volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+	for _, vsr := range vsrList.Items {
+		if readsKey(vsr.Spec.ProtectedNamespace, vsr.Spec.ResticSecretRef, vsr.Spec.Mover) {
+			vsrs = append(vsrs, vsr)
+		}
+	}
+
+	return vsbs, vsrs, nil
+}
+
+// completeKeyRotation records the completion of a key rotation
+func (r *ResticKeyRotationReconciler) completeKeyRotation(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation, message string) error {
+	now := metav1.Now()
+	rotation.Status.CompletionTimestamp = &now
+	return r.setKeyRotationStatus(ctx, rotation, volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseCompleted, metav1.ConditionTrue, KeyRotatedReasonComplete, message)
+}
+
+// setKeyRotationStatus records the result of a key rotation
+func (r *ResticKeyRotationReconciler) setKeyRotationStatus(ctx context.Context, rotation *volsnapmoverv1alpha1.ResticKeyRotation,
+	phase volsnapmoverv1alpha1.ResticKeyRotationPhase, status metav1.ConditionStatus, reason, message string) error {
+
+	rotation.Status.Phase = phase
+	apimeta.SetStatusCondition(&rotation.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionKeyRotated,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(ctx, rotation)
+}
+
+// getKeyRotationOutput returns the logs of the succeeded pod of a key rotation job
+func (r *ResticKeyRotationReconciler) getKeyRotationOutput(ctx context.Context, job *batchv1.Job) (string, error) {
+	podList := corev1.PodList{}
+	if err := r.List(ctx, &podList, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+
+		logs, err := r.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		if err != nil {
+			return "", err
+		}
+		return string(logs), nil
+	}
+
+	return "", k8serrors.NewNotFound(corev1.Resource("pods"), fmt.Sprintf("succeeded pod of job %s", job.Name))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ResticKeyRotationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.ResticKeyRotation{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestResticKeyRotationReconciler_Reconcile(t *testing.T) {
+	keyProviders := KeyProviders{FileKeyProvider: NewFileKeyProvider(newTestKeyDir(t))}
+
+	newRotation := func(phase volsnapmoverv1alpha1.ResticKeyRotationPhase, retainOldKey bool) *volsnapmoverv1alpha1.ResticKeyRotation {
+		return &volsnapmoverv1alpha1.ResticKeyRotation{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "rotation",
+				Namespace: namespace,
+			},
+			Spec: volsnapmoverv1alpha1.ResticKeyRotationSpec{
+				ResticSecretRef: corev1.LocalObjectReference{Name: "restic-secret"},
+				NewKeySecretRef: corev1.LocalObjectReference{Name: "new-key"},
+				RetainOldKey:    retainOldKey,
+			},
+			Status: volsnapmoverv1alpha1.ResticKeyRotationStatus{
+				Phase: phase,
+			},
+		}
+	}
+	resticSecret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "restic-secret",
+			Namespace: namespace,
+			Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
+		},
+		Data: map[string][]byte{
+			AWSAccessKey:     []byte("access"),
+			AWSSecretKey:     []byte("secret"),
+			ResticPassword:   []byte("old"),
+			ResticRepository: []byte("s3:s3.amazonaws.com/bucket/"),
+		},
+	}
+	newKeySecret := func(password string, provider string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "new-key", Namespace: namespace},
+			Data:       map[string][]byte{ResticPassword: []byte(password)},
+		}
+		if len(provider) > 0 {
+			secret.Data[ResticKeyProvider] = []byte(provider)
+			secret.Data[ResticKeyID] = []byte("raw")
+		}
+		return secret
+	}
+	rotationSecret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "rotation-key-rotation-secret", Namespace: namespace},
+		Data: map[string][]byte{
+			ResticRepository:  []byte("s3:s3.amazonaws.com/bucket"),
+			ResticPassword:    []byte("old"),
+			ResticNewPassword: []byte("new"),
+		},
+	}
+	newJob := func(step string, status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      keyRotationName("rotation", step),
+				Namespace: namespace,
+			},
+			Status: status,
+		}
+	}
+	succeededPod := func(step string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      keyRotationName("rotation", step) + "-abcde",
+				Namespace: namespace,
+				Labels:    map[string]string{"job-name": keyRotationName("rotation", step)},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodSucceeded,
+			},
+		}
+	}
+	newVSB := func(phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: v1.ObjectMeta{Name: "vsb", Namespace: "app"},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				ProtectedNamespace: namespace,
+				ResticSecretRef:    corev1.LocalObjectReference{Name: "restic-secret"},
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{Phase: phase},
+		}
+	}
+	vsbSecret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "vsb-secret", Namespace: namespace},
+		Data: map[string][]byte{
+			ResticRepository: []byte("s3:s3.amazonaws.com/bucket/app/vsb"),
+			ResticPassword:   []byte("old"),
+		},
+	}
+
+	tests := []struct {
+		name             string
+		objs             []client.Object
+		wantPhase        volsnapmoverv1alpha1.ResticKeyRotationPhase
+		wantJob          string
+		wantPassword     string
+		wantWrapped      bool
+		wantVSBPassword  string
+		wantMigrated     int32
+		wantRotationData bool
+	}{
+		{
+			name:             "Given new rotation -> add job started with the current and new passwords",
+			objs:             []client.Object{newRotation("", false), resticSecret.DeepCopy(), newKeySecret("new", "")},
+			wantPhase:        volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey,
+			wantJob:          keyRotationStepAdd,
+			wantPassword:     "old",
+			wantRotationData: true,
+		},
+		{
+			name:         "Given rotation without new key secret -> failed",
+			objs:         []client.Object{newRotation("", false), resticSecret.DeepCopy()},
+			wantPhase:    volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed,
+			wantPassword: "old",
+		},
+		{
+			name:         "Given new key secret with unknown key provider -> failed",
+			objs:         []client.Object{newRotation("", false), resticSecret.DeepCopy(), newKeySecret("new", "vault")},
+			wantPhase:    volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed,
+			wantPassword: "old",
+		},
+		{
+			name:        "Given same password with key provider -> password wrapped without job",
+			objs:        []client.Object{newRotation("", false), resticSecret.DeepCopy(), newKeySecret("old", FileKeyProvider)},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseCompleted,
+			wantWrapped: true,
+		},
+		{
+			name:      "Given running add job -> still adding",
+			objs:      []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey, false), newJob(keyRotationStepAdd, batchv1.JobStatus{Active: 1})},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey,
+			wantJob:   keyRotationStepAdd,
+		},
+		{
+			name: "Given succeeded add job -> secrets migrated and old key retiring",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey, false), resticSecret.DeepCopy(), newKeySecret("new", ""),
+				newJob(keyRotationStepAdd, batchv1.JobStatus{Succeeded: 1}), succeededPod(keyRotationStepAdd), newVSB(volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted), vsbSecret.DeepCopy()},
+			wantPhase:       volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey,
+			wantPassword:    "new",
+			wantVSBPassword: "new",
+			wantMigrated:    1,
+		},
+		{
+			name: "Given succeeded add job with backup reading another restic secret -> its secret not migrated",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey, false), resticSecret.DeepCopy(), newKeySecret("new", ""),
+				newJob(keyRotationStepAdd, batchv1.JobStatus{Succeeded: 1}), succeededPod(keyRotationStepAdd), func() client.Object {
+					vsb := newVSB(volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted)
+					vsb.Spec.ProtectedNamespace = "other-oadp"
+					return vsb
+				}(), vsbSecret.DeepCopy()},
+			wantPhase:       volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey,
+			wantPassword:    "new",
+			wantVSBPassword: "old",
+		},
+		{
+			name: "Given succeeded add job retaining old key -> completed",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey, true), resticSecret.DeepCopy(), newKeySecret("new", FileKeyProvider),
+				newJob(keyRotationStepAdd, batchv1.JobStatus{Succeeded: 1}), succeededPod(keyRotationStepAdd)},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseCompleted,
+			wantWrapped: true,
+		},
+		{
+			name: "Given failed add job -> failed",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseAddingKey, false), resticSecret.DeepCopy(), newJob(keyRotationStepAdd, batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			})},
+			wantPhase:    volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseFailed,
+			wantJob:      keyRotationStepAdd,
+			wantPassword: "old",
+		},
+		{
+			name: "Given retiring with backup in progress -> retire job waits",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey, false), rotationSecret.DeepCopy(),
+				newVSB(volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress)},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey,
+		},
+		{
+			name:      "Given retiring without running movers -> retire job started",
+			objs:      []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey, false), rotationSecret.DeepCopy()},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey,
+			wantJob:   keyRotationStepRetire,
+		},
+		{
+			name: "Given succeeded retire job -> completed",
+			objs: []client.Object{newRotation(volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseRetiringKey, false),
+				newJob(keyRotationStepRetire, batchv1.JobStatus{Succeeded: 1}), succeededPod(keyRotationStepRetire)},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverKeyRotationPhaseCompleted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &ResticKeyRotationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				EventRecorder: record.NewFakeRecorder(10),
+				Clientset:     fake.NewSimpleClientset(),
+				KeyProviders:  keyProviders,
+			}
+			ctx := newContextForTest(tt.name)
+			if _, err := r.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: "rotation"},
+			}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			rotation := volsnapmoverv1alpha1.ResticKeyRotation{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "rotation"}, &rotation); err != nil {
+				t.Fatalf("unable to fetch restickeyrotation: %v", err)
+			}
+			if rotation.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %v, want %v", rotation.Status.Phase, tt.wantPhase)
+			}
+			if rotation.Status.MigratedSecrets != tt.wantMigrated {
+				t.Errorf("migrated secrets = %v, want %v", rotation.Status.MigratedSecrets, tt.wantMigrated)
+			}
+
+			for _, step := range []string{keyRotationStepAdd, keyRotationStepRetire} {
+				job := batchv1.Job{}
+				err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: keyRotationName("rotation", step)}, &job)
+				if step == tt.wantJob && err != nil {
+					t.Errorf("expected %s job, got error %v", step, err)
+				}
+				if step != tt.wantJob && !k8serrors.IsNotFound(err) {
+					t.Errorf("expected no %s job, got error %v", step, err)
+				}
+			}
+
+			if tt.wantRotationData {
+				secret := corev1.Secret{}
+				if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "rotation-key-rotation-secret"}, &secret); err != nil {
+					t.Fatalf("unable to fetch key rotation secret: %v", err)
+				}
+				if string(secret.Data[ResticPassword]) != "old" || string(secret.Data[ResticNewPassword]) != "new" ||
+					string(secret.Data[ResticRepository]) != "s3:s3.amazonaws.com/bucket" {
+					t.Errorf("key rotation secret data = %v", secret.Data)
+				}
+			}
+
+			secret := corev1.Secret{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "restic-secret"}, &secret)
+			if len(tt.wantPassword) > 0 && (err != nil || string(secret.Data[ResticPassword]) != tt.wantPassword) {
+				t.Errorf("restic secret password = %s, want %s, error %v", secret.Data[ResticPassword], tt.wantPassword, err)
+			}
+			if tt.wantWrapped {
+				if len(secret.Data[ResticPassword]) > 0 || !isWrappedResticSecret(&secret) {
+					t.Errorf("expected wrapped restic secret, got %v", secret.Data)
+				}
+				if _, err := keyProviders.UnwrapResticSecret(&secret); err != nil {
+					t.Errorf("UnwrapResticSecret() error = %v", err)
+				}
+			}
+
+			if len(tt.wantVSBPassword) > 0 {
+				secret := corev1.Secret{}
+				if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "vsb-secret"}, &secret); err != nil {
+					t.Fatalf("unable to fetch vsb secret: %v", err)
+				}
+				if string(secret.Data[ResticPassword]) != tt.wantVSBPassword {
+					t.Errorf("vsb secret password = %s, want %s", secret.Data[ResticPassword], tt.wantVSBPassword)
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyRotationResults(t *testing.T) {
+	output := strings.Join([]string{
+		"add ok s3:s3.amazonaws.com/bucket/foo/backup-1/data",
+		"add failed s3:s3.amazonaws.com/bucket/foo/backup-2/data Fatal: wrong password or no key found",
+		"retire ok s3:s3.amazonaws.com/bucket/foo/backup-1/data",
+		"unexpected",
+	}, "\n")
+
+	repositories, failed, message := parseKeyRotationResults(output, keyRotationStepAdd)
+	if repositories != 2 {
+		t.Errorf("parseKeyRotationResults() repositories = %v, want 2", repositories)
+	}
+	if !reflect.DeepEqual(failed, []string{"s3:s3.amazonaws.com/bucket/foo/backup-2/data"}) {
+		t.Errorf("parseKeyRotationResults() failed = %v", failed)
+	}
+	if message != "Fatal: wrong password or no key found" {
+		t.Errorf("parseKeyRotationResults() message = %v", message)
+	}
+}
+
+func TestBuildKeyRotationJob(t *testing.T) {
+	rotation := &volsnapmoverv1alpha1.ResticKeyRotation{
+		ObjectMeta: v1.ObjectMeta{Name: "rotation", Namespace: namespace},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "rotation-key-rotation-secret", Namespace: namespace},
+	}
+
+	if _, err := buildKeyRotationJob(rotation, "s3:s3.amazonaws.com/bucket", secret, "rewrap"); err == nil {
+		t.Errorf("buildKeyRotationJob() expected error for unknown step")
+	}
+
+	job, err := buildKeyRotationJob(rotation, "s3:s3.amazonaws.com/bucket", secret, keyRotationStepRetire)
+	if err != nil {
+		t.Fatalf("buildKeyRotationJob() error = %v", err)
+	}
+	if job.Name != "rotation-key-retire" || job.Labels[KeyRotationLabel] != "rotation" {
+		t.Errorf("buildKeyRotationJob() job = %v labels %v", job.Name, job.Labels)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if got := container.Command[len(container.Command)-1]; got != "/credentials/RESTIC_NEW_PASSWORD" {
+		t.Errorf("buildKeyRotationJob() new password argument = %v", got)
+	}
+	if !strings.Contains(container.Command[2], "key remove") {
+		t.Errorf("buildKeyRotationJob() script = %v", container.Command[2])
+	}
+}
+
+func TestNewKeySecretData(t *testing.T) {
+	keyProviders := KeyProviders{FileKeyProvider: NewFileKeyProvider(newTestKeyDir(t))}
+
+	tests := []struct {
+		name        string
+		data        map[string]string
+		wantKeys    []string
+		wantWrapped bool
+		wantErr     bool
+	}{
+		{
+			name:     "Given plain password -> plain password",
+			data:     map[string]string{ResticPassword: "new"},
+			wantKeys: []string{ResticPassword},
+		},
+		{
+			name:        "Given plain password with key provider -> password wrapped",
+			data:        map[string]string{ResticPassword: "new", ResticKeyProvider: FileKeyProvider, ResticKeyID: "raw"},
+			wantKeys:    []string{ResticKeyID, ResticKeyProvider, ResticPasswordWrapped},
+			wantWrapped: true,
+		},
+		{
+			name:     "Given wrapped password -> kept wrapped",
+			data:     map[string]string{ResticPasswordWrapped: base64.StdEncoding.EncodeToString([]byte("sealed")), ResticKeyProvider: FileKeyProvider, ResticKeyID: "raw"},
+			wantKeys: []string{ResticKeyID, ResticKeyProvider, ResticPasswordWrapped},
+		},
+		{
+			name:    "Given no password -> error",
+			data:    map[string]string{ResticKeyProvider: FileKeyProvider},
+			wantErr: true,
+		},
+		{
+			name:    "Given missing key -> error",
+			data:    map[string]string{ResticPassword: "new", ResticKeyProvider: FileKeyProvider, ResticKeyID: "missing"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "new-key"}, Data: map[string][]byte{}}
+			for key, val := range tt.data {
+				secret.Data[key] = []byte(val)
+			}
+			got, err := newKeySecretData(keyProviders, secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newKeySecretData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			keys := []string{}
+			for _, key := range []string{ResticKeyID, ResticKeyProvider, ResticPassword, ResticPasswordWrapped} {
+				if _, ok := got[key]; ok {
+					keys = append(keys, key)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("newKeySecretData() keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if tt.wantWrapped {
+				unwrapped, err := keyProviders.UnwrapResticSecret(&corev1.Secret{Data: got})
+				if err != nil || string(unwrapped.Data[ResticPassword]) != "new" {
+					t.Errorf("UnwrapResticSecret() = %v, error %v", unwrapped, err)
+				}
+			}
+		})
+	}
+}
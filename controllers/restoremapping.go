@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch
+
+// GetDataMoverConfig returns the cluster DataMoverConfig, or nil if it was not created
+func GetDataMoverConfig(c client.Client) (*volsnapmoverv1alpha1.DataMoverConfig, error) {
+	config := volsnapmoverv1alpha1.DataMoverConfig{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: volsnapmoverv1alpha1.DataMoverConfigName}, &config)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to get datamoverconfig %s", volsnapmoverv1alpha1.DataMoverConfigName))
+	}
+
+	return &config, nil
+}
+
+// getRestoreStorageClass returns the storage class mapped to the backed up storage class of a vsr by the
+// DataMoverConfig, or the backed up storage class. The mapped storage class must exist
+func getRestoreStorageClass(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, dmConfig *volsnapmoverv1alpha1.DataMoverConfig, c client.Client) (string, error) {
+	storageClassName := vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.StorageClassName
+	if dmConfig == nil {
+		return storageClassName, nil
+	}
+
+	mapped, ok := dmConfig.Spec.StorageClassMapping[storageClassName]
+	if !ok {
+		return storageClassName, nil
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: mapped}, &storagev1.StorageClass{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", errors.New(fmt.Sprintf("storage class %s mapped to %s for vsr %s/%s does not exist", mapped, storageClassName, vsr.Namespace, vsr.Name))
+		}
+		return "", err
+	}
+
+	return mapped, nil
+}
+
+// getRestoreVolumeSnapshotClass returns the volume snapshot class mapped to the backed up volume snapshot class of
+// a vsr by the DataMoverConfig, or the backed up volume snapshot class. The mapped volume snapshot class must exist
+func getRestoreVolumeSnapshotClass(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, dmConfig *volsnapmoverv1alpha1.DataMoverConfig, c client.Client) (string, error) {
+	vscName := vsr.Spec.VolumeSnapshotMoverBackupref.VolumeSnapshotClassName
+	if dmConfig == nil {
+		return vscName, nil
+	}
+
+	mapped, ok := dmConfig.Spec.VolumeSnapshotClassMapping[vscName]
+	if !ok {
+		return vscName, nil
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: mapped}, &snapv1.VolumeSnapshotClass{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", errors.New(fmt.Sprintf("volume snapshot class %s mapped to %s for vsr %s/%s does not exist", mapped, vscName, vsr.Namespace, vsr.Name))
+		}
+		return "", err
+	}
+
+	return mapped, nil
+}
+
+// getRestoreCapacity grows the backed up capacity of a restored volume by the percentage set in the
+// DataMoverConfig, rounded up to the byte
+func getRestoreCapacity(capacity *resource.Quantity, dmConfig *volsnapmoverv1alpha1.DataMoverConfig) *resource.Quantity {
+	if dmConfig == nil || dmConfig.Spec.RestoreCapacityGrowthPercent == nil || *dmConfig.Spec.RestoreCapacityGrowthPercent <= 0 {
+		return capacity
+	}
+
+	size := capacity.Value()
+	growth := (size*int64(*dmConfig.Spec.RestoreCapacityGrowthPercent) + 99) / 100
+	return resource.NewQuantity(size+growth, capacity.Format)
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestVolumeSnapshotRestoreReconciler_configureRepDestVolOptionsMapping(t *testing.T) {
+	vsr := &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vsr",
+			Namespace: "bar",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+			VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+				BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+					Name:             "test-pvc",
+					Size:             "10Gi",
+					StorageClassName: "gp2",
+				},
+				VolumeSnapshotClassName: "ebs-snapclass",
+			},
+			ProtectedNamespace: namespace,
+		},
+	}
+	newDataMoverConfig := func(spec volsnapmoverv1alpha1.DataMoverConfigSpec) *volsnapmoverv1alpha1.DataMoverConfig {
+		return &volsnapmoverv1alpha1.DataMoverConfig{
+			ObjectMeta: v1.ObjectMeta{Name: volsnapmoverv1alpha1.DataMoverConfigName},
+			Spec:       spec,
+		}
+	}
+	mapping := volsnapmoverv1alpha1.DataMoverConfigSpec{
+		StorageClassMapping:        map[string]string{"gp2": "ocs-storagecluster-ceph-rbd"},
+		VolumeSnapshotClassMapping: map[string]string{"ebs-snapclass": "ocs-storagecluster-rbdplugin-snapclass"},
+	}
+	targetClasses := []client.Object{
+		&storagev1.StorageClass{ObjectMeta: v1.ObjectMeta{Name: "ocs-storagecluster-ceph-rbd"}},
+		&snapv1.VolumeSnapshotClass{ObjectMeta: v1.ObjectMeta{Name: "ocs-storagecluster-rbdplugin-snapclass"}},
+	}
+
+	tests := []struct {
+		name             string
+		objs             []client.Object
+		config           *volsnapmoverv1alpha1.DataMoverStorageClassConfig
+		wantStorageClass string
+		wantVSC          string
+		wantCapacity     string
+		wantErr          bool
+	}{
+		{
+			name:             "Given no datamoverconfig, should restore the backed up classes and size",
+			wantStorageClass: "gp2",
+			wantVSC:          "ebs-snapclass",
+			wantCapacity:     "10Gi",
+		},
+		{
+			name: "Given mapping and capacity growth, should restore the mapped classes and grown size",
+			objs: append([]client.Object{newDataMoverConfig(volsnapmoverv1alpha1.DataMoverConfigSpec{
+				StorageClassMapping:          mapping.StorageClassMapping,
+				VolumeSnapshotClassMapping:   mapping.VolumeSnapshotClassMapping,
+				RestoreCapacityGrowthPercent: pointer.Int32(10),
+			})}, targetClasses...),
+			wantStorageClass: "ocs-storagecluster-ceph-rbd",
+			wantVSC:          "ocs-storagecluster-rbdplugin-snapclass",
+			wantCapacity:     "11Gi",
+		},
+		{
+			name: "Given mapping and storage class config, should let the storage class config override the mapping",
+			objs: append([]client.Object{newDataMoverConfig(mapping)}, targetClasses...),
+			config: &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+				ObjectMeta: v1.ObjectMeta{Name: "gp2", Namespace: namespace},
+				Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+					Destination: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "other-class"},
+				},
+			},
+			wantStorageClass: "other-class",
+			wantVSC:          "ocs-storagecluster-rbdplugin-snapclass",
+			wantCapacity:     "10Gi",
+		},
+		{
+			name:    "Given mapping to a missing storage class, should error out",
+			objs:    []client.Object{newDataMoverConfig(mapping), targetClasses[1]},
+			wantErr: true,
+		},
+		{
+			name:    "Given mapping to a missing volume snapshot class, should error out",
+			objs:    []client.Object{newDataMoverConfig(mapping), targetClasses[0]},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotRestoreReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(tt.name),
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+
+			capacity := resource.MustParse("10Gi")
+			got, err := r.configureRepDestVolOptions(vsr, &capacity, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configureRepDestVolOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *got.StorageClassName != tt.wantStorageClass {
+				t.Errorf("storageClassName = %s, want %s", *got.StorageClassName, tt.wantStorageClass)
+			}
+			if *got.VolumeSnapshotClassName != tt.wantVSC {
+				t.Errorf("volumeSnapshotClassName = %s, want %s", *got.VolumeSnapshotClassName, tt.wantVSC)
+			}
+			if got.Capacity.Cmp(resource.MustParse(tt.wantCapacity)) != 0 {
+				t.Errorf("capacity = %s, want %s", got.Capacity.String(), tt.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestGetRestoreCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity string
+		percent  *int32
+		want     string
+	}{
+		{
+			name:     "Given no growth -> backed up size",
+			capacity: "10Gi",
+			want:     "10Gi",
+		},
+		{
+			name:     "Given 50 percent growth -> grown size",
+			capacity: "10Gi",
+			percent:  pointer.Int32(50),
+			want:     "15Gi",
+		},
+		{
+			name:     "Given growth of an odd size -> rounded up to the byte",
+			capacity: "3",
+			percent:  pointer.Int32(10),
+			want:     "4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity := resource.MustParse(tt.capacity)
+			dmConfig := &volsnapmoverv1alpha1.DataMoverConfig{
+				Spec: volsnapmoverv1alpha1.DataMoverConfigSpec{RestoreCapacityGrowthPercent: tt.percent},
+			}
+			if got := getRestoreCapacity(&capacity, dmConfig); got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("getRestoreCapacity() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
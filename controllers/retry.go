@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultRetryBackoff    = 30 * time.Second
+	defaultRetryMaxBackoff = 10 * time.Minute
+)
+
+// attemptFailedConditionType returns the condition type recording why an attempt failed
+func attemptFailedConditionType(attempt int32) string {
+	return fmt.Sprintf("Attempt%dFailed", attempt)
+}
+
+// manualTrigger returns the ReplicationSource manual trigger of an attempt,
+// every retry needs a new value for VolSync to sync again
+func manualTrigger(vsbName string, attempt int32) string {
+	if attempt <= 1 {
+		return fmt.Sprintf("%s-trigger", vsbName)
+	}
+
+	return fmt.Sprintf("%s-trigger-%d", vsbName, attempt)
+}
+
+// retryBackoff returns the delay before retrying the failed attempt, doubling
+// the backoff for every attempt up to the max backoff
+func retryBackoff(policy *volsnapmoverv1alpha1.RetryPolicy, failedAttempt int32) time.Duration {
+	backoff := defaultRetryBackoff
+	maxBackoff := defaultRetryMaxBackoff
+	if policy != nil && policy.Backoff != nil {
+		backoff = policy.Backoff.Duration
+	}
+	if policy != nil && policy.MaxBackoff != nil {
+		maxBackoff = policy.MaxBackoff.Duration
+	}
+
+	for i := int32(1); i < failedAttempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+// retryRepSource records the failure of the current replicationsource sync attempt in the vsb conditions
+// and retriggers the sync once the backoff has elapsed. It returns true while the failed sync is being
+// retried, false once the retry policy is exhausted and the vsb should be marked as failed
+func (r *VolumeSnapshotBackupReconciler) retryRepSource(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, repSource *volsyncv1alpha1.ReplicationSource,
+	syncCondition metav1.Condition) (bool, error) {
+
+	attempt := vsb.Status.Attempts
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	// failure of the current attempt already recorded, retrigger the sync once the backoff has elapsed
+	if apimeta.FindStatusCondition(vsb.Status.Conditions, attemptFailedConditionType(attempt)) != nil {
+		if vsb.Status.NextRetryTimestamp == nil {
+			return false, nil
+		}
+		if time.Now().Before(vsb.Status.NextRetryTimestamp.Time) {
+			r.Log.Info(fmt.Sprintf("waiting until %s to retry replicationsource %s/%s", vsb.Status.NextRetryTimestamp, repSource.Namespace, repSource.Name))
+			return true, nil
+		}
+
+		// scheduled replicationsources sync again on their own
+		if repSource.Spec.Trigger == nil || len(repSource.Spec.Trigger.Manual) == 0 {
+			return false, nil
+		}
+
+		repSource.Spec.Trigger.Manual = manualTrigger(vsb.Name, attempt+1)
+		if err := r.Update(context.Background(), repSource); err != nil {
+			return false, err
+		}
+
+		vsb.Status.Attempts = attempt + 1
+		if err := r.Status().Update(context.Background(), vsb); err != nil {
+			return false, err
+		}
+
+		r.EventRecorder.Event(vsb,
+			corev1.EventTypeNormal,
+			"ReplicationSourceSyncRetried",
+			fmt.Sprintf("started attempt %v of replicationsource %s", attempt+1, repSource.Name),
+		)
+		return true, nil
+	}
+
+	// error reported by VolSync before the current attempt was triggered
+	if vsb.Status.NextRetryTimestamp != nil && !syncCondition.LastTransitionTime.After(vsb.Status.NextRetryTimestamp.Time) {
+		return true, nil
+	}
+
+	vsb.Status.Attempts = attempt
+	apimeta.SetStatusCondition(&vsb.Status.Conditions,
+		metav1.Condition{
+			Type:    attemptFailedConditionType(attempt),
+			Status:  metav1.ConditionTrue,
+			Reason:  volsyncv1alpha1.SynchronizingReasonError,
+			Message: syncCondition.Message,
+		})
+
+	// no retries left, the caller marks the vsb as failed along with the recorded failure
+	if vsb.Spec.RetryPolicy == nil || attempt >= vsb.Spec.RetryPolicy.MaxAttempts {
+		vsb.Status.NextRetryTimestamp = nil
+		return false, nil
+	}
+
+	backoff := retryBackoff(vsb.Spec.RetryPolicy, attempt)
+	nextRetry := metav1.NewTime(time.Now().Add(backoff))
+	vsb.Status.NextRetryTimestamp = &nextRetry
+	if err := r.Status().Update(context.Background(), vsb); err != nil {
+		return false, err
+	}
+
+	r.EventRecorder.Event(vsb,
+		corev1.EventTypeWarning,
+		"ReplicationSourceSyncFailed",
+		fmt.Sprintf("attempt %v of replicationsource %s failed, retrying in %s: %s", attempt, repSource.Name, backoff, syncCondition.Message),
+	)
+	return true, nil
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        *volsnapmoverv1alpha1.RetryPolicy
+		failedAttempt int32
+		want          time.Duration
+	}{
+		{
+			name:          "Given nil policy, first attempt should use the default backoff",
+			failedAttempt: 1,
+			want:          defaultRetryBackoff,
+		},
+		{
+			name:          "Given nil policy, third attempt should double the default backoff twice",
+			failedAttempt: 3,
+			want:          4 * defaultRetryBackoff,
+		},
+		{
+			name:          "Given nil policy, backoff should be capped at the default max backoff",
+			failedAttempt: 20,
+			want:          defaultRetryMaxBackoff,
+		},
+		{
+			name: "Given custom backoff, second attempt should double it",
+			policy: &volsnapmoverv1alpha1.RetryPolicy{
+				MaxAttempts: 5,
+				Backoff:     &metav1.Duration{Duration: time.Minute},
+			},
+			failedAttempt: 2,
+			want:          2 * time.Minute,
+		},
+		{
+			name: "Given custom max backoff, backoff should be capped at it",
+			policy: &volsnapmoverv1alpha1.RetryPolicy{
+				MaxAttempts: 5,
+				Backoff:     &metav1.Duration{Duration: time.Minute},
+				MaxBackoff:  &metav1.Duration{Duration: 3 * time.Minute},
+			},
+			failedAttempt: 4,
+			want:          3 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryBackoff(tt.policy, tt.failedAttempt); got != tt.want {
+				t.Errorf("retryBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolumeSnapshotBackupReconciler_retryRepSource(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Minute))
+	future := metav1.NewTime(time.Now().Add(time.Hour))
+	syncError := metav1.Condition{
+		Type:               volsyncv1alpha1.ConditionSynchronizing,
+		Status:             metav1.ConditionFalse,
+		Reason:             volsyncv1alpha1.SynchronizingReasonError,
+		Message:            "mover job failed",
+		LastTransitionTime: metav1.Now(),
+	}
+	failedCondition := func(attempt int32) metav1.Condition {
+		return metav1.Condition{
+			Type:               attemptFailedConditionType(attempt),
+			Status:             metav1.ConditionTrue,
+			Reason:             volsyncv1alpha1.SynchronizingReasonError,
+			LastTransitionTime: past,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		retryPolicy   *volsnapmoverv1alpha1.RetryPolicy
+		status        volsnapmoverv1alpha1.VolumeSnapshotBackupStatus
+		syncCondition metav1.Condition
+		want          bool
+		wantAttempts  int32
+		wantNextRetry bool
+		wantTrigger   string
+		wantFailed    []int32
+	}{
+		{
+			name:          "Given no retry policy, first failure should not be retried",
+			syncCondition: syncError,
+			want:          false,
+			wantAttempts:  1,
+			wantTrigger:   "sample-vsb-trigger",
+			wantFailed:    []int32{1},
+		},
+		{
+			name:          "Given retry policy, first failure should schedule a retry",
+			retryPolicy:   &volsnapmoverv1alpha1.RetryPolicy{MaxAttempts: 3},
+			status:        volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{Attempts: 1},
+			syncCondition: syncError,
+			want:          true,
+			wantAttempts:  1,
+			wantNextRetry: true,
+			wantTrigger:   "sample-vsb-trigger",
+			wantFailed:    []int32{1},
+		},
+		{
+			name:        "Given recorded failure before next retry, should keep waiting",
+			retryPolicy: &volsnapmoverv1alpha1.RetryPolicy{MaxAttempts: 3},
+			status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Attempts:           1,
+				NextRetryTimestamp: &future,
+				Conditions:         []metav1.Condition{failedCondition(1)},
+			},
+			syncCondition: syncError,
+			want:          true,
+			wantAttempts:  1,
+			wantNextRetry: true,
+			wantTrigger:   "sample-vsb-trigger",
+			wantFailed:    []int32{1},
+		},
+		{
+			name:        "Given recorded failure after next retry, should retrigger the replicationsource",
+			retryPolicy: &volsnapmoverv1alpha1.RetryPolicy{MaxAttempts: 3},
+			status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Attempts:           1,
+				NextRetryTimestamp: &past,
+				Conditions:         []metav1.Condition{failedCondition(1)},
+			},
+			syncCondition: syncError,
+			want:          true,
+			wantAttempts:  2,
+			wantNextRetry: true,
+			wantTrigger:   "sample-vsb-trigger-2",
+			wantFailed:    []int32{1},
+		},
+		{
+			name:        "Given stale failure of the previous attempt, should keep waiting",
+			retryPolicy: &volsnapmoverv1alpha1.RetryPolicy{MaxAttempts: 3},
+			status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Attempts:           2,
+				NextRetryTimestamp: &past,
+				Conditions:         []metav1.Condition{failedCondition(1)},
+			},
+			syncCondition: metav1.Condition{
+				Type:               volsyncv1alpha1.ConditionSynchronizing,
+				Status:             metav1.ConditionFalse,
+				Reason:             volsyncv1alpha1.SynchronizingReasonError,
+				LastTransitionTime: metav1.NewTime(past.Add(-time.Minute)),
+			},
+			want:          true,
+			wantAttempts:  2,
+			wantNextRetry: true,
+			wantTrigger:   "sample-vsb-trigger",
+			wantFailed:    []int32{1},
+		},
+		{
+			name:        "Given failure of the last attempt, should not be retried",
+			retryPolicy: &volsnapmoverv1alpha1.RetryPolicy{MaxAttempts: 2},
+			status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Attempts:           2,
+				NextRetryTimestamp: &past,
+				Conditions:         []metav1.Condition{failedCondition(1)},
+			},
+			syncCondition: syncError,
+			want:          false,
+			wantAttempts:  2,
+			wantTrigger:   "sample-vsb-trigger",
+			wantFailed:    []int32{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sample-vsb",
+					Namespace: "bar",
+				},
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+					ProtectedNamespace: namespace,
+					RetryPolicy:        tt.retryPolicy,
+				},
+				Status: tt.status,
+			}
+			repSource := &volsyncv1alpha1.ReplicationSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sample-vsb-rep-src",
+					Namespace: namespace,
+				},
+				Spec: volsyncv1alpha1.ReplicationSourceSpec{
+					Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+						Manual: manualTrigger("sample-vsb", 1),
+					},
+				},
+			}
+
+			fakeClient, err := getFakeClientFromObjectsRepSrc(vsb, repSource)
+			if err != nil {
+				t.Errorf("error in creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotBackupReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				Context:       newContextForTest(tt.name),
+				EventRecorder: record.NewFakeRecorder(10),
+				req: reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name},
+				},
+			}
+
+			got, err := r.retryRepSource(vsb, repSource, tt.syncCondition)
+			if err != nil {
+				t.Errorf("retryRepSource() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("retryRepSource() got = %v, want %v", got, tt.want)
+			}
+			if vsb.Status.Attempts != tt.wantAttempts {
+				t.Errorf("retryRepSource() attempts = %v, want %v", vsb.Status.Attempts, tt.wantAttempts)
+			}
+			if (vsb.Status.NextRetryTimestamp != nil) != tt.wantNextRetry {
+				t.Errorf("retryRepSource() nextRetryTimestamp = %v, want set %v", vsb.Status.NextRetryTimestamp, tt.wantNextRetry)
+			}
+			for _, attempt := range tt.wantFailed {
+				if apimeta.FindStatusCondition(vsb.Status.Conditions, attemptFailedConditionType(attempt)) == nil {
+					t.Errorf("retryRepSource() failure of attempt %v not recorded", attempt)
+				}
+			}
+
+			gotRepSource := &volsyncv1alpha1.ReplicationSource{}
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: repSource.Name}, gotRepSource); err != nil {
+				t.Errorf("unable to get replicationsource: %v", err)
+				return
+			}
+			if gotRepSource.Spec.Trigger.Manual != tt.wantTrigger {
+				t.Errorf("retryRepSource() manual trigger = %v, want %v", gotRepSource.Spec.Trigger.Manual, tt.wantTrigger)
+			}
+		})
+	}
+}
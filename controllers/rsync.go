@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rsyncTLSDataMover copies the cloned volume of a VSB to a VSR of the same
+// cluster using the VolSync rsync-tls mover, without a repository
+type rsyncTLSDataMover struct{}
+
+func (m *rsyncTLSDataMover) repositoryKey() string {
+	return ""
+}
+
+func (m *rsyncTLSDataMover) validateSecret(secret *corev1.Secret) error {
+	return nil
+}
+
+func (m *rsyncTLSDataMover) buildSecret(givensecret *corev1.Secret, secret *corev1.Secret, repo, pruneInterval string, rpolicy *RetainPolicy, scheduleCronExpr string) error {
+	return errors.New(fmt.Sprintf("the %s mover does not use a repository secret", volsnapmoverv1alpha1.RsyncTLSDataMover))
+}
+
+func (m *rsyncTLSDataMover) buildReplicationSourceSpec(r *VolumeSnapshotBackupReconciler, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim,
+	secret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+	return nil, errors.New(fmt.Sprintf("the %s mover replicationsource is created by the volumesnapshotrestore", volsnapmoverv1alpha1.RsyncTLSDataMover))
+}
+
+func (m *rsyncTLSDataMover) buildReplicationDestinationSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
+	secret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationSpec, error) {
+
+	capacity, err := getBackedUpCapacity(vsr)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsSpec, err := r.configureRepDestVolOptions(vsr, capacity, config)
+	if err != nil {
+		return nil, err
+	}
+
+	podSC, err := getMoverSecurityContext(restoreVolumeOptions(vsr, config), vsr.Namespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	// the replicationsource connects from the same cluster
+	serviceType := corev1.ServiceTypeClusterIP
+
+	replicationDestinationSpec := volsyncv1alpha1.ReplicationDestinationSpec{
+		Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		RsyncTLS: &volsyncv1alpha1.ReplicationDestinationRsyncTLSSpec{
+			ReplicationDestinationVolumeOptions: *optionsSpec,
+			ServiceType:                         &serviceType,
+			MoverSecurityContext:                podSC,
+			MoverServiceAccount:                 &sa.Name,
+		},
+	}
+
+	return &replicationDestinationSpec, nil
+}
+
+func (m *rsyncTLSDataMover) buildCloneReplicationSourceSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, pvcName string,
+	repDest *volsyncv1alpha1.ReplicationDestination, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+
+	if repDest == nil {
+		return nil, errors.New("nil repDest in buildCloneReplicationSourceSpec")
+	}
+
+	// wait for VolSync to publish the address and key of the replicationdestination
+	if repDest.Status == nil || repDest.Status.RsyncTLS == nil || repDest.Status.RsyncTLS.Address == nil || repDest.Status.RsyncTLS.KeySecret == nil {
+		return nil, nil
+	}
+
+	podSC, err := getMoverSecurityContext(sourceVolumeOptions(config), vsr.Namespace, vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationSourceSpec := volsyncv1alpha1.ReplicationSourceSpec{
+		SourcePVC: pvcName,
+		Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		RsyncTLS: &volsyncv1alpha1.ReplicationSourceRsyncTLSSpec{
+			ReplicationSourceVolumeOptions: getCloneRepSourceVolOptions(),
+			KeySecret:                      repDest.Status.RsyncTLS.KeySecret,
+			Address:                        repDest.Status.RsyncTLS.Address,
+			Port:                           repDest.Status.RsyncTLS.Port,
+			MoverSecurityContext:           podSC,
+			MoverServiceAccount:            &sa.Name,
+		},
+	}
+
+	return &replicationSourceSpec, nil
+}
+
+func (m *rsyncTLSDataMover) isReplicationSourceCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool {
+	return repSourceSyncCompleted(repSource)
+}
+
+func (m *rsyncTLSDataMover) isReplicationDestinationCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool {
+	return repDestSyncCompleted(repDest)
+}
+
+// rsyncDataMover copies the cloned volume of a VSB to a VSR of the same
+// cluster using the VolSync rsync over ssh mover, without a repository
+type rsyncDataMover struct{}
+
+func (m *rsyncDataMover) repositoryKey() string {
+	return ""
+}
+
+func (m *rsyncDataMover) validateSecret(secret *corev1.Secret) error {
+	return nil
+}
+
+func (m *rsyncDataMover) buildSecret(givensecret *corev1.Secret, secret *corev1.Secret, repo, pruneInterval string, rpolicy *RetainPolicy, scheduleCronExpr string) error {
+	return errors.New(fmt.Sprintf("the %s mover does not use a repository secret", volsnapmoverv1alpha1.RsyncDataMover))
+}
+
+func (m *rsyncDataMover) buildReplicationSourceSpec(r *VolumeSnapshotBackupReconciler, vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, pvc *corev1.PersistentVolumeClaim,
+	secret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+	return nil, errors.New(fmt.Sprintf("the %s mover replicationsource is created by the volumesnapshotrestore", volsnapmoverv1alpha1.RsyncDataMover))
+}
+
+func (m *rsyncDataMover) buildReplicationDestinationSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore,
+	secret *corev1.Secret, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationDestinationSpec, error) {
+
+	capacity, err := getBackedUpCapacity(vsr)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsSpec, err := r.configureRepDestVolOptions(vsr, capacity, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// the replicationsource connects from the same cluster
+	serviceType := corev1.ServiceTypeClusterIP
+
+	replicationDestinationSpec := volsyncv1alpha1.ReplicationDestinationSpec{
+		Trigger: &volsyncv1alpha1.ReplicationDestinationTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		Rsync: &volsyncv1alpha1.ReplicationDestinationRsyncSpec{
+			ReplicationDestinationVolumeOptions: *optionsSpec,
+			ServiceType:                         &serviceType,
+			MoverServiceAccount:                 &sa.Name,
+		},
+	}
+
+	return &replicationDestinationSpec, nil
+}
+
+func (m *rsyncDataMover) buildCloneReplicationSourceSpec(r *VolumeSnapshotRestoreReconciler, vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, pvcName string,
+	repDest *volsyncv1alpha1.ReplicationDestination, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, sa *corev1.ServiceAccount) (*volsyncv1alpha1.ReplicationSourceSpec, error) {
+
+	if repDest == nil {
+		return nil, errors.New("nil repDest in buildCloneReplicationSourceSpec")
+	}
+
+	// wait for VolSync to publish the address and ssh keys of the replicationdestination
+	if repDest.Status == nil || repDest.Status.Rsync == nil || repDest.Status.Rsync.Address == nil || repDest.Status.Rsync.SSHKeys == nil {
+		return nil, nil
+	}
+
+	replicationSourceSpec := volsyncv1alpha1.ReplicationSourceSpec{
+		SourcePVC: pvcName,
+		Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+			Manual: fmt.Sprintf("%s-trigger", vsr.Name),
+		},
+		Rsync: &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+			ReplicationSourceVolumeOptions: getCloneRepSourceVolOptions(),
+			SSHKeys:                        repDest.Status.Rsync.SSHKeys,
+			Address:                        repDest.Status.Rsync.Address,
+			Port:                           repDest.Status.Rsync.Port,
+			MoverServiceAccount:            &sa.Name,
+		},
+	}
+
+	return &replicationSourceSpec, nil
+}
+
+func (m *rsyncDataMover) isReplicationSourceCompleted(repSource *volsyncv1alpha1.ReplicationSource) bool {
+	return repSourceSyncCompleted(repSource)
+}
+
+func (m *rsyncDataMover) isReplicationDestinationCompleted(repDest *volsyncv1alpha1.ReplicationDestination) bool {
+	return repDestSyncCompleted(repDest)
+}
+
+// getCloneRepSourceVolOptions returns the volume options of the replicationsource reading the cloned PVC,
+// which is already a point in time copy of the volume
+func getCloneRepSourceVolOptions() volsyncv1alpha1.ReplicationSourceVolumeOptions {
+	return volsyncv1alpha1.ReplicationSourceVolumeOptions{
+		CopyMethod: volsyncv1alpha1.CopyMethodDirect,
+	}
+}
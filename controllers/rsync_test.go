@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildCloneReplicationSourceSpec(t *testing.T) {
+	address := "10.0.0.1"
+	keySecret := "rsync-tls-key"
+	sshKeys := "rsync-ssh-keys"
+	port := int32(8000)
+	saName := "velero"
+
+	vsr := &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "sample-vsr",
+			Namespace: "bar",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+			ProtectedNamespace: namespace,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		mover   cloneMover
+		repDest *volsyncv1alpha1.ReplicationDestination
+		want    *volsyncv1alpha1.ReplicationSourceSpec
+		wantErr bool
+	}{
+		{
+			name:    "Given nil repDest -> error",
+			mover:   &rsyncTLSDataMover{},
+			repDest: nil,
+			wantErr: true,
+		},
+		{
+			name:    "Given rsync-tls repDest without address -> wait",
+			mover:   &rsyncTLSDataMover{},
+			repDest: &volsyncv1alpha1.ReplicationDestination{},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:  "Given rsync repDest with only rsync-tls status -> wait",
+			mover: &rsyncDataMover{},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				Status: &volsyncv1alpha1.ReplicationDestinationStatus{
+					RsyncTLS: &volsyncv1alpha1.ReplicationDestinationRsyncTLSStatus{
+						Address:   &address,
+						KeySecret: &keySecret,
+					},
+				},
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:  "Given ready rsync-tls repDest -> rsync-tls replicationsource",
+			mover: &rsyncTLSDataMover{},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				Status: &volsyncv1alpha1.ReplicationDestinationStatus{
+					RsyncTLS: &volsyncv1alpha1.ReplicationDestinationRsyncTLSStatus{
+						Address:   &address,
+						KeySecret: &keySecret,
+						Port:      &port,
+					},
+				},
+			},
+			want: &volsyncv1alpha1.ReplicationSourceSpec{
+				SourcePVC: "snapcontent-pvc",
+				Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+					Manual: "sample-vsr-trigger",
+				},
+				RsyncTLS: &volsyncv1alpha1.ReplicationSourceRsyncTLSSpec{
+					ReplicationSourceVolumeOptions: volsyncv1alpha1.ReplicationSourceVolumeOptions{
+						CopyMethod: volsyncv1alpha1.CopyMethodDirect,
+					},
+					KeySecret:           &keySecret,
+					Address:             &address,
+					Port:                &port,
+					MoverServiceAccount: &saName,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Given ready rsync repDest -> rsync replicationsource",
+			mover: &rsyncDataMover{},
+			repDest: &volsyncv1alpha1.ReplicationDestination{
+				Status: &volsyncv1alpha1.ReplicationDestinationStatus{
+					Rsync: &volsyncv1alpha1.ReplicationDestinationRsyncStatus{
+						Address: &address,
+						SSHKeys: &sshKeys,
+					},
+				},
+			},
+			want: &volsyncv1alpha1.ReplicationSourceSpec{
+				SourcePVC: "snapcontent-pvc",
+				Trigger: &volsyncv1alpha1.ReplicationSourceTriggerSpec{
+					Manual: "sample-vsr-trigger",
+				},
+				Rsync: &volsyncv1alpha1.ReplicationSourceRsyncSpec{
+					ReplicationSourceVolumeOptions: volsyncv1alpha1.ReplicationSourceVolumeOptions{
+						CopyMethod: volsyncv1alpha1.CopyMethodDirect,
+					},
+					SSHKeys:             &sshKeys,
+					Address:             &address,
+					MoverServiceAccount: &saName,
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &VolumeSnapshotRestoreReconciler{}
+			sa := &corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: saName, Namespace: namespace}}
+			got, err := tt.mover.buildCloneReplicationSourceSpec(r, vsr, "snapcontent-pvc", tt.repDest, nil, sa)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildCloneReplicationSourceSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildCloneReplicationSourceSpec() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// endpointDialTimeout bounds the connection fetching the certificates of an insecure endpoint
+const endpointDialTimeout = 10 * time.Second
+
+// getS3ResticRepository returns the restic repository of the BSL with the endpoint of S3-compatible providers.
+// When the restic secret sets s3Url, the host of RESTIC_REPOSITORY is replaced by that endpoint, and the bucket is
+// kept in the path so the repository is addressed path-style, as MinIO, Ceph RGW and ODF expect
+func getS3ResticRepository(resticRepo string, secret *corev1.Secret) (string, error) {
+	// if trailing '/' in user-created repo, remove it
+	resticRepo = strings.TrimRight(resticRepo, "/")
+	s3Url := strings.TrimRight(string(secret.Data[S3Url]), "/")
+	if len(s3Url) == 0 {
+		return resticRepo, nil
+	}
+
+	backend, location, found := strings.Cut(resticRepo, ":")
+	if !found || backend != "s3" {
+		return "", errors.New(fmt.Sprintf("%s is only supported by s3 restic repositories, got %q", S3Url, resticRepo))
+	}
+
+	// s3:https://host:port/bucket/prefix or s3:host/bucket/prefix
+	var path string
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("cannot parse restic repository %q: %v", resticRepo, err))
+		}
+		path = strings.Trim(u.Path, "/")
+	} else {
+		_, path, _ = strings.Cut(location, "/")
+		path = strings.Trim(path, "/")
+	}
+	if len(path) == 0 {
+		return "", errors.New(fmt.Sprintf("restic repository %q has no bucket", resticRepo))
+	}
+
+	if !strings.Contains(s3Url, "://") {
+		s3Url = fmt.Sprintf("https://%s", s3Url)
+	}
+	endpoint, err := url.Parse(s3Url)
+	if err != nil || len(endpoint.Host) == 0 || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return "", errors.New(fmt.Sprintf("invalid %s %q", S3Url, s3Url))
+	}
+
+	return fmt.Sprintf("s3:%s://%s/%s", endpoint.Scheme, endpoint.Host, path), nil
+}
+
+// isInsecureSkipTLSVerify returns whether the certificate of the endpoint of the repository is not verified
+func isInsecureSkipTLSVerify(secret *corev1.Secret) bool {
+	return strings.EqualFold(string(secret.Data[InsecureSkipTLSVerify]), "true")
+}
+
+// getEndpointCertificates returns the PEM encoded certificates presented by the https endpoint of an s3 restic
+// repository, without verifying them. Restic still checks they name the endpoint host
+func getEndpointCertificates(resticRepo string) ([]byte, error) {
+	backend, location, found := strings.Cut(resticRepo, ":")
+	if !found || backend != "s3" {
+		return nil, errors.New(fmt.Sprintf("%s is only supported by s3 restic repositories, got %q", InsecureSkipTLSVerify, resticRepo))
+	}
+
+	// s3:https://host:port/bucket/prefix or s3:host/bucket/prefix
+	host, _, _ := strings.Cut(location, "/")
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("cannot parse restic repository %q: %v", resticRepo, err))
+		}
+		if u.Scheme == "http" {
+			return nil, nil
+		}
+		host = u.Host
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "443")
+	}
+
+	dialer := &net.Dialer{Timeout: endpointDialTimeout}
+	// the certificates are only read here, insecureSkipTLSVerify asks for them to be trusted
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot fetch the certificates of endpoint %s: %v", host, err))
+	}
+	defer conn.Close()
+
+	var certs []byte
+	for _, cert := range conn.ConnectionState().PeerCertificates {
+		certs = append(certs, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return certs, nil
+}
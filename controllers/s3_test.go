@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newMinIOServer returns a MinIO-like TLS endpoint serving the objects of the velero bucket, it only answers
+// path-style requests and rejects the requests addressing the bucket in the host name
+func newMinIOServer(t *testing.T, objects map[string]string) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Host, "velero.") {
+			http.Error(w, "virtual host style requests are not supported", http.StatusBadRequest)
+			return
+		}
+		object, ok := objects[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(object))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newMinIOSecret returns the restic secret of a MinIO-like BSL, region-less and addressed path-style
+func newMinIOSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "minio-restic",
+			Namespace: namespace,
+			Labels:    map[string]string{OADPBSLProviderName: AWSProvider},
+		},
+		Data: map[string][]byte{
+			AWSAccessKey:     []byte("minio"),
+			AWSSecretKey:     []byte("minio123"),
+			ResticPassword:   []byte("password"),
+			ResticRepository: []byte("s3:s3.amazonaws.com/velero/restic/"),
+		},
+	}
+	for key, val := range data {
+		secret.Data[key] = []byte(val)
+	}
+	return secret
+}
+
+func TestGetS3ResticRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   *corev1.Secret
+		wantRepo string
+		wantErr  bool
+	}{
+		{
+			name:     "Given no s3Url -> repository unchanged",
+			secret:   newMinIOSecret(nil),
+			wantRepo: "s3:s3.amazonaws.com/velero/restic",
+		},
+		{
+			name:     "Given MinIO s3Url -> repository on the MinIO endpoint",
+			secret:   newMinIOSecret(map[string]string{S3Url: "http://minio.minio.svc:9000/"}),
+			wantRepo: "s3:http://minio.minio.svc:9000/velero/restic",
+		},
+		{
+			name:     "Given s3Url without scheme -> https endpoint",
+			secret:   newMinIOSecret(map[string]string{S3Url: "rgw.ceph.example.com"}),
+			wantRepo: "s3:https://rgw.ceph.example.com/velero/restic",
+		},
+		{
+			name: "Given repository already on an endpoint -> endpoint replaced",
+			secret: newMinIOSecret(map[string]string{
+				ResticRepository: "s3:https://old.example.com:9000/velero",
+				S3Url:            "https://s3.openshift-storage.svc:443",
+			}),
+			wantRepo: "s3:https://s3.openshift-storage.svc:443/velero",
+		},
+		{
+			name:    "Given s3Url with azure repository -> error",
+			secret:  newMinIOSecret(map[string]string{ResticRepository: "azure:container:/prefix", S3Url: "http://minio:9000"}),
+			wantErr: true,
+		},
+		{
+			name:    "Given repository without bucket -> error",
+			secret:  newMinIOSecret(map[string]string{ResticRepository: "s3:s3.amazonaws.com", S3Url: "http://minio:9000"}),
+			wantErr: true,
+		},
+		{
+			name:    "Given invalid s3Url -> error",
+			secret:  newMinIOSecret(map[string]string{S3Url: "ftp://minio:9000"}),
+			wantErr: true,
+		},
+		{
+			name:     "Given s3ForcePathStyle with AWS endpoint -> repository unchanged",
+			secret:   newMinIOSecret(map[string]string{S3ForcePathStyle: "true"}),
+			wantRepo: "s3:s3.amazonaws.com/velero/restic",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := getS3ResticRepository(string(tt.secret.Data[ResticRepository]), tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getS3ResticRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if repo != tt.wantRepo {
+				t.Errorf("getS3ResticRepository() = %v, want %v", repo, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestBuildResticSecret_S3Compatible(t *testing.T) {
+	tests := []struct {
+		name        string
+		givensecret *corev1.Secret
+		wantCA      string
+		wantKeys    map[string]string
+	}{
+		{
+			name: "Given MinIO secret with endpoint CA -> CA carried, path style not copied",
+			givensecret: newMinIOSecret(map[string]string{
+				AWSEndpointsCA:   "minio-ca",
+				S3ForcePathStyle: "true",
+			}),
+			wantCA:   "minio-ca",
+			wantKeys: map[string]string{},
+		},
+		{
+			name: "Given insecureSkipTLSVerify with http endpoint -> no CA",
+			givensecret: newMinIOSecret(map[string]string{
+				InsecureSkipTLSVerify: "true",
+			}),
+			wantCA:   "",
+			wantKeys: map[string]string{},
+		},
+		{
+			name: "Given restic custom CA -> restic custom CA kept",
+			givensecret: newMinIOSecret(map[string]string{
+				ResticCustomCA: "restic-ca",
+				AWSEndpointsCA: "minio-ca",
+			}),
+			wantCA:   "restic-ca",
+			wantKeys: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{}
+			if err := BuildResticSecret(tt.givensecret, secret, "s3:http://minio.minio.svc:9000/velero", "", &RetainPolicy{}, ""); err != nil {
+				t.Fatalf("BuildResticSecret() error = %v", err)
+			}
+			if got := string(secret.Data[ResticCustomCA]); got != tt.wantCA {
+				t.Errorf("BuildResticSecret() %s = %v, want %v", ResticCustomCA, got, tt.wantCA)
+			}
+			for _, key := range []string{S3ForcePathStyle, InsecureSkipTLSVerify} {
+				got, ok := secret.Data[key]
+				want, wantOk := tt.wantKeys[key]
+				if ok != wantOk || string(got) != want {
+					t.Errorf("BuildResticSecret() %s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateResticSecret_InsecureSkipTLSVerify(t *testing.T) {
+	for _, val := range []string{"false", "true"} {
+		if err := ValidateResticSecret(newMinIOSecret(map[string]string{InsecureSkipTLSVerify: val})); err != nil {
+			t.Errorf("ValidateResticSecret() with %s %s error = %v", InsecureSkipTLSVerify, val, err)
+		}
+	}
+}
+
+func TestS3CompatibleEndpoint(t *testing.T) {
+	server := newMinIOServer(t, map[string]string{"velero/restic/config": "restic repository config"})
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name    string
+		data    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Given endpoint CA -> repository config read path-style",
+			data: map[string]string{S3Url: server.URL, AWSEndpointsCA: serverCA},
+		},
+		{
+			name: "Given insecureSkipTLSVerify -> certificate of the endpoint trusted",
+			data: map[string]string{S3Url: server.URL, InsecureSkipTLSVerify: "true"},
+		},
+		{
+			name:    "Given no endpoint CA -> certificate of the endpoint not verified",
+			data:    map[string]string{S3Url: server.URL},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			givensecret := newMinIOSecret(tt.data)
+			if err := ValidateResticSecret(givensecret); err != nil {
+				t.Fatalf("ValidateResticSecret() error = %v", err)
+			}
+			repo, err := getS3ResticRepository(string(givensecret.Data[ResticRepository]), givensecret)
+			if err != nil {
+				t.Fatalf("getS3ResticRepository() error = %v", err)
+			}
+			secret := &corev1.Secret{}
+			if err := BuildResticSecret(givensecret, secret, repo, "", &RetainPolicy{}, ""); err != nil {
+				t.Fatalf("BuildResticSecret() error = %v", err)
+			}
+
+			// read the repository config as restic does, with the CA of the mover secret
+			roots := x509.NewCertPool()
+			roots.AppendCertsFromPEM(secret.Data[ResticCustomCA])
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+			resp, err := client.Get(strings.TrimPrefix(string(secret.Data[ResticRepository]), "s3:") + "/config")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reading the repository config error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("reading the repository config status = %v, want %v", resp.Status, http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BatchScheduler limits the number of volumesnapshotbackups or volumesnapshotrestores
+// moving data at the same time. Slot accounting is rebuilt from the batching status
+// of the objects in the cluster the first time it is used, so objects that were
+// processing before a restart or leader change keep their slots.
+// The limit read from the controller deployment can be overridden at any time by a
+// DataMoverConfig, lowering it lets the objects holding a slot finish.
+// All methods are safe for concurrent use by multiple reconciles.
+type BatchScheduler struct {
+	mu              sync.Mutex
+	limit           int
+	configuredLimit int
+	synced          bool
+	slots           map[types.NamespacedName]struct{}
+	queued          map[types.NamespacedName]struct{}
+}
+
+// NewBatchScheduler returns a scheduler with no slots until a limit is set
+func NewBatchScheduler() *BatchScheduler {
+	return &BatchScheduler{
+		slots:  map[types.NamespacedName]struct{}{},
+		queued: map[types.NamespacedName]struct{}{},
+	}
+}
+
+// Sync rebuilds the slot accounting from the keys returned by list. It only
+// runs once, later calls are no-ops
+func (s *BatchScheduler) Sync(list func() ([]types.NamespacedName, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.synced {
+		return nil
+	}
+
+	keys, err := list()
+	if err != nil {
+		return err
+	}
+
+	s.slots = map[types.NamespacedName]struct{}{}
+	for _, key := range keys {
+		s.slots[key] = struct{}{}
+	}
+	s.synced = true
+
+	return nil
+}
+
+// Limit returns the maximum number of objects processed at the same time
+func (s *BatchScheduler) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.currentLimit()
+}
+
+// SetLimit sets the default maximum number of objects processed at the same time
+func (s *BatchScheduler) SetLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+}
+
+// SetConfiguredLimit overrides the default limit, 0 removes the override
+func (s *BatchScheduler) SetConfiguredLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configuredLimit = limit
+}
+
+func (s *BatchScheduler) currentLimit() int {
+	if s.configuredLimit > 0 {
+		return s.configuredLimit
+	}
+
+	return s.limit
+}
+
+// TryAcquire returns true if key holds a slot, taking a free slot if it does not have one yet
+func (s *BatchScheduler) TryAcquire(key types.NamespacedName) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.slots[key]; ok {
+		return true
+	}
+
+	if len(s.slots) >= s.currentLimit() {
+		s.queued[key] = struct{}{}
+		return false
+	}
+
+	s.slots[key] = struct{}{}
+	delete(s.queued, key)
+	return true
+}
+
+// Track records key as holding a slot even if the limit has been reached.
+// Used for objects that were already marked as processing
+func (s *BatchScheduler) Track(key types.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slots[key] = struct{}{}
+	delete(s.queued, key)
+}
+
+// Release frees the slot held by key, if any, and removes key from the queue
+func (s *BatchScheduler) Release(key types.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.slots, key)
+	delete(s.queued, key)
+}
+
+// Queued returns the number of objects that were refused a slot and are still waiting
+func (s *BatchScheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queued)
+}
+
+// QueuedObject is an object waiting for a slot
+type QueuedObject struct {
+	Key               types.NamespacedName
+	Priority          int32
+	CreationTimestamp metav1.Time
+}
+
+// Schedule gives key a free slot if it is next in line among the waiting objects.
+// Waiting objects are ordered by priority, then by the number of slots already held
+// in their namespace so a single namespace cannot starve the others, then by age.
+// It returns true if key holds a slot, otherwise the position of key in the queue
+// starting at 1
+func (s *BatchScheduler) Schedule(key types.NamespacedName, waiting []QueuedObject) (bool, int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.slots[key]; ok {
+		return true, 0
+	}
+
+	held := map[string]int{}
+	for slot := range s.slots {
+		held[slot.Namespace]++
+	}
+
+	// keys missing from the waiting list, e.g. because of a stale cache, go last
+	order := queueOrder(waiting, held)
+	position := len(order)
+	for i, queued := range order {
+		if queued == key {
+			position = i
+			break
+		}
+	}
+
+	if position < s.currentLimit()-len(s.slots) {
+		s.slots[key] = struct{}{}
+		delete(s.queued, key)
+		return true, 0
+	}
+
+	s.queued[key] = struct{}{}
+	return false, int32(position + 1)
+}
+
+// queueOrder returns the order in which the waiting objects get a slot, given
+// the number of slots held per namespace
+func queueOrder(waiting []QueuedObject, held map[string]int) []types.NamespacedName {
+	counts := map[string]int{}
+	for ns, count := range held {
+		counts[ns] = count
+	}
+
+	remaining := append([]QueuedObject{}, waiting...)
+	order := make([]types.NamespacedName, 0, len(remaining))
+	for len(remaining) > 0 {
+		next := 0
+		for i := 1; i < len(remaining); i++ {
+			if queuedBefore(remaining[i], remaining[next], counts) {
+				next = i
+			}
+		}
+
+		order = append(order, remaining[next].Key)
+		counts[remaining[next].Key.Namespace]++
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return order
+}
+
+func queuedBefore(a, b QueuedObject, counts map[string]int) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if counts[a.Key.Namespace] != counts[b.Key.Namespace] {
+		return counts[a.Key.Namespace] < counts[b.Key.Namespace]
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Key.String() < b.Key.String()
+}
+
+// InUse returns the number of slots currently held
+func (s *BatchScheduler) InUse() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.slots)
+}
+
+// processingVSBKeys returns the volumesnapshotbackups that are moving data
+func processingVSBKeys(c client.Client) ([]types.NamespacedName, error) {
+	vsbList := volsnapmoverv1alpha1.VolumeSnapshotBackupList{}
+	if err := c.List(context.Background(), &vsbList); err != nil {
+		return nil, err
+	}
+
+	keys := []types.NamespacedName{}
+	for _, vsb := range vsbList.Items {
+		if vsb.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing && !isVSBPhaseTerminal(vsb.Status.Phase) {
+			keys = append(keys, types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name})
+		}
+	}
+
+	return keys, nil
+}
+
+// queuedVSBs returns the volumesnapshotbackups waiting for a slot
+func queuedVSBs(c client.Client) ([]QueuedObject, error) {
+	vsbList := volsnapmoverv1alpha1.VolumeSnapshotBackupList{}
+	if err := c.List(context.Background(), &vsbList); err != nil {
+		return nil, err
+	}
+
+	queued := []QueuedObject{}
+	for _, vsb := range vsbList.Items {
+		if vsb.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing ||
+			vsb.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverBackupBatchingCompleted ||
+			isVSBPhaseTerminal(vsb.Status.Phase) || !vsb.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		// invalid priorities are rejected by the webhook, treat them as the default otherwise
+		priority, _ := vsb.GetPriority()
+		queued = append(queued, QueuedObject{
+			Key:               types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name},
+			Priority:          priority,
+			CreationTimestamp: vsb.CreationTimestamp,
+		})
+	}
+
+	return queued, nil
+}
+
+// processingVSRKeys returns the volumesnapshotrestores that are moving data
+func processingVSRKeys(c client.Client) ([]types.NamespacedName, error) {
+	vsrList := volsnapmoverv1alpha1.VolumeSnapshotRestoreList{}
+	if err := c.List(context.Background(), &vsrList); err != nil {
+		return nil, err
+	}
+
+	keys := []types.NamespacedName{}
+	for _, vsr := range vsrList.Items {
+		if vsr.Status.BatchingStatus == volsnapmoverv1alpha1.SnapMoverRestoreBatchingProcessing && !isVSRPhaseTerminal(vsr.Status.Phase) {
+			keys = append(keys, types.NamespacedName{Namespace: vsr.Namespace, Name: vsr.Name})
+		}
+	}
+
+	return keys, nil
+}
+
+func isVSBPhaseTerminal(phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase) bool {
+	return phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted ||
+		phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed ||
+		phase == volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed
+}
+
+func isVSRPhaseTerminal(phase volsnapmoverv1alpha1.VolumeSnapshotRestorePhase) bool {
+	return phase == volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted ||
+		phase == volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed ||
+		phase == volsnapmoverv1alpha1.SnapMoverRestorePhasePartiallyFailed
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBatchScheduler_TryAcquire(t *testing.T) {
+	keyA := types.NamespacedName{Namespace: "bar", Name: "vsb-a"}
+	keyB := types.NamespacedName{Namespace: "bar", Name: "vsb-b"}
+	tests := []struct {
+		name  string
+		limit int
+		held  []types.NamespacedName
+		key   types.NamespacedName
+		want  bool
+	}{
+		{
+			name:  "Given free slot -> slot acquired",
+			limit: 1,
+			key:   keyA,
+			want:  true,
+		},
+		{
+			name:  "Given no free slot -> slot not acquired",
+			limit: 1,
+			held:  []types.NamespacedName{keyB},
+			key:   keyA,
+			want:  false,
+		},
+		{
+			name:  "Given key already holds a slot -> slot kept",
+			limit: 1,
+			held:  []types.NamespacedName{keyA},
+			key:   keyA,
+			want:  true,
+		},
+		{
+			name:  "Given no limit set -> slot not acquired",
+			limit: 0,
+			key:   keyA,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewBatchScheduler()
+			s.SetLimit(tt.limit)
+			for _, key := range tt.held {
+				s.Track(key)
+			}
+			if got := s.TryAcquire(tt.key); got != tt.want {
+				t.Errorf("TryAcquire() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchScheduler_ConfiguredLimit(t *testing.T) {
+	keyA := types.NamespacedName{Namespace: "bar", Name: "vsb-a"}
+	keyB := types.NamespacedName{Namespace: "bar", Name: "vsb-b"}
+
+	s := NewBatchScheduler()
+	s.SetLimit(1)
+	if !s.TryAcquire(keyA) || s.TryAcquire(keyB) {
+		t.Fatalf("expected only one slot with the default limit")
+	}
+
+	// raising the limit lets queued objects in without a restart
+	s.SetConfiguredLimit(2)
+	if !s.TryAcquire(keyB) {
+		t.Fatalf("expected a free slot after raising the limit")
+	}
+
+	// removing the override goes back to the default limit
+	s.SetConfiguredLimit(0)
+	s.Release(keyB)
+	if s.TryAcquire(keyB) {
+		t.Errorf("expected no free slot after removing the override")
+	}
+}
+
+func TestBatchScheduler_ConcurrentReconciles(t *testing.T) {
+	s := NewBatchScheduler()
+	s.SetLimit(3)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := types.NamespacedName{Namespace: "bar", Name: fmt.Sprintf("vsb-%d", i)}
+			if s.TryAcquire(key) {
+				mu.Lock()
+				acquired++
+				mu.Unlock()
+			}
+			// releasing keys that never held a slot must not free other slots
+			s.Release(types.NamespacedName{Namespace: "baz", Name: fmt.Sprintf("vsb-%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	if acquired != 3 {
+		t.Errorf("acquired %d slots, want 3", acquired)
+	}
+	if s.InUse() != 3 {
+		t.Errorf("InUse() = %d, want 3", s.InUse())
+	}
+}
+
+func TestBatchScheduler_Schedule(t *testing.T) {
+	created := func(minutes int) v1.Time {
+		return v1.NewTime(time.Date(2023, 1, 1, 0, minutes, 0, 0, time.UTC))
+	}
+	key := func(ns, name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: ns, Name: name}
+	}
+	tests := []struct {
+		name         string
+		limit        int
+		held         []types.NamespacedName
+		waiting      []QueuedObject
+		key          types.NamespacedName
+		wantSlot     bool
+		wantPosition int32
+	}{
+		{
+			name:  "Given older vsb waiting -> newer vsb queued behind it",
+			limit: 1,
+			waiting: []QueuedObject{
+				{Key: key("app", "new"), CreationTimestamp: created(2)},
+				{Key: key("app", "old"), CreationTimestamp: created(1)},
+			},
+			key:          key("app", "new"),
+			wantSlot:     false,
+			wantPosition: 2,
+		},
+		{
+			name:  "Given oldest vsb and free slot -> slot acquired",
+			limit: 1,
+			waiting: []QueuedObject{
+				{Key: key("app", "new"), CreationTimestamp: created(2)},
+				{Key: key("app", "old"), CreationTimestamp: created(1)},
+			},
+			key:      key("app", "old"),
+			wantSlot: true,
+		},
+		{
+			name:  "Given higher priority vsb -> slot acquired before older vsb",
+			limit: 1,
+			waiting: []QueuedObject{
+				{Key: key("app", "old"), CreationTimestamp: created(1)},
+				{Key: key("app", "urgent"), Priority: 10, CreationTimestamp: created(2)},
+			},
+			key:      key("app", "urgent"),
+			wantSlot: true,
+		},
+		{
+			name:  "Given namespace already holding slots -> other namespace goes first",
+			limit: 3,
+			held:  []types.NamespacedName{key("big", "a"), key("big", "b")},
+			waiting: []QueuedObject{
+				{Key: key("big", "c"), CreationTimestamp: created(1)},
+				{Key: key("small", "a"), CreationTimestamp: created(2)},
+			},
+			key:          key("big", "c"),
+			wantSlot:     false,
+			wantPosition: 2,
+		},
+		{
+			name:  "Given many vsbs in one namespace -> namespaces alternate in the queue",
+			limit: 0,
+			waiting: []QueuedObject{
+				{Key: key("big", "a"), CreationTimestamp: created(1)},
+				{Key: key("big", "b"), CreationTimestamp: created(2)},
+				{Key: key("big", "c"), CreationTimestamp: created(3)},
+				{Key: key("small", "a"), CreationTimestamp: created(4)},
+				{Key: key("small", "b"), CreationTimestamp: created(5)},
+			},
+			key:          key("small", "b"),
+			wantSlot:     false,
+			wantPosition: 4,
+		},
+		{
+			name:  "Given vsb missing from waiting list -> queued last",
+			limit: 1,
+			waiting: []QueuedObject{
+				{Key: key("app", "old"), CreationTimestamp: created(1)},
+			},
+			key:          key("app", "new"),
+			wantSlot:     false,
+			wantPosition: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewBatchScheduler()
+			s.SetLimit(tt.limit)
+			for _, key := range tt.held {
+				s.Track(key)
+			}
+			gotSlot, gotPosition := s.Schedule(tt.key, tt.waiting)
+			if gotSlot != tt.wantSlot || gotPosition != tt.wantPosition {
+				t.Errorf("Schedule() = %v, %v, want %v, %v", gotSlot, gotPosition, tt.wantSlot, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestVolumeSnapshotBackupReconciler_setVSBQueueAfterRestart(t *testing.T) {
+	newVSB := func(name string, batching volsnapmoverv1alpha1.VolumeSnapshotBackupBatchingStatus, phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: "bar",
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+				ProtectedNamespace: namespace,
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				BatchingStatus: batching,
+				Phase:          phase,
+			},
+		}
+	}
+
+	fakeClient, err := getFakeClientFromObjects(
+		newVSB("vsb-processing", volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing, volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress),
+		newVSB("vsb-failed", volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed),
+		newVSB("vsb-queued", volsnapmoverv1alpha1.SnapMoverBackupBatchingQueued, ""),
+	)
+	if err != nil {
+		t.Fatalf("error creating fake client, likely programmer error")
+	}
+
+	// simulates a freshly started controller
+	newReconciler := func(name string) *VolumeSnapshotBackupReconciler {
+		return &VolumeSnapshotBackupReconciler{
+			Client:        fakeClient,
+			Scheme:        fakeClient.Scheme(),
+			Log:           logr.Discard(),
+			Context:       newContextForTest(name),
+			EventRecorder: record.NewFakeRecorder(10),
+			req: reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: "bar", Name: name},
+			},
+		}
+	}
+	newScheduler := func() *BatchScheduler {
+		s := NewBatchScheduler()
+		s.SetLimit(1)
+		if err := s.Sync(func() ([]types.NamespacedName, error) { return processingVSBKeys(fakeClient) }); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		return s
+	}
+
+	scheduler := newScheduler()
+	if scheduler.InUse() != 1 {
+		t.Fatalf("InUse() after restart = %d, want 1", scheduler.InUse())
+	}
+
+	queued := getVSB(t, fakeClient, "vsb-queued")
+	r := newReconciler("vsb-queued")
+	r.Scheduler = scheduler
+	processed, err := r.setVSBQueue(queued, r.Log)
+	if err != nil || processed {
+		t.Fatalf("setVSBQueue() = %v, %v, want queued vsb to wait for the in-flight vsb", processed, err)
+	}
+	if got := getVSB(t, fakeClient, "vsb-queued").Status.QueuePosition; got != 1 {
+		t.Fatalf("queue position = %v, want 1", got)
+	}
+
+	// in-flight vsb completes and frees its slot
+	scheduler.Release(types.NamespacedName{Namespace: "bar", Name: "vsb-processing"})
+	processed, err = r.setVSBQueue(queued, r.Log)
+	if err != nil || !processed {
+		t.Fatalf("setVSBQueue() = %v, %v, want queued vsb to be processed", processed, err)
+	}
+	if got := getVSB(t, fakeClient, "vsb-queued").Status; got.BatchingStatus != volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing || got.QueuePosition != 0 {
+		t.Fatalf("batching status = %s at position %v, want %s", got.BatchingStatus, got.QueuePosition, volsnapmoverv1alpha1.SnapMoverBackupBatchingProcessing)
+	}
+
+	// another restart must keep counting the vsbs marked as processing
+	if got := newScheduler().InUse(); got != 2 {
+		t.Errorf("InUse() after second restart = %d, want 2", got)
+	}
+}
+
+func getVSB(t *testing.T, c client.Client, name string) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	if err := c.Get(newContextForTest(name), types.NamespacedName{Namespace: "bar", Name: name}, &vsb); err != nil {
+		t.Fatalf("unable to fetch vsb %s: %v", name, err)
+	}
+	return &vsb
+}
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=datamoverstorageclassconfigs,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=datamoverstorageclassconfigs/status,verbs=get;update;patch
+
+// maximum number of volumesnapshotbackups and of volumesnapshotrestores listed in the status of a DataMoverStorageClassConfig
+const maxStorageClassConfigUsers = 50
+
+// configMapKeys are the keys of the volume options in a "<storageclass>-config" ConfigMap
+type configMapKeys struct {
+	storageClassName      string
+	accessMode            string
+	cacheStorageClassName string
+	cacheAccessMode       string
+	cacheCapacity         string
+	moverSecurityContext  string
+}
+
+var sourceConfigMapKeys = configMapKeys{
+	storageClassName:      SourceStorageClassName,
+	accessMode:            SourceAccessMoce,
+	cacheStorageClassName: SourceCacheStorageClassName,
+	cacheAccessMode:       SourceCacheAccessMoce,
+	cacheCapacity:         SourceCacheCapacity,
+	moverSecurityContext:  SourceMoverSecurityContext,
+}
+
+var destinationConfigMapKeys = configMapKeys{
+	storageClassName:      DestinationStorageClassName,
+	accessMode:            DestinationAccessMoce,
+	cacheStorageClassName: DestinationCacheStorageClassName,
+	cacheAccessMode:       DestinationCacheAccessMoce,
+	cacheCapacity:         DestinationCacheCapacity,
+	moverSecurityContext:  DestinationMoverSecurityContext,
+}
+
+// GetDataMoverStorageClassConfig returns the DataMoverStorageClassConfig of a storage class, or nil if the storage
+// class is not configured. It does not write: a "<storageclass>-config" ConfigMap that the DataMoverConfigMap
+// controller has not migrated yet is converted in memory
+func GetDataMoverStorageClassConfig(namespace string, sc string, log logr.Logger, c client.Client) (*volsnapmoverv1alpha1.DataMoverStorageClassConfig, error) {
+	if len(sc) == 0 {
+		return nil, nil
+	}
+
+	config := volsnapmoverv1alpha1.DataMoverStorageClassConfig{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: sc}, &config)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, errors.New(fmt.Sprintf("failed to get data mover storage class config %s/%s", namespace, sc))
+	}
+	found := err == nil
+
+	// a configuration created for the storage class takes precedence over the ConfigMap
+	if found && len(config.Annotations[volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation]) == 0 {
+		return &config, nil
+	}
+
+	cm, err := GetDataMoverConfigMap(namespace, sc, log, c)
+	if err != nil {
+		return nil, err
+	}
+	// the configuration migrated from a deleted ConfigMap is garbage collected
+	if cm == nil {
+		return nil, nil
+	}
+	if found && config.Annotations[volsnapmoverv1alpha1.MigratedResourceVersionAnnotation] == cm.ResourceVersion {
+		return &config, nil
+	}
+
+	spec, err := convertDataMoverConfigMap(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sc,
+			Namespace: namespace,
+		},
+		Spec: *spec,
+	}, nil
+}
+
+// migrateDataMoverConfigMap creates or updates the DataMoverStorageClassConfig of a storage class from its
+// ConfigMap, controlled by the ConfigMap
+func migrateDataMoverConfigMap(cm *corev1.ConfigMap, sc string, log logr.Logger, c client.Client) (*volsnapmoverv1alpha1.DataMoverStorageClassConfig, error) {
+	spec, err := convertDataMoverConfigMap(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sc,
+			Namespace: cm.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(context.Background(), c, config, func() error {
+		if config.Annotations == nil {
+			config.Annotations = map[string]string{}
+		}
+		config.Annotations[volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation] = cm.Name
+		config.Annotations[volsnapmoverv1alpha1.MigratedResourceVersionAnnotation] = cm.ResourceVersion
+		config.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       cm.Name,
+				UID:        cm.UID,
+				Controller: pointer.Bool(true),
+			},
+		}
+		config.Spec = *spec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if op != controllerutil.OperationResultNone {
+		log.Info(fmt.Sprintf("migrated configmap %s/%s to datamoverstorageclassconfig %s", cm.Namespace, cm.Name, sc))
+	}
+
+	return config, nil
+}
+
+// convertDataMoverConfigMap returns the DataMoverStorageClassConfig spec of a "<storageclass>-config" ConfigMap
+func convertDataMoverConfigMap(cm *corev1.ConfigMap) (*volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec, error) {
+	if cm == nil {
+		return nil, errors.New("nil configmap in convertDataMoverConfigMap")
+	}
+
+	source, err := convertVolumeOptions(cm, sourceConfigMapKeys)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := convertVolumeOptions(cm, destinationConfigMapKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+		Source:      *source,
+		Destination: *destination,
+	}, nil
+}
+
+func convertVolumeOptions(cm *corev1.ConfigMap, keys configMapKeys) (*volsnapmoverv1alpha1.DataMoverVolumeOptions, error) {
+	options := volsnapmoverv1alpha1.DataMoverVolumeOptions{
+		StorageClassName:      cm.Data[keys.storageClassName],
+		AccessMode:            corev1.PersistentVolumeAccessMode(cm.Data[keys.accessMode]),
+		CacheStorageClassName: cm.Data[keys.cacheStorageClassName],
+		CacheAccessMode:       corev1.PersistentVolumeAccessMode(cm.Data[keys.cacheAccessMode]),
+	}
+
+	for _, key := range []string{keys.accessMode, keys.cacheAccessMode} {
+		if val, ok := cm.Data[key]; ok && !isValidAccessMode(corev1.PersistentVolumeAccessMode(val)) {
+			return nil, errors.New(fmt.Sprintf("invalid %s %s in configmap %s/%s", key, val, cm.Namespace, cm.Name))
+		}
+	}
+
+	if val, ok := cm.Data[keys.cacheCapacity]; ok {
+		capacity, err := resource.ParseQuantity(val)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s %s in configmap %s/%s", keys.cacheCapacity, val, cm.Namespace, cm.Name))
+		}
+		options.CacheCapacity = &capacity
+	}
+
+	if val, ok := cm.Data[keys.moverSecurityContext]; ok {
+		moverSecurityContext, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s %s in configmap %s/%s", keys.moverSecurityContext, val, cm.Namespace, cm.Name))
+		}
+		options.MoverSecurityContext = moverSecurityContext
+	}
+
+	return &options, nil
+}
+
+func isValidAccessMode(accessMode corev1.PersistentVolumeAccessMode) bool {
+	switch accessMode {
+	case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany, corev1.ReadWriteOncePod:
+		return true
+	}
+
+	return false
+}
+
+// sourceVolumeOptions returns the volume options of the volumesnapshotbackups of a storage class
+func sourceVolumeOptions(config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) volsnapmoverv1alpha1.DataMoverVolumeOptions {
+	if config == nil {
+		return volsnapmoverv1alpha1.DataMoverVolumeOptions{}
+	}
+
+	return config.Spec.Source
+}
+
+// destinationVolumeOptions returns the volume options of the volumesnapshotrestores of a storage class
+func destinationVolumeOptions(config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) volsnapmoverv1alpha1.DataMoverVolumeOptions {
+	if config == nil {
+		return volsnapmoverv1alpha1.DataMoverVolumeOptions{}
+	}
+
+	return config.Spec.Destination
+}
+
+// backupVolumeOptions returns the volume options of a volumesnapshotbackup, its spec volume options taking
+// precedence over the source options of the storage class config
+func backupVolumeOptions(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) volsnapmoverv1alpha1.DataMoverVolumeOptions {
+	return mergeVolumeOptions(sourceVolumeOptions(config), vsb.Spec.VolumeOptions)
+}
+
+// restoreVolumeOptions returns the volume options of a volumesnapshotrestore, its spec volume options taking
+// precedence over the destination options of the storage class config
+func restoreVolumeOptions(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, config *volsnapmoverv1alpha1.DataMoverStorageClassConfig) volsnapmoverv1alpha1.DataMoverVolumeOptions {
+	return mergeVolumeOptions(destinationVolumeOptions(config), vsr.Spec.VolumeOptions)
+}
+
+// mergeVolumeOptions overrides the storage class volume options with the ones set in a spec
+func mergeVolumeOptions(options volsnapmoverv1alpha1.DataMoverVolumeOptions, overrides *volsnapmoverv1alpha1.VolumeOptions) volsnapmoverv1alpha1.DataMoverVolumeOptions {
+	if overrides == nil {
+		return options
+	}
+
+	if len(overrides.StorageClassName) > 0 {
+		options.StorageClassName = overrides.StorageClassName
+	}
+	if len(overrides.AccessMode) > 0 {
+		options.AccessMode = overrides.AccessMode
+	}
+	if len(overrides.CacheStorageClassName) > 0 {
+		options.CacheStorageClassName = overrides.CacheStorageClassName
+	}
+	if len(overrides.CacheAccessMode) > 0 {
+		options.CacheAccessMode = overrides.CacheAccessMode
+	}
+	if overrides.CacheCapacity != nil {
+		options.CacheCapacity = overrides.CacheCapacity
+	}
+	if overrides.MoverSecurityContext != nil {
+		options.MoverSecurityContext = *overrides.MoverSecurityContext
+	}
+
+	return options
+}
+
+// recordStorageClassConfigUse lists a volumesnapshotbackup or a volumesnapshotrestore in the status of the
+// DataMoverStorageClassConfig it uses, keeping the latest ones
+func recordStorageClassConfigUse(config *volsnapmoverv1alpha1.DataMoverStorageClassConfig, obj client.Object, c client.Client) error {
+	// a ConfigMap converted in memory has no status until it is migrated
+	if config == nil || len(config.ResourceVersion) == 0 {
+		return nil
+	}
+
+	users := &config.Status.VolumeSnapshotBackups
+	if _, ok := obj.(*volsnapmoverv1alpha1.VolumeSnapshotRestore); ok {
+		users = &config.Status.VolumeSnapshotRestores
+	}
+
+	name := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	for _, user := range *users {
+		if user == name {
+			return nil
+		}
+	}
+
+	*users = append(*users, name)
+	if len(*users) > maxStorageClassConfigUsers {
+		*users = (*users)[len(*users)-maxStorageClassConfigUsers:]
+	}
+	now := metav1.Now()
+	config.Status.LastUsedTimestamp = &now
+
+	return c.Status().Update(context.Background(), config)
+}
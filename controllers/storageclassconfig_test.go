@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetDataMoverStorageClassConfig(t *testing.T) {
+	cacheCapacity := resource.MustParse("2Gi")
+	newConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "gp2-config", Namespace: namespace, UID: "cm-uid"},
+			Data:       data,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		wantNil  bool
+		wantSpec volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec
+		wantErr  bool
+	}{
+		{
+			name:    "Given no config -> nil",
+			wantNil: true,
+		},
+		{
+			name: "Given configmap -> converted",
+			objs: []client.Object{
+				newConfigMap(map[string]string{
+					SourceStorageClassName:          "gp3",
+					SourceCacheCapacity:             "2Gi",
+					SourceMoverSecurityContext:      "true",
+					DestinationAccessMoce:           string(corev1.ReadWriteMany),
+					DestinationCacheAccessMoce:      string(corev1.ReadWriteOnce),
+					DestinationMoverSecurityContext: "false",
+				}),
+			},
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+					StorageClassName:     "gp3",
+					CacheCapacity:        &cacheCapacity,
+					MoverSecurityContext: true,
+				},
+				Destination: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+					AccessMode:      corev1.ReadWriteMany,
+					CacheAccessMode: corev1.ReadWriteOnce,
+				},
+			},
+		},
+		{
+			name: "Given config and configmap -> configmap ignored",
+			objs: []client.Object{
+				newConfigMap(map[string]string{SourceStorageClassName: "gp3"}),
+				&volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+					ObjectMeta: v1.ObjectMeta{Name: "gp2", Namespace: namespace},
+					Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+						Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+					},
+				},
+			},
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+			},
+		},
+		{
+			name: "Given config migrated from an older configmap -> configmap converted",
+			objs: []client.Object{
+				newConfigMap(map[string]string{SourceStorageClassName: "gp3"}),
+				&volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "gp2",
+						Namespace: namespace,
+						Annotations: map[string]string{
+							volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation:   "gp2-config",
+							volsnapmoverv1alpha1.MigratedResourceVersionAnnotation: "1",
+						},
+					},
+					Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+						Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "io1"},
+					},
+				},
+			},
+			wantSpec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+				Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{StorageClassName: "gp3"},
+			},
+		},
+		{
+			name: "Given config migrated from a deleted configmap -> nil",
+			objs: []client.Object{
+				&volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "gp2",
+						Namespace: namespace,
+						Annotations: map[string]string{
+							volsnapmoverv1alpha1.MigratedFromConfigMapAnnotation: "gp2-config",
+						},
+					},
+				},
+			},
+			wantNil: true,
+		},
+		{
+			name:    "Given invalid cache capacity -> error",
+			objs:    []client.Object{newConfigMap(map[string]string{SourceCacheCapacity: "2 Gi"})},
+			wantErr: true,
+		},
+		{
+			name:    "Given invalid access mode -> error",
+			objs:    []client.Object{newConfigMap(map[string]string{DestinationAccessMoce: "ReadWrite"})},
+			wantErr: true,
+		},
+		{
+			name:    "Given invalid mover security context -> error",
+			objs:    []client.Object{newConfigMap(map[string]string{SourceMoverSecurityContext: "yes"})},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+
+			got, err := GetDataMoverStorageClassConfig(namespace, "gp2", logr.Discard(), fakeClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDataMoverStorageClassConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("GetDataMoverStorageClassConfig() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Spec, tt.wantSpec) {
+				t.Errorf("spec = %v, want %v", got.Spec, tt.wantSpec)
+			}
+
+			// the getter never writes
+			for _, obj := range tt.objs {
+				stored := obj.DeepCopyObject().(client.Object)
+				if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), stored); err != nil {
+					t.Fatalf("failed to get %s: %v", obj.GetName(), err)
+				}
+				if stored.GetResourceVersion() != "999" {
+					t.Errorf("%s was updated", obj.GetName())
+				}
+			}
+			configs := volsnapmoverv1alpha1.DataMoverStorageClassConfigList{}
+			if err := fakeClient.List(context.Background(), &configs); err != nil {
+				t.Fatalf("failed to list datamoverstorageclassconfigs: %v", err)
+			}
+			if want := len(tt.objs) - 1; len(configs.Items) != want {
+				t.Errorf("datamoverstorageclassconfigs = %v, want %v", len(configs.Items), want)
+			}
+		})
+	}
+}
+
+func TestRecordStorageClassConfigUse(t *testing.T) {
+	users := []string{}
+	for i := 0; i < maxStorageClassConfigUsers; i++ {
+		users = append(users, fmt.Sprintf("%s/vsb-%d", namespace, i))
+	}
+	newConfig := func(backups []string) *volsnapmoverv1alpha1.DataMoverStorageClassConfig {
+		return &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+			ObjectMeta: v1.ObjectMeta{Name: "gp2", Namespace: namespace},
+			Status:     volsnapmoverv1alpha1.DataMoverStorageClassConfigStatus{VolumeSnapshotBackups: backups},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		config      *volsnapmoverv1alpha1.DataMoverStorageClassConfig
+		inMemory    bool
+		obj         client.Object
+		wantBackups []string
+		wantRestore []string
+	}{
+		{
+			name:        "Given vsb -> listed",
+			config:      newConfig(nil),
+			obj:         &volsnapmoverv1alpha1.VolumeSnapshotBackup{ObjectMeta: v1.ObjectMeta{Name: "sample-vsb", Namespace: namespace}},
+			wantBackups: []string{namespace + "/sample-vsb"},
+		},
+		{
+			name:        "Given vsr -> listed",
+			config:      newConfig(nil),
+			obj:         &volsnapmoverv1alpha1.VolumeSnapshotRestore{ObjectMeta: v1.ObjectMeta{Name: "sample-vsr", Namespace: namespace}},
+			wantRestore: []string{namespace + "/sample-vsr"},
+		},
+		{
+			name:        "Given listed vsb -> unchanged",
+			config:      newConfig([]string{namespace + "/sample-vsb"}),
+			obj:         &volsnapmoverv1alpha1.VolumeSnapshotBackup{ObjectMeta: v1.ObjectMeta{Name: "sample-vsb", Namespace: namespace}},
+			wantBackups: []string{namespace + "/sample-vsb"},
+		},
+		{
+			name:        "Given full list -> oldest vsb dropped",
+			config:      newConfig(users),
+			obj:         &volsnapmoverv1alpha1.VolumeSnapshotBackup{ObjectMeta: v1.ObjectMeta{Name: "sample-vsb", Namespace: namespace}},
+			wantBackups: append(append([]string{}, users[1:]...), namespace+"/sample-vsb"),
+		},
+		{
+			name:     "Given configmap converted in memory -> not recorded",
+			config:   newConfig(nil),
+			inMemory: true,
+			obj:      &volsnapmoverv1alpha1.VolumeSnapshotBackup{ObjectMeta: v1.ObjectMeta{Name: "sample-vsb", Namespace: namespace}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := []client.Object{}
+			if !tt.inMemory {
+				objs = append(objs, tt.config)
+			}
+			fakeClient, err := getFakeClientFromObjects(objs...)
+			if err != nil {
+				t.Fatalf("error in creating fake client, likely programmer error")
+			}
+
+			if err := recordStorageClassConfigUse(tt.config, tt.obj, fakeClient); err != nil {
+				t.Fatalf("recordStorageClassConfigUse() error = %v", err)
+			}
+			if tt.inMemory {
+				if len(tt.config.Status.VolumeSnapshotBackups) != 0 {
+					t.Errorf("volumeSnapshotBackups = %v, want none", tt.config.Status.VolumeSnapshotBackups)
+				}
+				return
+			}
+
+			got := volsnapmoverv1alpha1.DataMoverStorageClassConfig{}
+			if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(tt.config), &got); err != nil {
+				t.Fatalf("failed to get datamoverstorageclassconfig: %v", err)
+			}
+			if !reflect.DeepEqual(got.Status.VolumeSnapshotBackups, tt.wantBackups) {
+				t.Errorf("volumeSnapshotBackups = %v, want %v", got.Status.VolumeSnapshotBackups, tt.wantBackups)
+			}
+			if !reflect.DeepEqual(got.Status.VolumeSnapshotRestores, tt.wantRestore) {
+				t.Errorf("volumeSnapshotRestores = %v, want %v", got.Status.VolumeSnapshotRestores, tt.wantRestore)
+			}
+		})
+	}
+}
+
+func TestBackupVolumeOptions(t *testing.T) {
+	configCapacity := resource.MustParse("1Gi")
+	specCapacity := resource.MustParse("20Gi")
+	config := &volsnapmoverv1alpha1.DataMoverStorageClassConfig{
+		ObjectMeta: v1.ObjectMeta{Name: "gp2", Namespace: namespace},
+		Spec: volsnapmoverv1alpha1.DataMoverStorageClassConfigSpec{
+			Source: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+				StorageClassName:     "gp3",
+				CacheCapacity:        &configCapacity,
+				MoverSecurityContext: true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		volumeOptions *volsnapmoverv1alpha1.VolumeOptions
+		config        *volsnapmoverv1alpha1.DataMoverStorageClassConfig
+		want          volsnapmoverv1alpha1.DataMoverVolumeOptions
+	}{
+		{
+			name: "Given no config and no spec options -> empty options",
+			want: volsnapmoverv1alpha1.DataMoverVolumeOptions{},
+		},
+		{
+			name:   "Given config and no spec options -> config options",
+			config: config,
+			want:   config.Spec.Source,
+		},
+		{
+			name: "Given config and spec options -> spec options take precedence",
+			volumeOptions: &volsnapmoverv1alpha1.VolumeOptions{
+				CacheAccessMode:      corev1.ReadWriteOnce,
+				CacheCapacity:        &specCapacity,
+				MoverSecurityContext: pointer.Bool(false),
+			},
+			config: config,
+			want: volsnapmoverv1alpha1.DataMoverVolumeOptions{
+				StorageClassName: "gp3",
+				CacheAccessMode:  corev1.ReadWriteOnce,
+				CacheCapacity:    &specCapacity,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vsb := &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+				Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{VolumeOptions: tt.volumeOptions},
+			}
+			if got := backupVolumeOptions(vsb, tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backupVolumeOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
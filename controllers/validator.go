@@ -7,6 +7,7 @@ import (
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/go-logr/logr"
 	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
@@ -17,8 +18,8 @@ import (
 )
 
 func (r *VolumeSnapshotBackupReconciler) ValidateVolumeSnapshotMoverBackup(log logr.Logger) (bool, error) {
-	VSBStatusUpdateNeeded := false
-	var errString string
+	// collect every validation error so they are all reported at once
+	validationErrs := []error{}
 
 	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
 	if err := r.Get(r.Context, r.req.NamespacedName, &vsb); err != nil {
@@ -31,13 +32,11 @@ func (r *VolumeSnapshotBackupReconciler) ValidateVolumeSnapshotMoverBackup(log l
 	}
 	// Check if VolumeSnapshotContent is nil
 	if vsb.Spec.VolumeSnapshotContent.Name == "" {
-		VSBStatusUpdateNeeded = true
-		errString = fmt.Sprintf("snapshot name cannot be nil for volumesnapshotbackup %s", r.req.NamespacedName)
+		validationErrs = append(validationErrs, errors.New(fmt.Sprintf("snapshot name cannot be nil for volumesnapshotbackup %s", r.req.NamespacedName)))
 	}
 
 	if len(vsb.Spec.ProtectedNamespace) == 0 {
-		VSBStatusUpdateNeeded = true
-		errString = fmt.Sprintf("protected ns cannot be empty for volumesnapshotbackup %s", r.req.NamespacedName)
+		validationErrs = append(validationErrs, errors.New(fmt.Sprintf("protected ns cannot be empty for volumesnapshotbackup %s", r.req.NamespacedName)))
 	}
 
 	vscInCluster := snapv1.VolumeSnapshotContent{}
@@ -48,24 +47,22 @@ func (r *VolumeSnapshotBackupReconciler) ValidateVolumeSnapshotMoverBackup(log l
 
 	hasOneDefaultVSClass, err := r.checkForOneDefaultVSBSnapClass(log)
 	if !hasOneDefaultVSClass {
-		VSBStatusUpdateNeeded = true
-		errString = err.Error()
+		validationErrs = append(validationErrs, err)
 	}
 
 	hasOneDefaultStorageClass, err := r.checkForOneDefaultVSBStorageClass(log)
 	if !hasOneDefaultStorageClass {
-		VSBStatusUpdateNeeded = true
-		errString = err.Error()
+		validationErrs = append(validationErrs, err)
 	}
 
-	if VSBStatusUpdateNeeded {
+	if len(validationErrs) > 0 {
 		err := r.updateVSBStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, r.Client)
 		if err != nil {
 			return false, err
 		}
 
 		r.Log.Info(fmt.Sprintf("marking volumesnapshotbackup %s as failed", r.req.NamespacedName))
-		return false, errors.New(errString)
+		return false, utilerrors.NewAggregate(validationErrs)
 	}
 
 	if vsb.Status.StartTimestamp == nil {
@@ -84,8 +81,8 @@ func (r *VolumeSnapshotBackupReconciler) ValidateVolumeSnapshotMoverBackup(log l
 }
 
 func (r *VolumeSnapshotRestoreReconciler) ValidateVolumeSnapshotMoverRestore(log logr.Logger) (bool, error) {
-	VSRStatusUpdateNeeded := false
-	var errString string
+	// collect every validation error so they are all reported at once
+	validationErrs := []error{}
 
 	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
 	if err := r.Get(r.Context, r.req.NamespacedName, &vsr); err != nil {
@@ -96,53 +93,29 @@ func (r *VolumeSnapshotRestoreReconciler) ValidateVolumeSnapshotMoverRestore(log
 		return false, errors.New(fmt.Sprintf("unable to fetch volumesnapshotrestore %s", r.req.NamespacedName))
 	}
 
-	// Check if restic secret ref is empty
-	if len(vsr.Spec.ResticSecretRef.Name) == 0 {
-		VSRStatusUpdateNeeded = true
-		errString = fmt.Sprintf("ResticSecretRef name cannot be empty for volumesnapshotrestore %s", r.req.NamespacedName)
-	}
-
-	// Check if VolumeSnapshotMoverbackuRef attributes are empty
-	if len(vsr.Spec.VolumeSnapshotMoverBackupref.ResticRepository) == 0 {
-		VSRStatusUpdateNeeded = true
-		errString = fmt.Sprintf("volumeSnapshotMoverBackupref ResticRepository cannot be empty for volumesnapshotrestore %s", r.req.NamespacedName)
-	}
-
-	if len(vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name) == 0 {
-		VSRStatusUpdateNeeded = true
-		errString = fmt.Sprintf("volumeSnapshotMoverBackupref BackedUpPVCData name cannot be empty cannot be empty for volumesnapshotrestore %s", r.req.NamespacedName)
-	}
-
-	if len(vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Size) == 0 {
-		VSRStatusUpdateNeeded = true
-		errString = fmt.Sprintf("volumeSnapshotMoverBackupref BackedUpPVCData size cannot be empty for volumesnapshotrestore %s", r.req.NamespacedName)
-	}
-
-	if len(vsr.Spec.ProtectedNamespace) == 0 {
-		VSRStatusUpdateNeeded = true
-		errString = fmt.Sprintf("protected ns cannot be empty for volumesnapshotrestore %s", r.req.NamespacedName)
+	// Check for empty or malformed spec attributes, the same checks are run by the validating webhook
+	if specErrs := vsr.ValidateSpec(); len(specErrs) > 0 {
+		validationErrs = append(validationErrs, errors.New(fmt.Sprintf("invalid spec for volumesnapshotrestore %s: %s", r.req.NamespacedName, specErrs.ToAggregate().Error())))
 	}
 
 	hasOneDefaultVSClass, err := r.checkForOneDefaultVSRSnapClass(log)
 	if !hasOneDefaultVSClass {
-		VSRStatusUpdateNeeded = true
-		errString = err.Error()
+		validationErrs = append(validationErrs, err)
 	}
 
 	hasOneDefaultStorageClass, err := r.checkForOneDefaultVSRStorageClass(log)
 	if !hasOneDefaultStorageClass {
-		VSRStatusUpdateNeeded = true
-		errString = err.Error()
+		validationErrs = append(validationErrs, err)
 	}
 
-	if VSRStatusUpdateNeeded {
+	if len(validationErrs) > 0 {
 		err := r.updateVSRStatusPhase(nil, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, r.Client)
 		if err != nil {
 			return false, err
 		}
 
 		r.Log.Info(fmt.Sprintf("marking volumesnapshotrestore %s as failed", r.req.NamespacedName))
-		return false, errors.New(errString)
+		return false, utilerrors.NewAggregate(validationErrs)
 	}
 
 	if vsr.Status.StartTimestamp == nil {
@@ -19,7 +19,6 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -48,23 +47,27 @@ const ReconciledReasonError = "Error"
 const ReconciledReasonComplete = "Complete"
 const ReconcileCompleteMessage = "Reconcile complete"
 
-var processingVSBs = 0
-var VSBBatchNumber = 0
-
 // VolumeSnapshotBackupReconciler reconciles a VolumeSnapshotBackup object
 type VolumeSnapshotBackupReconciler struct {
 	client.Client
-	Scheme         *runtime.Scheme
-	Log            logr.Logger
-	Context        context.Context
-	NamespacedName types.NamespacedName
-	EventRecorder  record.EventRecorder
-	req            ctrl.Request
+	Scheme            *runtime.Scheme
+	Log               logr.Logger
+	Context           context.Context
+	NamespacedName    types.NamespacedName
+	EventRecorder     record.EventRecorder
+	Scheduler         *BatchScheduler
+	ProgressCollector *ProgressCollector
+	// resolves the short-lived credentials of restic secrets configured for a workload identity
+	CredentialResolver CredentialResolver
+	// unwraps the restic passwords of restic secrets wrapped by a key provider
+	KeyProviders KeyProviders
+	req          ctrl.Request
 }
 
 //+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackups,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackups/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete;deletecollection
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -88,6 +91,8 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 	if err := r.Get(ctx, req.NamespacedName, &vsb); err != nil {
 		// ignore is not found error
 		if k8serrors.IsNotFound(err) {
+			// free the slot of a vsb deleted without going through the finalizer
+			r.Scheduler.Release(req.NamespacedName)
 			return result, nil
 		}
 		r.Log.Error(err, "unable to fetch VolumeSnapshotBackup CR")
@@ -100,16 +105,8 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 		Name:      vsb.Name,
 	}
 
-	if VSBBatchNumber == 0 {
-		batchValue, err := GetBackupBatchValue(vsb.Spec.ProtectedNamespace, r.Client)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-
-		VSBBatchNumber, err = strconv.Atoi(batchValue)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
+	if err := r.initBackupScheduler(vsb.Spec.ProtectedNamespace); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	// stop reconciling on this resource when completed or failed
@@ -118,6 +115,9 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 		vsb.Status.Phase == volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed) &&
 		vsb.DeletionTimestamp.IsZero() {
 
+		// free the slot of a vsb that failed while processing
+		r.Scheduler.Release(req.NamespacedName)
+
 		// remove from queue
 		return ctrl.Result{
 			Requeue: false,
@@ -134,29 +134,28 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	// Check and add VSBs to queue until full
-	processed, err := r.setVSBQueue(&vsb, r.Log)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// no error but VSB queue is full
-	if !processed && err == nil {
-		r.Log.Info(fmt.Sprintf("requeuing vsb %v as max vsbs are being processed", vsb.Name))
-		return ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}, nil
-	}
-
 	if !vsb.DeletionTimestamp.IsZero() {
 		// remove VSB from queue if deleted
-		if vsb.Status.BatchingStatus != "" && vsb.Status.BatchingStatus != volsnapmoverv1alpha1.SnapMoverBackupBatchingCompleted {
-			processingVSBs--
-		}
+		r.Scheduler.Release(req.NamespacedName)
 
 		_, err := r.CleanBackupResources(r.Log)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 
+		// the finalizer is kept until the moved data is deleted from the BSL
+		deleted, err := r.DeleteBackupData(r.Log)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !deleted {
+			return ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}, nil
+		}
+
+		// the status was updated by the cleanup
+		if err := r.Get(ctx, req.NamespacedName, &vsb); err != nil {
+			return ctrl.Result{}, err
+		}
 		controllerutil.RemoveFinalizer(&vsb, dmFinalizer)
 		err = r.Update(ctx, &vsb)
 		if err != nil {
@@ -166,6 +165,18 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, nil
 	}
 
+	// Check and add VSBs to queue until full
+	processed, err := r.setVSBQueue(&vsb, r.Log)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// no error but VSB queue is full
+	if !processed && err == nil {
+		r.Log.Info(fmt.Sprintf("requeuing vsb %v as max vsbs are being processed", vsb.Name))
+		return ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}, nil
+	}
+
 	// Run through all reconcilers associated with VSB needs
 	// Reconciliation logic
 
@@ -180,6 +191,7 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 		r.CreateVSBResticSecret,
 		r.IsPVCBound,
 		r.CreateReplicationSource,
+		r.WriteSnapshotManifest,
 		r.CleanBackupResources,
 	)
 
@@ -225,6 +237,10 @@ func (r *VolumeSnapshotBackupReconciler) Reconcile(ctx context.Context, req ctrl
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VolumeSnapshotBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := registerSchedulerMetrics(backupMetricKind, r.Scheduler); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&volsnapmoverv1alpha1.VolumeSnapshotBackup{}).
 		Owns(&snapv1.VolumeSnapshotContent{}).
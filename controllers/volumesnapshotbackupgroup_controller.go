@@ -0,0 +1,246 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// VolumeSnapshotBackupGroupReconciler moves the VolumeSnapshotContents of a volume group snapshot
+// with one member volumesnapshotbackup each, and completes or fails them as one unit
+type VolumeSnapshotBackupGroupReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Log           logr.Logger
+	EventRecorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackupgroups,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackupgroups/status,verbs=get;update;patch
+
+// Reconcile creates the member volumesnapshotbackups of the group and records their progress. The group
+// completes once every member has completed, and fails as soon as one member fails, rolling back the others
+func (r *VolumeSnapshotBackupGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("vsbg", req.NamespacedName)
+
+	group := volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{}
+	if err := r.Get(ctx, req.NamespacedName, &group); err != nil {
+		// ignore is not found error, the members are garbage collected with their owner
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch VolumeSnapshotBackupGroup CR")
+		return ctrl.Result{}, err
+	}
+
+	if !group.DeletionTimestamp.IsZero() || group.Status.Phase.IsTerminal() {
+		return ctrl.Result{}, nil
+	}
+
+	if err := validateBackupGroup(&group); err != nil {
+		return ctrl.Result{}, r.setBackupGroupStatus(ctx, &group, volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed, err.Error())
+	}
+
+	if len(group.Status.Tag) == 0 {
+		group.Status.Tag = string(group.UID)
+	}
+
+	members := []volsnapmoverv1alpha1.BackupGroupMember{}
+	completed, failed := 0, 0
+	for i, vsc := range group.Spec.VolumeSnapshotContents {
+		vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: group.Namespace, Name: groupMemberName(group.Name, i)}, &vsb)
+		if k8serrors.IsNotFound(err) {
+			buildBackupGroupMember(&vsb, &group, i)
+			if err := controllerutil.SetControllerReference(&group, &vsb, r.Scheme); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.Create(ctx, &vsb); err != nil && !k8serrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, err
+			}
+			r.Log.Info(fmt.Sprintf("created member volumesnapshotbackup %s/%s for volumesnapshotcontent %s", vsb.Namespace, vsb.Name, vsc.Name))
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		switch vsb.Status.Phase {
+		case volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted:
+			completed++
+		case volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed:
+			failed++
+		}
+
+		members = append(members, volsnapmoverv1alpha1.BackupGroupMember{
+			VolumeSnapshotContent: vsc.Name,
+			VolumeSnapshotBackup:  vsb.Name,
+			Phase:                 vsb.Status.Phase,
+			BackupRef: volsnapmoverv1alpha1.VSBRef{
+				BackedUpPVCData:         vsb.Status.SourcePVCData,
+				ResticRepository:        vsb.Status.ResticRepository,
+				VolumeSnapshotClassName: vsb.Status.VolumeSnapshotClassName,
+				SnapshotTimestamp:       getSnapshotTimestamp(&vsb),
+				SnapshotID:              getSnapshotID(&vsb),
+			},
+		})
+	}
+	group.Status.Members = members
+
+	phase := getGroupPhase(completed, failed, len(members))
+	switch phase {
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed:
+		if err := r.rollBackBackupGroupMembers(ctx, &group); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.EventRecorder.Event(&group, corev1.EventTypeWarning, "VolumeSnapshotBackupGroupFailed",
+			fmt.Sprintf("%v of %v member volumesnapshotbackups failed", failed, len(members)))
+		return ctrl.Result{}, r.setBackupGroupStatus(ctx, &group, phase, fmt.Sprintf("%v of %v member volumesnapshotbackups failed", failed, len(members)))
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted:
+		return ctrl.Result{}, r.setBackupGroupStatus(ctx, &group, phase, fmt.Sprintf("moved %v volumes", len(members)))
+	}
+
+	group.Status.Phase = phase
+	return ctrl.Result{}, r.Status().Update(ctx, &group)
+}
+
+// validateBackupGroup returns an error if the member volumesnapshotbackups of the group cannot be created
+func validateBackupGroup(group *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup) error {
+	if len(group.Spec.VolumeSnapshotContents) == 0 {
+		return errors.New("volumesnapshotbackupgroup has no volumesnapshotcontents")
+	}
+
+	if len(group.Spec.ProtectedNamespace) == 0 {
+		return errors.New("volumesnapshotbackupgroup protected namespace cannot be empty")
+	}
+
+	if len(group.Spec.ResticSecretRef.Name) == 0 && !group.Spec.Mover.IsClone() {
+		return errors.New("volumesnapshotbackupgroup resticSecretRef cannot be empty")
+	}
+
+	seen := map[string]bool{}
+	for _, vsc := range group.Spec.VolumeSnapshotContents {
+		if len(vsc.Name) == 0 {
+			return errors.New("volumesnapshotbackupgroup volumesnapshotcontent name cannot be empty")
+		}
+		if seen[vsc.Name] {
+			return errors.New(fmt.Sprintf("volumesnapshotcontent %s is listed more than once", vsc.Name))
+		}
+		seen[vsc.Name] = true
+	}
+
+	return nil
+}
+
+// buildBackupGroupMember builds the member volumesnapshotbackup moving the volumesnapshotcontent at the given index
+func buildBackupGroupMember(vsb *volsnapmoverv1alpha1.VolumeSnapshotBackup, group *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup, index int) {
+	vsb.Name = groupMemberName(group.Name, index)
+	vsb.Namespace = group.Namespace
+	vsb.Labels = map[string]string{
+		VSBGroupLabel: group.Name,
+		GroupTagLabel: group.Status.Tag,
+	}
+	// the members are written to the repository path of the velero backup
+	if backupName, ok := group.Labels[backupLabel]; ok {
+		vsb.Labels[backupLabel] = backupName
+	}
+
+	vsb.Spec = volsnapmoverv1alpha1.VolumeSnapshotBackupSpec{
+		VolumeSnapshotContent: group.Spec.VolumeSnapshotContents[index],
+		ProtectedNamespace:    group.Spec.ProtectedNamespace,
+		ResticSecretRef:       group.Spec.ResticSecretRef,
+		Mover:                 group.Spec.Mover,
+		RetryPolicy:           group.Spec.RetryPolicy,
+	}
+}
+
+// rollBackBackupGroupMembers deletes the members of a failed group that are still moving data, and the members that
+// completed with the Delete data deletion policy so their restic snapshots are forgotten. Failed members are kept
+func (r *VolumeSnapshotBackupGroupReconciler) rollBackBackupGroupMembers(ctx context.Context, group *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup) error {
+	for _, member := range group.Status.Members {
+		switch member.Phase {
+		case volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed, volsnapmoverv1alpha1.SnapMoverBackupPhasePartiallyFailed:
+			continue
+		}
+
+		vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: group.Namespace, Name: member.VolumeSnapshotBackup}, &vsb); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		if member.Phase == volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted && vsb.Spec.DataDeletionPolicy != volsnapmoverv1alpha1.DataDeletionPolicyDelete {
+			vsb.Spec.DataDeletionPolicy = volsnapmoverv1alpha1.DataDeletionPolicyDelete
+			if err := r.Update(ctx, &vsb); err != nil {
+				return err
+			}
+		}
+		if err := r.Delete(ctx, &vsb); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info(fmt.Sprintf("rolled back member volumesnapshotbackup %s/%s of failed group", vsb.Namespace, vsb.Name))
+	}
+
+	return nil
+}
+
+// setBackupGroupStatus records the terminal phase of a group
+func (r *VolumeSnapshotBackupGroupReconciler) setBackupGroupStatus(ctx context.Context, group *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup,
+	phase volsnapmoverv1alpha1.VolumeSnapshotGroupPhase, message string) error {
+
+	status, reason := metav1.ConditionTrue, ReconciledReasonComplete
+	if phase == volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed {
+		status, reason = metav1.ConditionFalse, ReconciledReasonError
+	}
+
+	now := metav1.Now()
+	group.Status.Phase = phase
+	group.Status.CompletionTimestamp = &now
+	apimeta.SetStatusCondition(&group.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionReconciled,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(ctx, group)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VolumeSnapshotBackupGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{}).
+		Owns(&volsnapmoverv1alpha1.VolumeSnapshotBackup{}).
+		Complete(r)
+}
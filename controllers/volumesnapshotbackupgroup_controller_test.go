@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotBackupGroupReconciler_Reconcile(t *testing.T) {
+	newGroup := func(vscs ...string) *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup {
+		group := &volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsbg",
+				Namespace: "bar",
+				UID:       "group-uid",
+				Labels: map[string]string{
+					backupLabel: "backup-1",
+				},
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupGroupSpec{
+				ProtectedNamespace: namespace,
+				ResticSecretRef: corev1.LocalObjectReference{
+					Name: "restic-secret",
+				},
+			},
+		}
+		for _, vsc := range vscs {
+			group.Spec.VolumeSnapshotContents = append(group.Spec.VolumeSnapshotContents, corev1.ObjectReference{Name: vsc})
+		}
+		return group
+	}
+	newMember := func(index int, phase volsnapmoverv1alpha1.VolumeSnapshotBackupPhase) *volsnapmoverv1alpha1.VolumeSnapshotBackup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      groupMemberName("sample-vsbg", index),
+				Namespace: "bar",
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupStatus{
+				Phase:            phase,
+				ResticRepository: "s3:s3.amazonaws.com/bucket/foo/backup-1/snapcontent-pvc",
+				SourcePVCData: volsnapmoverv1alpha1.PVCData{
+					Name: "data",
+					Size: "10Gi",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		objs           []client.Object
+		wantPhase      volsnapmoverv1alpha1.VolumeSnapshotGroupPhase
+		wantMembers    int
+		wantNotFound   []string
+		wantDeleted    []string
+		wantMemberRepo bool
+	}{
+		{
+			name:      "Given group without protected namespace -> group failed",
+			objs:      []client.Object{func() client.Object { g := newGroup("vsc-a"); g.Spec.ProtectedNamespace = ""; return g }()},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+		},
+		{
+			name:      "Given group listing a volumesnapshotcontent twice -> group failed",
+			objs:      []client.Object{newGroup("vsc-a", "vsc-a")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+		},
+		{
+			name:        "Given new group -> members created",
+			objs:        []client.Object{newGroup("vsc-a", "vsc-b")},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress,
+			wantMembers: 2,
+		},
+		{
+			name: "Given one member still in progress -> group in progress",
+			objs: []client.Object{
+				newGroup("vsc-a", "vsc-b"),
+				newMember(0, volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress),
+			},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress,
+			wantMembers: 2,
+		},
+		{
+			name: "Given all members completed -> group completed",
+			objs: []client.Object{
+				newGroup("vsc-a", "vsc-b"),
+				newMember(0, volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted),
+			},
+			wantPhase:      volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted,
+			wantMembers:    2,
+			wantMemberRepo: true,
+		},
+		{
+			name: "Given one member failed -> group failed and running members deleted",
+			objs: []client.Object{
+				newGroup("vsc-a", "vsc-b"),
+				newMember(0, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress),
+			},
+			wantPhase:    volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+			wantMembers:  2,
+			wantNotFound: []string{"sample-vsbg-1"},
+		},
+		{
+			name: "Given one member failed -> completed members deleted with their data",
+			objs: []client.Object{
+				newGroup("vsc-a", "vsc-b", "vsc-c"),
+				func() client.Object {
+					vsb := newMember(0, volsnapmoverv1alpha1.SnapMoverBackupPhaseCompleted)
+					vsb.Finalizers = []string{dmFinalizer}
+					return vsb
+				}(),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverBackupPhaseFailed),
+				newMember(2, volsnapmoverv1alpha1.SnapMoverBackupPhaseInProgress),
+			},
+			wantPhase:    volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+			wantMembers:  3,
+			wantNotFound: []string{"sample-vsbg-2"},
+			wantDeleted:  []string{"sample-vsbg-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotBackupGroupReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+			ctx := newContextForTest(tt.name)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsbg"}}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			group := volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{}
+			if err := fakeClient.Get(ctx, req.NamespacedName, &group); err != nil {
+				t.Fatalf("unable to fetch vsbg: %v", err)
+			}
+			if group.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v", group.Status.Phase, tt.wantPhase)
+			}
+			if len(group.Status.Members) != tt.wantMembers {
+				t.Errorf("Reconcile() members = %v, want %v", group.Status.Members, tt.wantMembers)
+			}
+			if tt.wantMemberRepo && group.Status.Members[0].BackupRef.ResticRepository == "" {
+				t.Errorf("Reconcile() member backupRef = %v", group.Status.Members[0].BackupRef)
+			}
+
+			notFound := map[string]bool{}
+			for _, name := range tt.wantNotFound {
+				notFound[name] = true
+			}
+			deleted := map[string]bool{}
+			for _, name := range tt.wantDeleted {
+				deleted[name] = true
+			}
+			for i := 0; i < tt.wantMembers; i++ {
+				vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+				name := groupMemberName("sample-vsbg", i)
+				err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "bar", Name: name}, &vsb)
+				if notFound[name] {
+					if !k8serrors.IsNotFound(err) {
+						t.Errorf("member %s should have been deleted, got err %v", name, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unable to fetch member %s: %v", name, err)
+				}
+				if deleted[name] != !vsb.DeletionTimestamp.IsZero() {
+					t.Errorf("member %s deletionTimestamp = %v, want deleted %v", name, vsb.DeletionTimestamp, deleted[name])
+				}
+				if deleted[name] && vsb.Spec.DataDeletionPolicy != volsnapmoverv1alpha1.DataDeletionPolicyDelete {
+					t.Errorf("member %s dataDeletionPolicy = %v, want %v", name, vsb.Spec.DataDeletionPolicy, volsnapmoverv1alpha1.DataDeletionPolicyDelete)
+				}
+				// members created by the reconcile have no phase yet
+				if len(vsb.Status.Phase) == 0 && (vsb.Labels[VSBGroupLabel] != "sample-vsbg" || vsb.Labels[backupLabel] != "backup-1") {
+					t.Errorf("member %s labels = %v", name, vsb.Labels)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildBackupGroupMember(t *testing.T) {
+	group := &volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsbg",
+			Namespace: "bar",
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotBackupGroupSpec{
+			VolumeSnapshotContents: []corev1.ObjectReference{{Name: "vsc-a"}, {Name: "vsc-b"}},
+			ProtectedNamespace:     namespace,
+			Mover:                  volsnapmoverv1alpha1.RcloneDataMover,
+		},
+		Status: volsnapmoverv1alpha1.VolumeSnapshotBackupGroupStatus{
+			Tag: "group-uid",
+		},
+	}
+
+	vsb := volsnapmoverv1alpha1.VolumeSnapshotBackup{}
+	buildBackupGroupMember(&vsb, group, 1)
+	if vsb.Name != "sample-vsbg-1" || vsb.Namespace != "bar" {
+		t.Errorf("buildBackupGroupMember() name = %s/%s", vsb.Namespace, vsb.Name)
+	}
+	if vsb.Spec.VolumeSnapshotContent.Name != "vsc-b" || vsb.Spec.Mover != volsnapmoverv1alpha1.RcloneDataMover {
+		t.Errorf("buildBackupGroupMember() spec = %v", vsb.Spec)
+	}
+	if vsb.Labels[GroupTagLabel] != "group-uid" {
+		t.Errorf("buildBackupGroupMember() labels = %v", vsb.Labels)
+	}
+}
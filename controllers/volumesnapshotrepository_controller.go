@@ -0,0 +1,232 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const ConditionDiscovered = "Discovered"
+const DiscoveredReasonError = "Error"
+const DiscoveredReasonComplete = "Complete"
+
+// VolumeSnapshotRepositoryReconciler discovers the snapshots written to a repository by the volumesnapshotbackups
+type VolumeSnapshotRepositoryReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Log       logr.Logger
+	Clientset kubernetes.Interface
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotrepositories,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotrepositories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile runs a discovery job listing the repository whenever the volumesnapshotrepository
+// changes or its refresh interval has elapsed, and records the snapshots found in its status
+func (r *VolumeSnapshotRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("vsrepo", req.NamespacedName)
+
+	repo := volsnapmoverv1alpha1.VolumeSnapshotRepository{}
+	if err := r.Get(ctx, req.NamespacedName, &repo); err != nil {
+		// ignore is not found error, the discovery job is garbage collected with its owner
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch VolumeSnapshotRepository CR")
+		return ctrl.Result{}, err
+	}
+
+	job := batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: discoveryJobName(repo.Name)}, &job)
+	if k8serrors.IsNotFound(err) {
+		if wait := nextDiscovery(&repo, time.Now()); wait != 0 {
+			if wait > 0 {
+				return ctrl.Result{RequeueAfter: wait}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.startDiscovery(ctx, &repo)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// wait for the discovery job to finish, its updates trigger a new reconcile
+	if job.Status.Succeeded == 0 && !isJobFailed(&job) {
+		return ctrl.Result{}, nil
+	}
+
+	if job.Status.Succeeded > 0 {
+		output, err := r.getDiscoveryOutput(ctx, &job)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		snapshots := parseRepositorySnapshots(output, job.Annotations[DatamoverResticRepository])
+		r.Log.Info(fmt.Sprintf("discovered %v snapshots in volumesnapshotrepository %s", len(snapshots), req.NamespacedName))
+		repo.Status.Snapshots = snapshots
+		err = r.setDiscoveryStatus(ctx, &repo, volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady, metav1.ConditionTrue, DiscoveredReasonComplete,
+			fmt.Sprintf("discovered %v snapshots", len(snapshots)))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else {
+		r.Log.Info(fmt.Sprintf("discovery job %s/%s failed", job.Namespace, job.Name))
+		err = r.setDiscoveryStatus(ctx, &repo, volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed, metav1.ConditionFalse, DiscoveredReasonError,
+			fmt.Sprintf("discovery job %s failed", job.Name))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// remove the finished job so the next discovery can run
+	propagation := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, &job, &client.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if repo.Spec.RefreshInterval != nil {
+		return ctrl.Result{RequeueAfter: repo.Spec.RefreshInterval.Duration}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// startDiscovery creates the job listing the repository referenced by the restic secret of the volumesnapshotrepository
+func (r *VolumeSnapshotRepositoryReconciler) startDiscovery(ctx context.Context, repo *volsnapmoverv1alpha1.VolumeSnapshotRepository) error {
+	resticSecret := corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: repo.Spec.ResticSecretRef.Name}, &resticSecret); err != nil {
+		r.Log.Error(err, fmt.Sprintf("unable to fetch restic secret %s/%s", repo.Namespace, repo.Spec.ResticSecretRef.Name))
+		return r.setDiscoveryStatus(ctx, repo, volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed, metav1.ConditionFalse, DiscoveredReasonError, err.Error())
+	}
+
+	// compose the repository the same way the volumesnapshotbackups do
+	resticRepo, err := getS3ResticRepository(string(resticSecret.Data[ResticRepository]), &resticSecret)
+	if err != nil {
+		return r.setDiscoveryStatus(ctx, repo, volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed, metav1.ConditionFalse, DiscoveredReasonError, err.Error())
+	}
+	job, err := buildDiscoveryJob(repo, resticRepo)
+	if err != nil {
+		return r.setDiscoveryStatus(ctx, repo, volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed, metav1.ConditionFalse, DiscoveredReasonError, err.Error())
+	}
+
+	if err := controllerutil.SetControllerReference(repo, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, job); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	r.Log.Info(fmt.Sprintf("started discovery job %s/%s for repository %s", job.Namespace, job.Name, resticRepo))
+
+	repo.Status.Phase = volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering
+	repo.Status.ObservedGeneration = repo.Generation
+	return r.Status().Update(ctx, repo)
+}
+
+// setDiscoveryStatus records the result of a discovery
+func (r *VolumeSnapshotRepositoryReconciler) setDiscoveryStatus(ctx context.Context, repo *volsnapmoverv1alpha1.VolumeSnapshotRepository,
+	phase volsnapmoverv1alpha1.VolumeSnapshotRepositoryPhase, status metav1.ConditionStatus, reason, message string) error {
+
+	now := metav1.Now()
+	repo.Status.Phase = phase
+	repo.Status.ObservedGeneration = repo.Generation
+	repo.Status.LastDiscoveryTimestamp = &now
+	apimeta.SetStatusCondition(&repo.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionDiscovered,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(ctx, repo)
+}
+
+// getDiscoveryOutput returns the logs of the succeeded pod of the discovery job
+func (r *VolumeSnapshotRepositoryReconciler) getDiscoveryOutput(ctx context.Context, job *batchv1.Job) (string, error) {
+	podList := corev1.PodList{}
+	if err := r.List(ctx, &podList, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+
+		logs, err := r.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		if err != nil {
+			return "", err
+		}
+		return string(logs), nil
+	}
+
+	return "", k8serrors.NewNotFound(corev1.Resource("pods"), fmt.Sprintf("succeeded pod of job %s", job.Name))
+}
+
+// nextDiscovery returns 0 when the repository has to be discovered now, the time left until the
+// next discovery, or a negative duration when the repository is not discovered again
+func nextDiscovery(repo *volsnapmoverv1alpha1.VolumeSnapshotRepository, now time.Time) time.Duration {
+	if repo.Status.LastDiscoveryTimestamp == nil || repo.Status.ObservedGeneration != repo.Generation ||
+		repo.Status.Phase == volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering {
+		return 0
+	}
+
+	if repo.Spec.RefreshInterval == nil {
+		return -1
+	}
+
+	wait := repo.Status.LastDiscoveryTimestamp.Add(repo.Spec.RefreshInterval.Duration).Sub(now)
+	if wait <= 0 {
+		return 0
+	}
+	return wait
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VolumeSnapshotRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.VolumeSnapshotRepository{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotRepositoryReconciler_Reconcile(t *testing.T) {
+	newRepo := func() *volsnapmoverv1alpha1.VolumeSnapshotRepository {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRepository{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "bsl",
+				Namespace: namespace,
+			},
+			Spec: volsnapmoverv1alpha1.VolumeSnapshotRepositorySpec{
+				ResticSecretRef: corev1.LocalObjectReference{Name: "restic-secret"},
+			},
+		}
+	}
+	newSecret := func(repo string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "restic-secret",
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				ResticRepository: []byte(repo),
+			},
+		}
+	}
+	newJob := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        "bsl-discovery",
+				Namespace:   namespace,
+				Annotations: map[string]string{DatamoverResticRepository: "s3:s3.amazonaws.com/bucket"},
+			},
+			Status: status,
+		}
+	}
+	succeededPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "bsl-discovery-abcde",
+			Namespace: namespace,
+			Labels:    map[string]string{"job-name": "bsl-discovery"},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodSucceeded,
+		},
+	}
+	discovered := v1.NewTime(time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name      string
+		objs      []client.Object
+		wantPhase volsnapmoverv1alpha1.VolumeSnapshotRepositoryPhase
+		wantJob   bool
+		wantErr   bool
+	}{
+		{
+			name:      "Given new repository -> discovery job started",
+			objs:      []client.Object{newRepo(), newSecret("s3:s3.amazonaws.com/bucket/")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering,
+			wantJob:   true,
+		},
+		{
+			name:      "Given repository without secret -> failed",
+			objs:      []client.Object{newRepo()},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed,
+		},
+		{
+			name:      "Given unsupported repository -> failed",
+			objs:      []client.Object{newRepo(), newSecret("/srv/restic")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed,
+		},
+		{
+			name: "Given discovered repository without refresh interval -> not discovered again",
+			objs: []client.Object{func() client.Object {
+				repo := newRepo()
+				repo.Status.Phase = volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady
+				repo.Status.LastDiscoveryTimestamp = &discovered
+				return repo
+			}(), newSecret("s3:s3.amazonaws.com/bucket")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady,
+		},
+		{
+			name: "Given discovered repository past its refresh interval -> discovery job started",
+			objs: []client.Object{func() client.Object {
+				repo := newRepo()
+				repo.Spec.RefreshInterval = &v1.Duration{Duration: time.Second}
+				repo.Status.Phase = volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady
+				repo.Status.LastDiscoveryTimestamp = &discovered
+				return repo
+			}(), newSecret("s3:s3.amazonaws.com/bucket")},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseDiscovering,
+			wantJob:   true,
+		},
+		{
+			name:      "Given running discovery job -> still discovering",
+			objs:      []client.Object{newRepo(), newJob(batchv1.JobStatus{Active: 1})},
+			wantPhase: "",
+			wantJob:   true,
+		},
+		{
+			name:      "Given succeeded discovery job -> ready and job removed",
+			objs:      []client.Object{newRepo(), newJob(batchv1.JobStatus{Succeeded: 1}), succeededPod},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseReady,
+		},
+		{
+			name: "Given failed discovery job -> failed and job removed",
+			objs: []client.Object{newRepo(), newJob(batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			})},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverRepositoryPhaseFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotRepositoryReconciler{
+				Client:    fakeClient,
+				Scheme:    fakeClient.Scheme(),
+				Log:       logr.Discard(),
+				Clientset: fake.NewSimpleClientset(),
+			}
+			_, err = r.Reconcile(newContextForTest(tt.name), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: "bsl"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			repo := volsnapmoverv1alpha1.VolumeSnapshotRepository{}
+			if err := fakeClient.Get(newContextForTest(tt.name), types.NamespacedName{Namespace: namespace, Name: "bsl"}, &repo); err != nil {
+				t.Fatalf("unable to fetch volumesnapshotrepository: %v", err)
+			}
+			if repo.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %v, want %v", repo.Status.Phase, tt.wantPhase)
+			}
+
+			job := batchv1.Job{}
+			err = fakeClient.Get(newContextForTest(tt.name), types.NamespacedName{Namespace: namespace, Name: "bsl-discovery"}, &job)
+			if tt.wantJob && err != nil {
+				t.Errorf("expected discovery job, got error %v", err)
+			}
+			if !tt.wantJob && !k8serrors.IsNotFound(err) {
+				t.Errorf("expected no discovery job, got error %v", err)
+			}
+		})
+	}
+}
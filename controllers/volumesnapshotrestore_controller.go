@@ -19,7 +19,6 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -41,18 +40,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-var processingVSRs = 0
-var VSRBatchNumber = 0
-
 // VolumeSnapshotRestoreReconciler reconciles a VolumeSnapshotRestore object
 type VolumeSnapshotRestoreReconciler struct {
 	client.Client
-	Scheme         *runtime.Scheme
-	Log            logr.Logger
-	Context        context.Context
-	NamespacedName types.NamespacedName
-	EventRecorder  record.EventRecorder
-	req            ctrl.Request
+	Scheme            *runtime.Scheme
+	Log               logr.Logger
+	Context           context.Context
+	NamespacedName    types.NamespacedName
+	EventRecorder     record.EventRecorder
+	Scheduler         *BatchScheduler
+	ProgressCollector *ProgressCollector
+	// resolves the short-lived credentials of restic secrets configured for a workload identity
+	CredentialResolver CredentialResolver
+	// unwraps the restic passwords of restic secrets wrapped by a key provider
+	KeyProviders KeyProviders
+	req          ctrl.Request
 }
 
 //+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotrestores,verbs=get;list;watch;create;update;patch;delete
@@ -82,6 +84,8 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 	if err := r.Get(ctx, req.NamespacedName, &vsr); err != nil {
 		// ignore is not found error
 		if k8serrors.IsNotFound(err) {
+			// free the slot of a vsr deleted without going through the finalizer
+			r.Scheduler.Release(req.NamespacedName)
 			return result, nil
 		}
 		r.Log.Error(err, "unable to fetch VolumeSnapshotRestore CR")
@@ -94,15 +98,8 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 		Name:      vsr.Name,
 	}
 
-	if VSRBatchNumber == 0 {
-		batchValue, err := GetRestoreBatchValue(vsr.Spec.ProtectedNamespace, r.Client)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		VSRBatchNumber, err = strconv.Atoi(batchValue)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
+	if err := r.initRestoreScheduler(vsr.Spec.ProtectedNamespace); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	// stop reconciling on this resource when completed or failed
@@ -111,6 +108,9 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 		vsr.Status.Phase == volsnapmoverv1alpha1.SnapMoverRestorePhasePartiallyFailed) &&
 		vsr.DeletionTimestamp.IsZero() {
 
+		// free the slot of a vsr that failed while processing
+		r.Scheduler.Release(req.NamespacedName)
+
 		return ctrl.Result{
 			Requeue: false,
 		}, nil
@@ -126,23 +126,9 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	// Check and add VSRs to queue until full
-	processed, err := r.setVSRQueue(&vsr, r.Log)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// no error but VSR queue is full
-	if !processed && err == nil {
-		r.Log.Info(fmt.Sprintf("requeuing vsr %v as max vsrs are being processed", vsr.Name))
-		return ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}, nil
-	}
-
 	if !vsr.DeletionTimestamp.IsZero() {
 		// remove VSR from queue if deleted
-		if vsr.Status.BatchingStatus != "" && vsr.Status.BatchingStatus != volsnapmoverv1alpha1.SnapMoverRestoreBatchingCompleted {
-			processingVSRs--
-		}
+		r.Scheduler.Release(req.NamespacedName)
 
 		_, err := r.CleanRestoreResources(r.Log)
 		if err != nil {
@@ -158,13 +144,27 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 		return ctrl.Result{}, nil
 	}
 
+	// Check and add VSRs to queue until full
+	processed, err := r.setVSRQueue(&vsr, r.Log)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// no error but VSR queue is full
+	if !processed && err == nil {
+		r.Log.Info(fmt.Sprintf("requeuing vsr %v as max vsrs are being processed", vsr.Name))
+		return ctrl.Result{Requeue: true, RequeueAfter: 5 * time.Second}, nil
+	}
+
 	// Run through all reconcilers associated with VSR needs
 	// Reconciliation logic
 
 	reconFlag, err := ReconcileBatch(r.Log,
+		r.ResolveRepositorySnapshot,
 		r.ValidateVolumeSnapshotMoverRestore,
 		r.CreateVSRResticSecret,
 		r.CreateReplicationDestination,
+		r.CreateCloneReplicationSource,
 		r.WaitForVolSyncSnapshotContentToBeReady,
 		r.CleanRestoreResources,
 	)
@@ -211,6 +211,10 @@ func (r *VolumeSnapshotRestoreReconciler) Reconcile(ctx context.Context, req ctr
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VolumeSnapshotRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := registerSchedulerMetrics(restoreMetricKind, r.Scheduler); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&volsnapmoverv1alpha1.VolumeSnapshotRestore{}).
 		Owns(&v1.PersistentVolumeClaim{}).
@@ -0,0 +1,271 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// VolumeSnapshotRestoreGroupReconciler restores the volumes of a backup group together,
+// with one member volumesnapshotrestore each
+type VolumeSnapshotRestoreGroupReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Log           logr.Logger
+	EventRecorder record.EventRecorder
+}
+
+// restoreGroupVolume is a volume restored by a member of a volumesnapshotrestoregroup
+type restoreGroupVolume struct {
+	vsbRef    volsnapmoverv1alpha1.VSBRef
+	backupRef *volsnapmoverv1alpha1.VolumeSnapshotBackupReference
+}
+
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotrestoregroups,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotrestoregroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=datamover.oadp.openshift.io,resources=volumesnapshotbackupgroups,verbs=get;list;watch
+
+// Reconcile creates the member volumesnapshotrestores of the group and records their progress. The group
+// completes once every volume has been restored, and fails as soon as one member fails
+func (r *VolumeSnapshotRestoreGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log = log.FromContext(ctx).WithValues("vsrg", req.NamespacedName)
+
+	group := volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup{}
+	if err := r.Get(ctx, req.NamespacedName, &group); err != nil {
+		// ignore is not found error, the members are garbage collected with their owner
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "unable to fetch VolumeSnapshotRestoreGroup CR")
+		return ctrl.Result{}, err
+	}
+
+	if !group.DeletionTimestamp.IsZero() || group.Status.Phase.IsTerminal() {
+		return ctrl.Result{}, nil
+	}
+
+	if len(group.Spec.ProtectedNamespace) == 0 {
+		return ctrl.Result{}, r.setRestoreGroupStatus(ctx, &group, volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+			"volumesnapshotrestoregroup protected namespace cannot be empty")
+	}
+
+	volumes, ready, err := r.getRestoreGroupVolumes(ctx, &group)
+	if err != nil {
+		return ctrl.Result{}, r.setRestoreGroupStatus(ctx, &group, volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed, err.Error())
+	}
+	if !ready {
+		r.Log.Info("waiting for the volumesnapshotbackupgroup to complete")
+		group.Status.Phase = volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress
+		if err := r.Status().Update(ctx, &group); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	members := []volsnapmoverv1alpha1.RestoreGroupMember{}
+	completed, failed := 0, 0
+	for i := range volumes {
+		vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: group.Namespace, Name: groupMemberName(group.Name, i)}, &vsr)
+		if k8serrors.IsNotFound(err) {
+			buildRestoreGroupMember(&vsr, &group, volumes[i], i)
+			if err := controllerutil.SetControllerReference(&group, &vsr, r.Scheme); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.Create(ctx, &vsr); err != nil && !k8serrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, err
+			}
+			r.Log.Info(fmt.Sprintf("created member volumesnapshotrestore %s/%s for pvc %s", vsr.Namespace, vsr.Name, volumes[i].vsbRef.BackedUpPVCData.Name))
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		switch vsr.Status.Phase {
+		case volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted:
+			completed++
+		case volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed, volsnapmoverv1alpha1.SnapMoverRestorePhasePartiallyFailed:
+			failed++
+		}
+
+		members = append(members, volsnapmoverv1alpha1.RestoreGroupMember{
+			PVCName:               volumes[i].vsbRef.BackedUpPVCData.Name,
+			VolumeSnapshotRestore: vsr.Name,
+			Phase:                 vsr.Status.Phase,
+			SnapshotHandle:        vsr.Status.SnapshotHandle,
+		})
+	}
+	group.Status.Members = members
+
+	phase := getGroupPhase(completed, failed, len(members))
+	switch phase {
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed:
+		if err := r.stopRestoreGroupMembers(ctx, &group); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.EventRecorder.Event(&group, corev1.EventTypeWarning, "VolumeSnapshotRestoreGroupFailed",
+			fmt.Sprintf("%v of %v member volumesnapshotrestores failed", failed, len(members)))
+		return ctrl.Result{}, r.setRestoreGroupStatus(ctx, &group, phase, fmt.Sprintf("%v of %v member volumesnapshotrestores failed", failed, len(members)))
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted:
+		return ctrl.Result{}, r.setRestoreGroupStatus(ctx, &group, phase, fmt.Sprintf("restored %v volumes", len(members)))
+	}
+
+	group.Status.Phase = phase
+	return ctrl.Result{}, r.Status().Update(ctx, &group)
+}
+
+// getRestoreGroupVolumes returns the volumes restored by the group, either listed in the spec or taken
+// from the members of the referenced backup group. Returns false while the backup group is in progress
+func (r *VolumeSnapshotRestoreGroupReconciler) getRestoreGroupVolumes(ctx context.Context, group *volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup) ([]restoreGroupVolume, bool, error) {
+	volumes := []restoreGroupVolume{}
+	if len(group.Spec.Volumes) > 0 {
+		for _, ref := range group.Spec.Volumes {
+			volumes = append(volumes, restoreGroupVolume{vsbRef: ref})
+		}
+		return volumes, true, nil
+	}
+
+	if group.Spec.BackupGroupRef == nil || len(group.Spec.BackupGroupRef.Name) == 0 {
+		return nil, false, errors.New("volumesnapshotrestoregroup requires volumes or a backupGroupRef")
+	}
+
+	backupGroupNamespace := group.Spec.BackupGroupRef.Namespace
+	if len(backupGroupNamespace) == 0 {
+		backupGroupNamespace = group.Namespace
+	}
+
+	backupGroup := volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: backupGroupNamespace, Name: group.Spec.BackupGroupRef.Name}, &backupGroup); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, false, errors.New(fmt.Sprintf("volumesnapshotbackupgroup %s/%s not found", backupGroupNamespace, group.Spec.BackupGroupRef.Name))
+		}
+		return nil, false, err
+	}
+
+	switch backupGroup.Status.Phase {
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted:
+	case volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed:
+		return nil, false, errors.New(fmt.Sprintf("volumesnapshotbackupgroup %s/%s failed", backupGroup.Namespace, backupGroup.Name))
+	default:
+		return nil, false, nil
+	}
+
+	for _, member := range backupGroup.Status.Members {
+		volumes = append(volumes, restoreGroupVolume{
+			vsbRef: member.BackupRef,
+			backupRef: &volsnapmoverv1alpha1.VolumeSnapshotBackupReference{
+				Name:      member.VolumeSnapshotBackup,
+				Namespace: backupGroup.Namespace,
+			},
+		})
+	}
+
+	return volumes, true, nil
+}
+
+// buildRestoreGroupMember builds the member volumesnapshotrestore restoring the volume at the given index
+func buildRestoreGroupMember(vsr *volsnapmoverv1alpha1.VolumeSnapshotRestore, group *volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup,
+	volume restoreGroupVolume, index int) {
+
+	vsr.Name = groupMemberName(group.Name, index)
+	vsr.Namespace = group.Namespace
+	vsr.Labels = map[string]string{
+		VSRGroupLabel: group.Name,
+	}
+	if restoreName, ok := group.Labels[restoreLabel]; ok {
+		vsr.Labels[restoreLabel] = restoreName
+	}
+
+	vsr.Spec = volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+		ResticSecretRef:              group.Spec.ResticSecretRef,
+		VolumeSnapshotMoverBackupref: volume.vsbRef,
+		ProtectedNamespace:           group.Spec.ProtectedNamespace,
+		Mover:                        group.Spec.Mover,
+	}
+	// the rsync movers consume the cloned volume of the member volumesnapshotbackup
+	if group.Spec.Mover.IsClone() {
+		vsr.Spec.BackupRef = volume.backupRef
+	}
+}
+
+// stopRestoreGroupMembers deletes the members of a failed group that are still restoring data
+func (r *VolumeSnapshotRestoreGroupReconciler) stopRestoreGroupMembers(ctx context.Context, group *volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup) error {
+	for _, member := range group.Status.Members {
+		switch member.Phase {
+		case volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed,
+			volsnapmoverv1alpha1.SnapMoverRestorePhasePartiallyFailed:
+			continue
+		}
+
+		vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+		vsr.Name = member.VolumeSnapshotRestore
+		vsr.Namespace = group.Namespace
+		if err := r.Delete(ctx, &vsr); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info(fmt.Sprintf("stopped member volumesnapshotrestore %s/%s of failed group", vsr.Namespace, vsr.Name))
+	}
+
+	return nil
+}
+
+// setRestoreGroupStatus records the terminal phase of a group
+func (r *VolumeSnapshotRestoreGroupReconciler) setRestoreGroupStatus(ctx context.Context, group *volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup,
+	phase volsnapmoverv1alpha1.VolumeSnapshotGroupPhase, message string) error {
+
+	status, reason := metav1.ConditionTrue, ReconciledReasonComplete
+	if phase == volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed {
+		status, reason = metav1.ConditionFalse, ReconciledReasonError
+	}
+
+	now := metav1.Now()
+	group.Status.Phase = phase
+	group.Status.CompletionTimestamp = &now
+	apimeta.SetStatusCondition(&group.Status.Conditions,
+		metav1.Condition{
+			Type:    ConditionReconciled,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+
+	return r.Status().Update(ctx, group)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VolumeSnapshotRestoreGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup{}).
+		Owns(&volsnapmoverv1alpha1.VolumeSnapshotRestore{}).
+		Complete(r)
+}
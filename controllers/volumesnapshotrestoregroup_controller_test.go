@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestVolumeSnapshotRestoreGroupReconciler_Reconcile(t *testing.T) {
+	newGroup := func(spec volsnapmoverv1alpha1.VolumeSnapshotRestoreGroupSpec) *volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup {
+		spec.ProtectedNamespace = namespace
+		spec.ResticSecretRef = corev1.LocalObjectReference{Name: "restic-secret"}
+		return &volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsrg",
+				Namespace: "bar",
+			},
+			Spec: spec,
+		}
+	}
+	newBackupGroup := func(phase volsnapmoverv1alpha1.VolumeSnapshotGroupPhase) *volsnapmoverv1alpha1.VolumeSnapshotBackupGroup {
+		return &volsnapmoverv1alpha1.VolumeSnapshotBackupGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sample-vsbg",
+				Namespace: "bar",
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotBackupGroupStatus{
+				Phase: phase,
+				Members: []volsnapmoverv1alpha1.BackupGroupMember{
+					{VolumeSnapshotBackup: "sample-vsbg-0", BackupRef: volsnapmoverv1alpha1.VSBRef{BackedUpPVCData: volsnapmoverv1alpha1.PVCData{Name: "data"}}},
+					{VolumeSnapshotBackup: "sample-vsbg-1", BackupRef: volsnapmoverv1alpha1.VSBRef{BackedUpPVCData: volsnapmoverv1alpha1.PVCData{Name: "logs"}}},
+				},
+			},
+		}
+	}
+	newMember := func(index int, phase volsnapmoverv1alpha1.VolumeSnapshotRestorePhase) *volsnapmoverv1alpha1.VolumeSnapshotRestore {
+		return &volsnapmoverv1alpha1.VolumeSnapshotRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      groupMemberName("sample-vsrg", index),
+				Namespace: "bar",
+			},
+			Status: volsnapmoverv1alpha1.VolumeSnapshotRestoreStatus{
+				Phase:          phase,
+				SnapshotHandle: "snap-handle",
+			},
+		}
+	}
+	backupGroupRef := volsnapmoverv1alpha1.VolumeSnapshotRestoreGroupSpec{
+		BackupGroupRef: &volsnapmoverv1alpha1.VolumeSnapshotBackupReference{Name: "sample-vsbg"},
+	}
+
+	tests := []struct {
+		name        string
+		objs        []client.Object
+		wantPhase   volsnapmoverv1alpha1.VolumeSnapshotGroupPhase
+		wantMembers []string
+	}{
+		{
+			name:      "Given group without volumes or backup group -> group failed",
+			objs:      []client.Object{newGroup(volsnapmoverv1alpha1.VolumeSnapshotRestoreGroupSpec{})},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+		},
+		{
+			name:      "Given missing backup group -> group failed",
+			objs:      []client.Object{newGroup(backupGroupRef)},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+		},
+		{
+			name:      "Given backup group in progress -> wait",
+			objs:      []client.Object{newGroup(backupGroupRef), newBackupGroup(volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress)},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress,
+		},
+		{
+			name:      "Given failed backup group -> group failed",
+			objs:      []client.Object{newGroup(backupGroupRef), newBackupGroup(volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed)},
+			wantPhase: volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+		},
+		{
+			name:        "Given completed backup group -> members created",
+			objs:        []client.Object{newGroup(backupGroupRef), newBackupGroup(volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted)},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress,
+			wantMembers: []string{"data", "logs"},
+		},
+		{
+			name: "Given listed volumes -> members created",
+			objs: []client.Object{newGroup(volsnapmoverv1alpha1.VolumeSnapshotRestoreGroupSpec{
+				Volumes: []volsnapmoverv1alpha1.VSBRef{{BackedUpPVCData: volsnapmoverv1alpha1.PVCData{Name: "data"}}},
+			})},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseInProgress,
+			wantMembers: []string{"data"},
+		},
+		{
+			name: "Given all members completed -> group completed",
+			objs: []client.Object{
+				newGroup(backupGroupRef),
+				newBackupGroup(volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted),
+				newMember(0, volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted),
+			},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted,
+			wantMembers: []string{"data", "logs"},
+		},
+		{
+			name: "Given one member failed -> group failed",
+			objs: []client.Object{
+				newGroup(backupGroupRef),
+				newBackupGroup(volsnapmoverv1alpha1.SnapMoverGroupPhaseCompleted),
+				newMember(0, volsnapmoverv1alpha1.SnapMoverRestorePhaseCompleted),
+				newMember(1, volsnapmoverv1alpha1.SnapMoverRestorePhaseFailed),
+			},
+			wantPhase:   volsnapmoverv1alpha1.SnapMoverGroupPhaseFailed,
+			wantMembers: []string{"data", "logs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient, err := getFakeClientFromObjects(tt.objs...)
+			if err != nil {
+				t.Fatalf("error creating fake client, likely programmer error")
+			}
+			r := &VolumeSnapshotRestoreGroupReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				Log:           logr.Discard(),
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+			ctx := newContextForTest(tt.name)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "bar", Name: "sample-vsrg"}}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			group := volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup{}
+			if err := fakeClient.Get(ctx, req.NamespacedName, &group); err != nil {
+				t.Fatalf("unable to fetch vsrg: %v", err)
+			}
+			if group.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v", group.Status.Phase, tt.wantPhase)
+			}
+			if len(group.Status.Members) != len(tt.wantMembers) {
+				t.Fatalf("Reconcile() members = %v, want %v", group.Status.Members, tt.wantMembers)
+			}
+			for i, pvcName := range tt.wantMembers {
+				if group.Status.Members[i].PVCName != pvcName {
+					t.Errorf("Reconcile() member %d pvc = %v, want %v", i, group.Status.Members[i].PVCName, pvcName)
+				}
+
+				vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+				if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "bar", Name: groupMemberName("sample-vsrg", i)}, &vsr); err != nil {
+					t.Fatalf("unable to fetch member %d: %v", i, err)
+				}
+				// members created by the reconcile have no phase yet
+				if len(vsr.Status.Phase) == 0 && vsr.Spec.VolumeSnapshotMoverBackupref.BackedUpPVCData.Name != pvcName {
+					t.Errorf("member %d backup ref = %v", i, vsr.Spec.VolumeSnapshotMoverBackupref)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRestoreGroupMember(t *testing.T) {
+	group := &volsnapmoverv1alpha1.VolumeSnapshotRestoreGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-vsrg",
+			Namespace: "bar",
+			Labels: map[string]string{
+				restoreLabel: "restore-1",
+			},
+		},
+		Spec: volsnapmoverv1alpha1.VolumeSnapshotRestoreGroupSpec{
+			ProtectedNamespace: namespace,
+			Mover:              volsnapmoverv1alpha1.RsyncTLSDataMover,
+		},
+	}
+	volume := restoreGroupVolume{
+		vsbRef:    volsnapmoverv1alpha1.VSBRef{BackedUpPVCData: volsnapmoverv1alpha1.PVCData{Name: "data"}},
+		backupRef: &volsnapmoverv1alpha1.VolumeSnapshotBackupReference{Name: "sample-vsbg-0", Namespace: "bar"},
+	}
+
+	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{}
+	buildRestoreGroupMember(&vsr, group, volume, 0)
+	if vsr.Name != "sample-vsrg-0" || vsr.Labels[VSRGroupLabel] != "sample-vsrg" || vsr.Labels[restoreLabel] != "restore-1" {
+		t.Errorf("buildRestoreGroupMember() vsr = %v/%v labels %v", vsr.Namespace, vsr.Name, vsr.Labels)
+	}
+	if vsr.Spec.BackupRef == nil || vsr.Spec.BackupRef.Name != "sample-vsbg-0" {
+		t.Errorf("buildRestoreGroupMember() clone mover backupRef = %v", vsr.Spec.BackupRef)
+	}
+}
@@ -28,11 +28,13 @@ import (
 
 	volsyncv1alpha1 "github.com/backube/volsync/api/v1alpha1"
 	pvcv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	pvcv1beta1 "github.com/konveyor/volume-snapshot-mover/api/v1beta1"
 	"github.com/konveyor/volume-snapshot-mover/controllers"
 	snapv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	velero "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -50,6 +52,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(pvcv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(pvcv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -57,11 +60,13 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var keyDir string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&keyDir, "key-dir", controllers.DefaultKeyDir, "The directory holding the key encryption keys of the file key provider.")
 	opts := zap.Options{
 		Development: true,
 		TimeEncoder: func(t time.Time, encoder zapcore.PrimitiveArrayEncoder) {
@@ -96,23 +101,124 @@ func main() {
 		setupLog.Error(err, "unable to add v1.Velero APIs to scheme")
 		os.Exit(1)
 	}
+	backupScheduler := controllers.NewBatchScheduler()
+	restoreScheduler := controllers.NewBatchScheduler()
+
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create clientset")
+		os.Exit(1)
+	}
+	progressCollector := &controllers.ProgressCollector{Clientset: clientset}
+	credentialResolver := controllers.NewCredentialResolver(clientset)
+	keyProviders := controllers.KeyProviders{
+		controllers.FileKeyProvider: controllers.NewFileKeyProvider(keyDir),
+	}
+
 	if err = (&controllers.VolumeSnapshotBackupReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		EventRecorder: mgr.GetEventRecorderFor("VolumeSnapshotBackup-Controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		EventRecorder:      mgr.GetEventRecorderFor("VolumeSnapshotBackup-Controller"),
+		Scheduler:          backupScheduler,
+		ProgressCollector:  progressCollector,
+		CredentialResolver: credentialResolver,
+		KeyProviders:       keyProviders,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotBackup")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.VolumeSnapshotRestoreReconciler{
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		EventRecorder:      mgr.GetEventRecorderFor("VolumeSnapshotRestore-Controller"),
+		Scheduler:          restoreScheduler,
+		ProgressCollector:  progressCollector,
+		CredentialResolver: credentialResolver,
+		KeyProviders:       keyProviders,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotRestore")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.DataMoverConfigReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		BackupScheduler:  backupScheduler,
+		RestoreScheduler: restoreScheduler,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DataMoverConfig")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.DataMoverConfigMapReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DataMoverConfigMap")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.VolumeSnapshotRepositoryReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Clientset: clientset,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotRepository")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.VolumeSnapshotBackupGroupReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
-		EventRecorder: mgr.GetEventRecorderFor("VolumeSnapshotRestore-Controller"),
+		EventRecorder: mgr.GetEventRecorderFor("VolumeSnapshotBackupGroup-Controller"),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotRestore")
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotBackupGroup")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.VolumeSnapshotRestoreGroupReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("VolumeSnapshotRestoreGroup-Controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSnapshotRestoreGroup")
 		os.Exit(1)
 	}
+
+	if err = (&controllers.RepositoryMaintenanceReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("RepositoryMaintenance-Controller"),
+		Clientset:     clientset,
+		KeyProviders:  keyProviders,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RepositoryMaintenance")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ResticKeyRotationReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("ResticKeyRotation-Controller"),
+		Clientset:     clientset,
+		KeyProviders:  keyProviders,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ResticKeyRotation")
+		os.Exit(1)
+	}
+
+	// webhooks need a serving certificate, allow running locally without them
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&pvcv1alpha1.VolumeSnapshotBackup{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VolumeSnapshotBackup")
+			os.Exit(1)
+		}
+		if err = (&pvcv1alpha1.VolumeSnapshotRestore{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VolumeSnapshotRestore")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,208 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifest defines the snapshot manifest written next to the restic repository
+// of every completed volumesnapshotbackup, <repository>/<namespace>/<backup>/<pvc>-manifest.json.
+// Repositories shared by the backups of a volume hold one manifest per backup,
+// <repository>/<namespace>/volumes/<pvc namespace>/<pvc uid>-<backup>-manifest.json.
+// The manifest holds everything needed to create the volumesnapshotrestore of the snapshot,
+// so a backup can be restored without the velero backup it was created by.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// V1 is the first version of the manifest schema
+	V1 = "v1"
+
+	// Version is the version of the manifests written by the controller
+	Version = V1
+
+	// FileSuffix is appended to the restic repository path to get the manifest path
+	FileSuffix = "-manifest.json"
+)
+
+// Manifest describes a volume snapshot stored in a restic repository
+type Manifest struct {
+	// version of the manifest schema
+	Version string `json:"version"`
+	// time the manifest was written
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	// name of the velero backup
+	BackupName string `json:"backupName"`
+	// name and namespace of the volumesnapshotbackup that wrote the snapshot
+	VolumeSnapshotBackup Reference `json:"volumeSnapshotBackup"`
+	// data mover used to move the volume data
+	Mover volsnapmoverv1alpha1.DataMoverType `json:"mover,omitempty"`
+	// restic repository path of the snapshot
+	Repository string `json:"repository"`
+	// layout of the restic repository, the volume layout shares the repository between the backups of the PVC
+	RepositoryLayout volsnapmoverv1alpha1.RepositoryLayout `json:"repositoryLayout,omitempty"`
+	// time the snapshot was taken, identifies the snapshot of the backup in a shared repository
+	SnapshotTimestamp *metav1.Time `json:"snapshotTimestamp,omitempty"`
+	// id of the snapshot of the backup in a shared repository, checked against the restored snapshot
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// name of the VolumeSnapshotClass of the backed up volume
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// backed up PersistentVolumeClaim
+	PVC PersistentVolumeClaim `json:"pvc"`
+	// id of the volumesnapshotbackupgroup the snapshot was moved with, the snapshots
+	// sharing a group id were taken together and should be restored together
+	Group string `json:"group,omitempty"`
+}
+
+// Reference identifies a namespaced object
+type Reference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// PersistentVolumeClaim holds the backed up PersistentVolumeClaim
+type PersistentVolumeClaim struct {
+	Name             string                              `json:"name"`
+	Namespace        string                              `json:"namespace"`
+	Size             string                              `json:"size"`
+	StorageClassName string                              `json:"storageClassName,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	VolumeMode       *corev1.PersistentVolumeMode        `json:"volumeMode,omitempty"`
+	Labels           map[string]string                   `json:"labels,omitempty"`
+	Annotations      map[string]string                   `json:"annotations,omitempty"`
+	UID              types.UID                           `json:"uid,omitempty"`
+}
+
+// versionHeader is decoded first to pick the schema of the manifest
+type versionHeader struct {
+	Version string `json:"version"`
+}
+
+// Path returns the path of the manifest of the given restic repository
+func Path(repository string) string {
+	return repository + FileSuffix
+}
+
+// BackupPath returns the path of the manifest of the given backup in a restic repository shared by several backups
+func BackupPath(repository string, backupName string) string {
+	return fmt.Sprintf("%s-%s%s", repository, backupName, FileSuffix)
+}
+
+// FilePath returns the path the manifest is written to
+func (m *Manifest) FilePath() string {
+	if m.RepositoryLayout == volsnapmoverv1alpha1.RepositoryLayoutVolume {
+		return BackupPath(m.Repository, m.BackupName)
+	}
+
+	return Path(m.Repository)
+}
+
+// Validate returns an error if the manifest cannot be used to restore the snapshot
+func (m *Manifest) Validate() error {
+	if m.Version != V1 {
+		return errors.New(fmt.Sprintf("unsupported manifest version %q", m.Version))
+	}
+	if len(m.Repository) == 0 {
+		return errors.New("manifest repository cannot be empty")
+	}
+	if len(m.PVC.Name) == 0 {
+		return errors.New("manifest pvc name cannot be empty")
+	}
+	if len(m.PVC.Size) == 0 {
+		return errors.New("manifest pvc size cannot be empty")
+	}
+	if m.RepositoryLayout == volsnapmoverv1alpha1.RepositoryLayoutVolume && (len(m.BackupName) == 0 || m.SnapshotTimestamp == nil) {
+		return errors.New("manifest of a shared repository needs a backup name and snapshot timestamp")
+	}
+
+	return nil
+}
+
+// Marshal returns the single line JSON encoding of the manifest, written with the current version when unset
+func Marshal(m *Manifest) ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("nil manifest in Marshal")
+	}
+
+	out := *m
+	if len(out.Version) == 0 {
+		out.Version = Version
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&out)
+}
+
+// Unmarshal decodes a manifest of any supported version
+func Unmarshal(data []byte) (*Manifest, error) {
+	header := versionHeader{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.Version {
+	case V1:
+		m := Manifest{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		return &m, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("unsupported manifest version %q", header.Version))
+}
+
+// VolumeSnapshotRestoreSpec returns the spec of the volumesnapshotrestore of the snapshot, restoring
+// with the given restic secret of the BSL into the given protected namespace
+func (m *Manifest) VolumeSnapshotRestoreSpec(resticSecretName string, protectedNamespace string) volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec {
+	return volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+		ResticSecretRef: corev1.LocalObjectReference{Name: resticSecretName},
+		VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+			BackedUpPVCData:         m.PVCData(),
+			ResticRepository:        m.Repository,
+			VolumeSnapshotClassName: m.VolumeSnapshotClassName,
+			SnapshotTimestamp:       m.SnapshotTimestamp,
+			SnapshotID:              m.SnapshotID,
+		},
+		ProtectedNamespace: protectedNamespace,
+		Mover:              m.Mover,
+	}
+}
+
+// PVCData returns the backed up PVC data of the snapshot
+func (m *Manifest) PVCData() volsnapmoverv1alpha1.PVCData {
+	return volsnapmoverv1alpha1.PVCData{
+		Name:             m.PVC.Name,
+		Size:             m.PVC.Size,
+		StorageClassName: m.PVC.StorageClassName,
+		AccessModes:      m.PVC.AccessModes,
+		VolumeMode:       m.PVC.VolumeMode,
+		Labels:           m.PVC.Labels,
+		Annotations:      m.PVC.Annotations,
+		UID:              m.PVC.UID,
+	}
+}
@@ -0,0 +1,152 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+
+	volsnapmoverv1alpha1 "github.com/konveyor/volume-snapshot-mover/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestManifest() *Manifest {
+	volumeMode := corev1.PersistentVolumeFilesystem
+	return &Manifest{
+		CreationTimestamp: metav1.Unix(1700000000, 0).Rfc3339Copy(),
+		BackupName:        "backup-1",
+		VolumeSnapshotBackup: Reference{
+			Name:      "vsb-abcde",
+			Namespace: "app",
+		},
+		Mover:                   volsnapmoverv1alpha1.ResticDataMover,
+		Repository:              "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc",
+		VolumeSnapshotClassName: "csi-snapclass",
+		PVC: PersistentVolumeClaim{
+			Name:             "mysql",
+			Namespace:        "app",
+			Size:             "10Gi",
+			StorageClassName: "gp3-csi",
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			VolumeMode:       &volumeMode,
+			Labels:           map[string]string{"app": "mysql"},
+			Annotations:      map[string]string{"backup.example.com/owner": "dba"},
+		},
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	m := newTestManifest()
+	data, err := Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := newTestManifest()
+	want.Version = Version
+	if !equality.Semantic.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name:    "Given v1 manifest -> no error",
+			data:    `{"version":"v1","repository":"s3:s3.amazonaws.com/bucket/ns/backup/pvc","pvc":{"name":"mysql","namespace":"app","size":"1Gi"}}`,
+			wantErr: false,
+		},
+		{
+			name:    "Given unknown version -> error",
+			data:    `{"version":"v2","repository":"s3:s3.amazonaws.com/bucket/ns/backup/pvc","pvc":{"name":"mysql","namespace":"app","size":"1Gi"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Given manifest without version -> error",
+			data:    `{"repository":"s3:s3.amazonaws.com/bucket/ns/backup/pvc","pvc":{"name":"mysql","namespace":"app","size":"1Gi"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Given manifest without pvc size -> error",
+			data:    `{"version":"v1","repository":"s3:s3.amazonaws.com/bucket/ns/backup/pvc","pvc":{"name":"mysql","namespace":"app"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Given malformed manifest -> error",
+			data:    `{"version":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManifest_VolumeSnapshotRestoreSpec(t *testing.T) {
+	volumeMode := corev1.PersistentVolumeFilesystem
+	got := newTestManifest().VolumeSnapshotRestoreSpec("restic-secret", "openshift-adp")
+	want := volsnapmoverv1alpha1.VolumeSnapshotRestoreSpec{
+		ResticSecretRef: corev1.LocalObjectReference{Name: "restic-secret"},
+		VolumeSnapshotMoverBackupref: volsnapmoverv1alpha1.VSBRef{
+			BackedUpPVCData: volsnapmoverv1alpha1.PVCData{
+				Name:             "mysql",
+				Size:             "10Gi",
+				StorageClassName: "gp3-csi",
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				VolumeMode:       &volumeMode,
+				Labels:           map[string]string{"app": "mysql"},
+				Annotations:      map[string]string{"backup.example.com/owner": "dba"},
+			},
+			ResticRepository:        "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc",
+			VolumeSnapshotClassName: "csi-snapclass",
+		},
+		ProtectedNamespace: "openshift-adp",
+		Mover:              volsnapmoverv1alpha1.ResticDataMover,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VolumeSnapshotRestoreSpec() got = %+v, want %+v", got, want)
+	}
+
+	vsr := volsnapmoverv1alpha1.VolumeSnapshotRestore{Spec: got}
+	if errs := vsr.ValidateSpec(); len(errs) > 0 {
+		t.Errorf("VolumeSnapshotRestoreSpec() is not valid: %v", errs.ToAggregate())
+	}
+}
+
+func TestManifest_FilePath(t *testing.T) {
+	m := newTestManifest()
+	if got := m.FilePath(); got != "s3:s3.amazonaws.com/bucket/openshift-adp/backup-1/snapcontent-a-pvc-manifest.json" {
+		t.Errorf("FilePath() got = %v", got)
+	}
+
+	snapshotTimestamp := metav1.Unix(1700000000, 0)
+	m.Repository = "s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid"
+	m.RepositoryLayout = volsnapmoverv1alpha1.RepositoryLayoutVolume
+	m.SnapshotTimestamp = &snapshotTimestamp
+	m.Version = Version
+	if got := m.FilePath(); got != "s3:s3.amazonaws.com/bucket/openshift-adp/volumes/app/pvc-uid-backup-1-manifest.json" {
+		t.Errorf("FilePath() got = %v", got)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	m.SnapshotTimestamp = nil
+	if err := m.Validate(); err == nil {
+		t.Errorf("Validate() of a shared repository manifest without snapshot timestamp should return an error")
+	}
+}